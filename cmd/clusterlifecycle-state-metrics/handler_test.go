@@ -0,0 +1,101 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	ocollectors "github.com/open-cluster-management/clusterlifecycle-state-metrics/pkg/collectors"
+)
+
+const testMetrics = `# HELP acm_managed_cluster_info Managed cluster information
+# TYPE acm_managed_cluster_info gauge
+acm_managed_cluster_info{managed_cluster_id="cluster_id_1",hub_cluster_id="hub_id"} 1
+acm_managed_cluster_info{hub_cluster_id="hub_id",managed_cluster_id="cluster_id_2"} 1
+acm_managed_cluster_info{hub_cluster_id="hub_id",managed_cluster_id="cluster_id_10"} 1
+`
+
+func Test_filterClusterMetrics(t *testing.T) {
+	header := `# HELP acm_managed_cluster_info Managed cluster information
+# TYPE acm_managed_cluster_info gauge
+`
+	tests := []struct {
+		name       string
+		clusterIDs []string
+		want       string
+	}{
+		{
+			name:       "no match",
+			clusterIDs: []string{"cluster-3"},
+			want:       header,
+		},
+		{
+			name:       "first label",
+			clusterIDs: []string{"cluster-1", "cluster_id_1"},
+			want:       header + `acm_managed_cluster_info{managed_cluster_id="cluster_id_1",hub_cluster_id="hub_id"} 1` + "\n",
+		},
+		{
+			name:       "later label",
+			clusterIDs: []string{"cluster-2", "cluster_id_2"},
+			want:       header + `acm_managed_cluster_info{hub_cluster_id="hub_id",managed_cluster_id="cluster_id_2"} 1` + "\n",
+		},
+		{
+			name:       "prefix of another id",
+			clusterIDs: []string{"cluster_id"},
+			want:       header,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			filterClusterMetrics(buf, []byte(testMetrics), tt.clusterIDs)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("filterClusterMetrics() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+type testWriter string
+
+func (w testWriter) WriteAll(out io.Writer) {
+	out.Write([]byte(w))
+}
+
+func Test_metricHandler_ServeHTTP_gzip(t *testing.T) {
+	handler := &metricHandler{
+		collectors: []ocollectors.MetricsWriter{testWriter(testMetrics)},
+		clusterIDs: func(name string) []string {
+			return []string{name, "cluster_id_2"}
+		},
+		enableGZIPEncoding: true,
+	}
+	req := httptest.NewRequest("GET", metricsPath+"?cluster=cluster-2", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	r, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP acm_managed_cluster_info Managed cluster information
+# TYPE acm_managed_cluster_info gauge
+acm_managed_cluster_info{hub_cluster_id="hub_id",managed_cluster_id="cluster_id_2"} 1
+`
+	if string(body) != want {
+		t.Errorf("body =\n%s\nwant:\n%s", body, want)
+	}
+}
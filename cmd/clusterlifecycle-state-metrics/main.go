@@ -4,6 +4,7 @@
 package main
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
 	"fmt"
@@ -13,18 +14,23 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/operator-framework/operator-sdk/pkg/log/zap"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/klog/v2"
 
-	metricsstore "k8s.io/kube-state-metrics/pkg/metrics_store"
 	koptions "k8s.io/kube-state-metrics/pkg/options"
 	"k8s.io/kube-state-metrics/pkg/whiteblacklist"
 
@@ -38,6 +44,8 @@ const (
 	leaderConfigMapName = "clusterlifecycle-state-metrics-lock"
 	metricsPath         = "/metrics"
 	healthzPath         = "/healthz"
+	// clusterQueryParam restricts the /metrics output to a single managed cluster
+	clusterQueryParam = "cluster"
 )
 
 var opts *options.Options
@@ -74,6 +82,10 @@ func main() {
 func start(opts *options.Options) {
 	collectorBuilder := ocollectors.NewBuilder(context.TODO())
 	collectorBuilder.WithApiserver(opts.Apiserver).WithKubeConfig(opts.Kubeconfig)
+	if opts.KubeContexts != "" {
+		klog.Infof("Using %s kube contexts", opts.KubeContexts)
+		collectorBuilder.WithKubeContexts(strings.Split(opts.KubeContexts, ","))
+	}
 	if len(opts.Collectors) == 0 {
 		klog.Info("Using default collectors")
 		collectorBuilder.WithEnabledCollectors(options.DefaultCollectors.AsSlice())
@@ -101,6 +113,57 @@ func start(opts *options.Options) {
 	klog.Infof("metric white- blacklisting: %v", whiteBlackList.Status())
 
 	collectorBuilder.WithWhiteBlackList(whiteBlackList)
+	collectorBuilder.WithMaxLabelValueLength(opts.MaxLabelValueLength)
+	collectorBuilder.WithConstLabels(opts.ConstLabels)
+	collectorBuilder.WithMetricsCacheTTL(opts.MetricsCacheTTL)
+	collectorBuilder.WithProviderClusterIDClaim(opts.ProviderClusterIDClaim)
+	collectorBuilder.WithAPIURLLabel(opts.APIURLLabel)
+	collectorBuilder.WithClusterUIDLabel(opts.ClusterUIDLabel)
+	collectorBuilder.WithAutoscalerClaim(opts.AutoscalerClaim)
+	collectorBuilder.WithFIPSClaim(opts.FIPSClaim)
+	collectorBuilder.WithEtcdEncryptionClaim(opts.EtcdEncryptionClaim)
+	collectorBuilder.WithHubClusterID(opts.HubClusterID)
+	collectorBuilder.WithRequestTimeout(opts.RequestTimeout)
+	collectorBuilder.WithWorkerResourceNames(opts.CoreWorkerResource, opts.SocketWorkerResource, opts.MemoryWorkerResource)
+	collectorBuilder.WithClusterSet(opts.ClusterSet)
+	if opts.ClusterSelector != "" {
+		selector, err := labels.Parse(opts.ClusterSelector)
+		if err != nil {
+			klog.Fatalf("invalid --cluster-selector %s: %v", opts.ClusterSelector, err)
+		}
+		collectorBuilder.WithClusterSelector(selector)
+	}
+	collectorBuilder.WithClusterCreatedWithin(opts.ClusterCreatedWithin)
+	collectorBuilder.WithClusterNameLabel(opts.ClusterNameLabel)
+	collectorBuilder.WithListPageSize(opts.ListPageSize)
+	collectorBuilder.WithInstanceTypeMetrics(opts.InstanceTypeMetrics)
+	collectorBuilder.WithSplitInfoMetrics(opts.SplitInfoMetrics)
+	collectorBuilder.WithCollapsedPrereleaseVersions(opts.CollapsePrerelease)
+	if opts.ExcludeVendors != "" {
+		collectorBuilder.WithExcludedVendors(strings.Split(opts.ExcludeVendors, ","))
+	}
+	if opts.ExcludeClouds != "" {
+		collectorBuilder.WithExcludedClouds(strings.Split(opts.ExcludeClouds, ","))
+	}
+	if opts.RequiredAddOns != "" {
+		collectorBuilder.WithRequiredAddOns(strings.Split(opts.RequiredAddOns, ","))
+	}
+	if opts.InfoLabels != "" {
+		collectorBuilder.WithInfoLabels(strings.Split(opts.InfoLabels, ","))
+	}
+	if opts.CapacityResources != "" {
+		collectorBuilder.WithCapacityResources(strings.Split(opts.CapacityResources, ","))
+	}
+	collectorBuilder.WithCapacityViewMaxAge(opts.CapacityViewMaxAge)
+	collectorBuilder.WithMachineSetMetrics(opts.MachineSetMetrics)
+	collectorBuilder.WithClusterVersionViews(opts.ClusterVersionViews)
+	if opts.CAPIClusterResource != "" {
+		gvr, _ := schema.ParseResourceArg(opts.CAPIClusterResource)
+		if gvr == nil {
+			klog.Fatalf("invalid --capi-cluster-resource %s, expected resource.version.group", opts.CAPIClusterResource)
+		}
+		collectorBuilder.WithCAPIClusterResource(*gvr)
+	}
 
 	ocmMetricsRegistry := prometheus.NewRegistry()
 	if err := ocmMetricsRegistry.Register(ocollectors.ResourcesPerScrapeMetric); err != nil {
@@ -109,6 +172,38 @@ func start(opts *options.Options) {
 	if err := ocmMetricsRegistry.Register(ocollectors.ScrapeErrorTotalMetric); err != nil {
 		panic(err)
 	}
+	if err := ocmMetricsRegistry.Register(ocollectors.LastCollectionTimestampMetric); err != nil {
+		panic(err)
+	}
+	if err := ocmMetricsRegistry.Register(ocollectors.CollectorGenerateDurationMetric); err != nil {
+		panic(err)
+	}
+	if err := ocmMetricsRegistry.Register(ocollectors.APIVersionMismatchMetric); err != nil {
+		panic(err)
+	}
+	if err := ocmMetricsRegistry.Register(ocollectors.CacheSizeMetric); err != nil {
+		panic(err)
+	}
+	if err := ocmMetricsRegistry.Register(ocollectors.RBACDeniedMetric); err != nil {
+		panic(err)
+	}
+	if err := ocmMetricsRegistry.Register(ocollectors.CollectorErrorsMetric); err != nil {
+		panic(err)
+	}
+	if err := ocmMetricsRegistry.Register(ocollectors.DuplicateManagedClusterInfoMetric); err != nil {
+		panic(err)
+	}
+	if err := ocmMetricsRegistry.Register(ocollectors.DistributionMismatchMetric); err != nil {
+		panic(err)
+	}
+	if err := ocmMetricsRegistry.Register(ocollectors.APIRequestsMetric); err != nil {
+		panic(err)
+	}
+	buildInfo := version.GetVersion()
+	ocollectors.BuildInfoMetric.WithLabelValues(buildInfo.Release, buildInfo.GitCommit, buildInfo.GoVersion).Set(1)
+	if err := ocmMetricsRegistry.Register(ocollectors.BuildInfoMetric); err != nil {
+		panic(err)
+	}
 	if err := ocmMetricsRegistry.Register(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{})); err != nil {
 		panic(err)
 	}
@@ -127,7 +222,17 @@ func start(opts *options.Options) {
 
 	collectors := collectorBuilder.Build()
 
-	serveMetrics(collectors, opts.Host, opts.HTTPPort, opts.HTTPSPort, opts.TLSCrtFile, opts.TLSKeyFile, opts.EnableGZIPEncoding)
+	if opts.PushgatewayURL != "" {
+		// On SIGTERM the metrics are pushed a last time before exiting.
+		pushCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			pushMetrics(pushCtx, collectors, opts.PushgatewayURL, opts.PushgatewayJob, opts.PushgatewayInterval)
+			stop()
+			os.Exit(0)
+		}()
+	}
+
+	serveMetrics(collectors, collectorBuilder.ClusterIDs, opts.Host, opts.HTTPPort, opts.HTTPSPort, opts.TLSCrtFile, opts.TLSKeyFile, opts.EnableGZIPEncoding)
 }
 
 func telemetryServer(
@@ -182,7 +287,36 @@ func telemetryServer(
 	log.Fatal(http.ListenAndServe(listenAddress, mux))
 }
 
-func serveMetrics(collectors []*metricsstore.MetricsStore,
+// pushMetrics pushes the metrics of the collectors to the Pushgateway on
+// each interval, the push replaces the metrics previously pushed for the job.
+// The metrics are pushed a last time when the context is done.
+func pushMetrics(ctx context.Context,
+	collectors []ocollectors.MetricsWriter,
+	url string,
+	job string,
+	interval time.Duration) {
+	pusher := push.New(url, job).Gatherer(ocollectors.NewGatherer(collectors))
+	klog.Infof("Pushing metrics to %s every %s", url, interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := pusher.Push(); err != nil {
+			klog.Errorf("Error pushing metrics to %s: %v", url, err)
+		}
+		select {
+		case <-ctx.Done():
+			klog.Infof("Pushing metrics to %s before shutdown", url)
+			if err := pusher.Push(); err != nil {
+				klog.Errorf("Error pushing metrics to %s: %v", url, err)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func serveMetrics(collectors []ocollectors.MetricsWriter,
+	clusterIDs func(name string) []string,
 	host string,
 	httpPort int,
 	httpsPort int,
@@ -199,7 +333,7 @@ func serveMetrics(collectors []*metricsstore.MetricsStore,
 	mux.Handle("/debug/pprof/trace", http.HandlerFunc(pprof.Trace))
 
 	// Add metricsPath
-	mux.Handle(metricsPath, &metricHandler{collectors, enableGZIPEncoding})
+	mux.Handle(metricsPath, &metricHandler{collectors, clusterIDs, enableGZIPEncoding})
 	// Add healthzPath
 	mux.HandleFunc(healthzPath, func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(200)
@@ -241,7 +375,10 @@ func serveMetrics(collectors []*metricsstore.MetricsStore,
 }
 
 type metricHandler struct {
-	collectors         []*metricsstore.MetricsStore
+	collectors []ocollectors.MetricsWriter
+	// clusterIDs resolves the name of the cluster query parameter to the
+	// managed_cluster_id values of its series
+	clusterIDs         func(name string) []string
 	enableGZIPEncoding bool
 }
 
@@ -265,8 +402,19 @@ func (m *metricHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	cluster := r.URL.Query().Get(clusterQueryParam)
+	var clusterIDs []string
+	if cluster != "" {
+		clusterIDs = m.clusterIDs(cluster)
+	}
 	for _, c := range m.collectors {
-		c.WriteAll(w)
+		if cluster == "" {
+			c.WriteAll(writer)
+			continue
+		}
+		buf := new(bytes.Buffer)
+		c.WriteAll(buf)
+		filterClusterMetrics(writer, buf.Bytes(), clusterIDs)
 	}
 
 	// In case we gziped the response, we have to close the writer.
@@ -276,3 +424,31 @@ func (m *metricHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+// filterClusterMetrics writes the family headers and only the metrics
+// having the managed_cluster_id label equal to one of the given cluster ids.
+func filterClusterMetrics(w io.Writer, metrics []byte, clusterIDs []string) {
+	clusterLabels := make([][]byte, 0, len(clusterIDs))
+	for _, id := range clusterIDs {
+		clusterLabels = append(clusterLabels, []byte(`managed_cluster_id="`+id+`"`))
+	}
+	for _, line := range bytes.SplitAfter(metrics, []byte{'\n'}) {
+		if bytes.HasPrefix(line, []byte("#")) || hasClusterLabel(line, clusterLabels) {
+			if _, err := w.Write(line); err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+// hasClusterLabel returns true when the series has one of the cluster labels,
+// as its first label or as a later one.
+func hasClusterLabel(line []byte, clusterLabels [][]byte) bool {
+	for _, label := range clusterLabels {
+		if bytes.Contains(line, append([]byte{'{'}, label...)) ||
+			bytes.Contains(line, append([]byte{','}, label...)) {
+			return true
+		}
+	}
+	return false
+}
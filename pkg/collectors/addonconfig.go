@@ -0,0 +1,140 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+var (
+	descAddOnUnsupportedConfigName   = "acm_managed_cluster_addon_unsupported_config"
+	descAddOnUnsupportedConfigHelp   = "Managed cluster addon referencing a config kind not supported by its ClusterManagementAddOn"
+	descAddOnUnsupportedConfigLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"addon"}
+
+	cmaGVR = schema.GroupVersionResource{
+		Group:    "addon.open-cluster-management.io",
+		Version:  "v1alpha1",
+		Resource: "clustermanagementaddons",
+	}
+)
+
+// addOnConfigResource is the kind of a config of an addon, as referenced by
+// the configs of the ManagedClusterAddOns and the supportedConfigs of the
+// ClusterManagementAddOns. The configs are not part of the compiled-in addon
+// API, so they are read from the unstructured objects.
+type addOnConfigResource struct {
+	Group    string `json:"group,omitempty"`
+	Resource string `json:"resource"`
+}
+
+// getAddOnUnsupportedConfigMetricFamilies returns the families checking the
+// configs of the addons are supported by their ClusterManagementAddOn. They
+// are computed by the fleet collector which reflects the addons and the
+// ClusterManagementAddOns.
+func getAddOnUnsupportedConfigMetricFamilies(hubClusterID string, settings clusterSettings) []metric.FamilyGenerator {
+	return []metric.FamilyGenerator{
+		{
+			Name: descAddOnUnsupportedConfigName,
+			Type: metric.Gauge,
+			Help: descAddOnUnsupportedConfigHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				clusterIDs := map[string]string{}
+				for _, mci := range f.managedClusterInfos {
+					clusterIDs[settings.clusterNameFor(mci)] = settings.getClusterID(mci)
+				}
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, mca := range f.managedClusterAddOns {
+					clusterID := clusterIDs[mca.GetNamespace()]
+					if clusterID == "" {
+						continue
+					}
+					// Without its ClusterManagementAddOn, the supported
+					// configs of the addon are not known.
+					supported, ok := f.supportedAddOnConfigs[mca.GetName()]
+					if !ok {
+						continue
+					}
+					unsupported := 0.0
+					if hasUnsupportedConfig(f.addOnConfigs[mca.GetNamespace()+"/"+mca.GetName()], supported) {
+						unsupported = 1
+					}
+					family.Metrics = append(family.Metrics, &metric.Metric{
+						LabelKeys:   descAddOnUnsupportedConfigLabels,
+						LabelValues: []string{hubClusterID, clusterID, mca.GetName()},
+						Value:       unsupported,
+					})
+				}
+				return family
+			}),
+		},
+	}
+}
+
+// hasUnsupportedConfig returns true if one of the configs is not in the
+// supported configs.
+func hasUnsupportedConfig(configs, supported []addOnConfigResource) bool {
+	kinds := map[addOnConfigResource]bool{}
+	for _, s := range supported {
+		kinds[s] = true
+	}
+	for _, c := range configs {
+		if !kinds[addOnConfigResource{Group: c.Group, Resource: c.Resource}] {
+			return true
+		}
+	}
+	return false
+}
+
+// getAddOnConfigResources returns the config kinds listed at the path of the
+// addon object, spec.configs for a ManagedClusterAddOn and
+// spec.supportedConfigs for a ClusterManagementAddOn.
+func getAddOnConfigResources(u *unstructured.Unstructured, fields ...string) ([]addOnConfigResource, error) {
+	configsU, _, err := unstructured.NestedSlice(u.Object, fields...)
+	if err != nil {
+		return nil, err
+	}
+	configs := []addOnConfigResource{}
+	for _, c := range configsU {
+		cU, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		config := addOnConfigResource{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(cU, &config); err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+func createClusterManagementAddOnListWatch(config *rest.Config, timeout time.Duration) cache.ListWatch {
+	client := dynamic.NewForConfigOrDie(config)
+	return createClusterManagementAddOnListWatchWithClient(client, timeout)
+}
+
+func createClusterManagementAddOnListWatchWithClient(client dynamic.Interface, timeout time.Duration) cache.ListWatch {
+	return cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			ctx, cancel := requestContext(cmaGVR.Resource, timeout)
+			defer cancel()
+			return client.Resource(cmaGVR).List(ctx, opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return client.Resource(cmaGVR).Watch(context.TODO(), opts)
+		},
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"testing"
+
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+func newAddOnWithConfigsU(t *testing.T, namespace, name string, configs ...addOnConfigResource) *unstructured.Unstructured {
+	u := newAddOnWithConditionU(t, namespace, name, nil)
+	if err := unstructured.SetNestedSlice(u.Object, addOnConfigResourcesU(configs), "spec", "configs"); err != nil {
+		t.Error(err)
+	}
+	return u
+}
+
+func newClusterManagementAddOnU(t *testing.T, name string, supported ...addOnConfigResource) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(cmaGVR.GroupVersion().String())
+	u.SetKind("ClusterManagementAddOn")
+	u.SetName(name)
+	if err := unstructured.SetNestedSlice(u.Object, addOnConfigResourcesU(supported), "spec", "supportedConfigs"); err != nil {
+		t.Error(err)
+	}
+	return u
+}
+
+func addOnConfigResourcesU(configs []addOnConfigResource) []interface{} {
+	configsU := []interface{}{}
+	for _, c := range configs {
+		configsU = append(configsU, map[string]interface{}{"group": c.Group, "resource": c.Resource})
+	}
+	return configsU
+}
+
+func Test_getAddOnUnsupportedConfigMetricFamilies(t *testing.T) {
+	mci1 := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Namespace: "cluster-1"},
+		Status:     mciv1beta1.ClusterInfoStatus{ClusterID: "cluster_id_1"},
+	})
+	deploymentConfig := addOnConfigResource{Group: "addon.open-cluster-management.io", Resource: "addondeploymentconfigs"}
+	configMap := addOnConfigResource{Resource: "configmaps"}
+	searchCMA := newClusterManagementAddOnU(t, "search-collector", deploymentConfig)
+
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         []interface{}{mci1, searchCMA, newAddOnWithConfigsU(t, "cluster-1", "search-collector", deploymentConfig)},
+			MetricNames: []string{"acm_managed_cluster_addon_unsupported_config"},
+			Want:        `acm_managed_cluster_addon_unsupported_config{hub_cluster_id="mycluster_id",managed_cluster_id="cluster_id_1",addon="search-collector"} 0`,
+		},
+		{
+			Obj:         []interface{}{mci1, searchCMA, newAddOnWithConfigsU(t, "cluster-1", "search-collector", deploymentConfig, configMap)},
+			MetricNames: []string{"acm_managed_cluster_addon_unsupported_config"},
+			Want:        `acm_managed_cluster_addon_unsupported_config{hub_cluster_id="mycluster_id",managed_cluster_id="cluster_id_1",addon="search-collector"} 1`,
+		},
+		{
+			Obj:         []interface{}{mci1, searchCMA, newAddOnWithConditionU(t, "cluster-1", "search-collector", nil)},
+			MetricNames: []string{"acm_managed_cluster_addon_unsupported_config"},
+			Want:        `acm_managed_cluster_addon_unsupported_config{hub_cluster_id="mycluster_id",managed_cluster_id="cluster_id_1",addon="search-collector"} 0`,
+		},
+		{
+			Obj:         []interface{}{mci1, newAddOnWithConfigsU(t, "cluster-1", "search-collector", configMap)},
+			MetricNames: []string{"acm_managed_cluster_addon_unsupported_config"},
+			Want:        "",
+		},
+		{
+			Obj:         []interface{}{searchCMA, newAddOnWithConfigsU(t, "cluster-1", "search-collector", configMap)},
+			MetricNames: []string{"acm_managed_cluster_addon_unsupported_config"},
+			Want:        "",
+		},
+	}
+	for i, c := range tests {
+		c.Func = withFleet(metric.ComposeMetricGenFuncs(getAddOnUnsupportedConfigMetricFamilies("mycluster_id", defaultClusterSettings)))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"net/http"
+	"strings"
+
+	"k8s.io/client-go/rest"
+)
+
+// apiRequestsRoundTripper counts the requests sent to the apiserver by
+// acm_state_metrics_api_requests_total.
+type apiRequestsRoundTripper struct {
+	rt http.RoundTripper
+}
+
+func (r *apiRequestsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resource, verb := getRequestResourceVerb(req)
+	APIRequestsMetric.WithLabelValues(resource, verb).Inc()
+	return r.rt.RoundTrip(req)
+}
+
+// withAPIRequestsCount wraps the transport of the config so the requests of
+// its clients are counted.
+func withAPIRequestsCount(config *rest.Config) *rest.Config {
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &apiRequestsRoundTripper{rt: rt}
+	})
+	return config
+}
+
+// getRequestResourceVerb returns the resource and the verb of a request to
+// the apiserver, ie: managedclusterinfos and list for
+// GET /apis/internal.open-cluster-management.io/v1beta1/namespaces/c1/managedclusterinfos.
+// The requests outside of the resource APIs, ie: the discovery, have an
+// empty resource.
+func getRequestResourceVerb(req *http.Request) (string, string) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case len(parts) >= 3 && parts[0] == "api":
+		parts = parts[2:]
+	case len(parts) >= 4 && parts[0] == "apis":
+		parts = parts[3:]
+	default:
+		return "", strings.ToLower(req.Method)
+	}
+	watch := req.URL.Query().Get("watch") == "true"
+	if len(parts) > 0 && parts[0] == "watch" {
+		watch = true
+		parts = parts[1:]
+	}
+	if len(parts) >= 3 && parts[0] == "namespaces" {
+		parts = parts[2:]
+	}
+	if len(parts) == 0 {
+		return "", strings.ToLower(req.Method)
+	}
+	resource := parts[0]
+	named := len(parts) > 1
+	switch req.Method {
+	case http.MethodGet:
+		switch {
+		case watch:
+			return resource, "watch"
+		case named:
+			return resource, "get"
+		}
+		return resource, "list"
+	case http.MethodPost:
+		return resource, "create"
+	case http.MethodPut:
+		return resource, "update"
+	case http.MethodPatch:
+		return resource, "patch"
+	case http.MethodDelete:
+		return resource, "delete"
+	}
+	return resource, strings.ToLower(req.Method)
+}
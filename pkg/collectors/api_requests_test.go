@@ -0,0 +1,61 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+func Test_getRequestResourceVerb(t *testing.T) {
+	tests := []struct {
+		method   string
+		url      string
+		resource string
+		verb     string
+	}{
+		{method: "GET", url: "/apis/internal.open-cluster-management.io/v1beta1/namespaces/c1/managedclusterinfos", resource: "managedclusterinfos", verb: "list"},
+		{method: "GET", url: "/apis/internal.open-cluster-management.io/v1beta1/managedclusterinfos?limit=500", resource: "managedclusterinfos", verb: "list"},
+		{method: "GET", url: "/apis/cluster.open-cluster-management.io/v1/managedclusters?watch=true&resourceVersion=12", resource: "managedclusters", verb: "watch"},
+		{method: "GET", url: "/apis/cluster.open-cluster-management.io/v1/watch/managedclusters", resource: "managedclusters", verb: "watch"},
+		{method: "GET", url: "/apis/config.openshift.io/v1/clusterversions/version", resource: "clusterversions", verb: "get"},
+		{method: "GET", url: "/api/v1/namespaces/c1/configmaps/lock", resource: "configmaps", verb: "get"},
+		{method: "PUT", url: "/api/v1/namespaces/c1/configmaps/lock", resource: "configmaps", verb: "update"},
+		{method: "GET", url: "/apis/cluster.open-cluster-management.io/v1", resource: "", verb: "get"},
+		{method: "GET", url: "/version", resource: "", verb: "get"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.url, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, nil)
+			resource, verb := getRequestResourceVerb(req)
+			if resource != tt.resource || verb != tt.verb {
+				t.Errorf("getRequestResourceVerb() = %s %s, want %s %s", resource, verb, tt.resource, tt.verb)
+			}
+		})
+	}
+}
+
+func Test_withAPIRequestsCount(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind":"ManagedClusterList","apiVersion":"cluster.open-cluster-management.io/v1","metadata":{},"items":[]}`))
+	}))
+	defer server.Close()
+
+	counter := APIRequestsMetric.WithLabelValues("managedclusters", "list")
+	before := testutil.ToFloat64(counter)
+	client := dynamic.NewForConfigOrDie(withAPIRequestsCount(&rest.Config{Host: server.URL}))
+	if _, err := client.Resource(mcGVR).List(context.TODO(), metav1.ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("expected 1 list request counted, got %v", got)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/klog/v2"
+)
+
+// checkServedVersion compares the version preferred by the hub for the group
+// of the resource with the compiled-in version of the resource. The objects
+// are converted to the compiled-in types, so a mismatch would produce zero
+// values instead of an error. It returns true on mismatch.
+func checkServedVersion(d discovery.DiscoveryInterface, gvr schema.GroupVersionResource) (bool, error) {
+	groups, err := d.ServerGroups()
+	if err != nil {
+		return false, fmt.Errorf("error getting the served API groups: %v", err)
+	}
+	for _, g := range groups.Groups {
+		if g.Name != gvr.Group {
+			continue
+		}
+		if g.PreferredVersion.Version == gvr.Version {
+			return false, nil
+		}
+		served := false
+		for _, v := range g.Versions {
+			if v.Version == gvr.Version {
+				served = true
+				break
+			}
+		}
+		klog.Warningf("The hub serves %s in version %s but version %s is compiled-in (still served: %t), the metrics may be incomplete",
+			gvr.GroupResource().String(), g.PreferredVersion.Version, gvr.Version, served)
+		APIVersionMismatchMetric.WithLabelValues(gvr.Resource).Inc()
+		return true, nil
+	}
+	klog.Warningf("The hub doesn't serve the API group %s of %s", gvr.Group, gvr.Resource)
+	APIVersionMismatchMetric.WithLabelValues(gvr.Resource).Inc()
+	return true, nil
+}
+
+// isResourceServed returns true when the hub serves the resource in its
+// version.
+func isResourceServed(d discovery.DiscoveryInterface, gvr schema.GroupVersionResource) (bool, error) {
+	resources, err := d.ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error getting the served resources of %s: %v", gvr.GroupVersion().String(), err)
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == gvr.Resource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,92 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func Test_checkServedVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		groupVersions []string
+		want          bool
+	}{
+		{
+			name:          "same version",
+			groupVersions: []string{"internal.open-cluster-management.io/v1beta1"},
+			want:          false,
+		},
+		{
+			name:          "newer preferred version",
+			groupVersions: []string{"internal.open-cluster-management.io/v1", "internal.open-cluster-management.io/v1beta1"},
+			want:          true,
+		},
+		{
+			name:          "group not served",
+			groupVersions: []string{"cluster.open-cluster-management.io/v1"},
+			want:          true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resources := []*metav1.APIResourceList{}
+			for _, gv := range tt.groupVersions {
+				resources = append(resources, &metav1.APIResourceList{GroupVersion: gv})
+			}
+			d := &discoveryfake.FakeDiscovery{Fake: &clienttesting.Fake{Resources: resources}}
+			before := testutil.ToFloat64(APIVersionMismatchMetric.WithLabelValues(mciGVR.Resource))
+			got, err := checkServedVersion(d, mciGVR)
+			if err != nil {
+				t.Error(err)
+			}
+			if got != tt.want {
+				t.Errorf("checkServedVersion() = %t, want %t", got, tt.want)
+			}
+			after := testutil.ToFloat64(APIVersionMismatchMetric.WithLabelValues(mciGVR.Resource))
+			if (after-before == 1) != tt.want {
+				t.Errorf("mismatch metric increased by %v", after-before)
+			}
+		})
+	}
+}
+
+func Test_isResourceServed(t *testing.T) {
+	capiGVR := schema.GroupVersionResource{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "clusters"}
+	tests := []struct {
+		name      string
+		resources []metav1.APIResource
+		want      bool
+	}{
+		{
+			name:      "served",
+			resources: []metav1.APIResource{{Name: "machines"}, {Name: "clusters"}},
+			want:      true,
+		},
+		{
+			name:      "not served",
+			resources: []metav1.APIResource{{Name: "machines"}},
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &discoveryfake.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{
+				{GroupVersion: "cluster.x-k8s.io/v1beta1", APIResources: tt.resources},
+			}}}
+			got, err := isResourceServed(d, capiGVR)
+			if err != nil {
+				t.Error(err)
+			}
+			if got != tt.want {
+				t.Errorf("isResourceServed() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
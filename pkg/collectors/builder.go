@@ -4,13 +4,21 @@
 package collectors
 
 import (
+	"io"
 	"sort"
 	"strings"
+	"time"
 
+	mcv1 "github.com/open-cluster-management/api/cluster/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/kube-state-metrics/pkg/metric"
 	metricsstore "k8s.io/kube-state-metrics/pkg/metrics_store"
 	"k8s.io/kube-state-metrics/pkg/options"
@@ -24,15 +32,89 @@ type whiteBlackLister interface {
 	IsExcluded(string) bool
 }
 
+// MetricsWriter writes the metrics of a collector with their help text.
+type MetricsWriter interface {
+	WriteAll(io.Writer)
+}
+
 // Builder helps to build collectors. It follows the builder pattern
 // (https://en.wikipedia.org/wiki/Builder_pattern).
 type Builder struct {
 	apiserver         string
 	kubeconfig        string
+	kubeContext       string
+	kubeContexts      []string
 	namespaces        options.NamespaceList
 	ctx               context.Context
 	enabledCollectors []string
 	whiteBlackList    whiteBlackLister
+	// maxLabelValueLength truncates the label values, 0 means no limit
+	maxLabelValueLength int
+	// constLabels are added to all the series
+	constLabels map[string]string
+	// metricsCacheTTL is the duration the serialized metrics are cached, 0 disables the cache
+	metricsCacheTTL time.Duration
+	// providerClusterIDClaim is the cluster claim holding the cloud provider cluster id
+	providerClusterIDClaim string
+	// apiURLLabel adds the api_url label to the managed cluster info metric
+	apiURLLabel bool
+	// clusterUIDLabel adds the managed_cluster_uid label to the managed cluster info metric
+	clusterUIDLabel bool
+	// infoLabels restricts the labels of the managed cluster info metric
+	infoLabels []string
+	// autoscalerClaim is the cluster claim reporting if the cluster autoscaler is enabled
+	autoscalerClaim string
+	// fipsClaim is the cluster claim reporting if the FIPS mode is enabled
+	fipsClaim string
+	// etcdEncryptionClaim is the cluster claim reporting if the etcd encryption is enabled
+	etcdEncryptionClaim string
+	// hubClusterID overrides the hub_cluster_id read from the hub
+	hubClusterID string
+	// clusterSet restricts the collection to the member clusters of the ManagedClusterSet
+	clusterSet string
+	// clusterSelector restricts the collection to the ManagedClusters matching it
+	clusterSelector labels.Selector
+	// clusterCreatedWithin restricts the collection to the ManagedClusters created within it
+	clusterCreatedWithin time.Duration
+	// excludeVendors excludes the clusters of these kube vendors
+	excludeVendors []string
+	// excludeClouds excludes the clusters of these cloud vendors
+	excludeClouds []string
+	// listPageSize is the number of objects requested per page on the initial lists
+	listPageSize int64
+	// instanceTypeMetrics enables the capacity by instance type families
+	instanceTypeMetrics bool
+	// splitInfoMetrics enables the families splitting the info metric
+	splitInfoMetrics bool
+	// requiredAddOns are the addons expected on all the clusters
+	requiredAddOns []string
+	// capacityResources are the ManagedCluster capacity resources exposed
+	capacityResources []string
+	// capacityViewMaxAge is the age of the ManagedClusterInfos after which
+	// their capacity is read from views, 0 disables the views
+	capacityViewMaxAge time.Duration
+	// machineSetMetrics enables the MachineSet count family, read from views
+	machineSetMetrics bool
+	// clusterVersionViews enables the families read from the views of the
+	// ClusterVersions of the OpenShift clusters
+	clusterVersionViews bool
+	// clusterNameLabel is the label of the ManagedClusterInfos overriding the
+	// name of their ManagedCluster
+	clusterNameLabel string
+	// workerResources are the names of the worker capacity resources
+	workerResources workerResourceNames
+	// requestTimeout bounds the lists and the gets sent to the apiserver
+	requestTimeout time.Duration
+	// collapsePrereleaseVersions collapses the OCP nightly and CI builds in
+	// the version labels
+	collapsePrereleaseVersions bool
+	// capiClusterResource is the resource of the Cluster API Clusters, the
+	// detection of the clusters provisioned by Cluster API is disabled when empty
+	capiClusterResource schema.GroupVersionResource
+	// clusters caches the clusters of the hub for the collectors
+	clusters *clusterCache
+	// hubClusters are the cluster caches of the collected hubs, once built
+	hubClusters []*clusterCache
 }
 
 // NewBuilder returns a new builder.
@@ -40,7 +122,9 @@ func NewBuilder(
 	ctx context.Context,
 ) *Builder {
 	return &Builder{
-		ctx: ctx,
+		ctx:             ctx,
+		workerResources: defaultWorkerResourceNames,
+		requestTimeout:  defaultRequestTimeout,
 	}
 }
 
@@ -54,6 +138,13 @@ func (b *Builder) WithKubeConfig(kubeconfig string) *Builder {
 	return b
 }
 
+// WithKubeContexts sets the kubeconfig contexts of the hubs to collect.
+// One set of collectors is built per context.
+func (b *Builder) WithKubeContexts(contexts []string) *Builder {
+	b.kubeContexts = contexts
+	return b
+}
+
 // WithEnabledCollectors sets the enabledCollectors property of a Builder.
 func (b *Builder) WithEnabledCollectors(c []string) *Builder {
 	copy := []string{}
@@ -80,15 +171,320 @@ func (b *Builder) WithWhiteBlackList(l whiteBlackLister) *Builder {
 	return b
 }
 
+// WithMaxLabelValueLength sets the maximum length of the label values,
+// longer values are truncated. 0 means no limit.
+func (b *Builder) WithMaxLabelValueLength(l int) *Builder {
+	b.maxLabelValueLength = l
+	return b
+}
+
+// WithConstLabels sets the labels added to all the series of the collectors.
+func (b *Builder) WithConstLabels(l map[string]string) *Builder {
+	b.constLabels = l
+	return b
+}
+
+// WithMetricsCacheTTL sets the duration the serialized metrics of the
+// collectors are cached. 0 disables the cache.
+func (b *Builder) WithMetricsCacheTTL(ttl time.Duration) *Builder {
+	b.metricsCacheTTL = ttl
+	return b
+}
+
+// WithProviderClusterIDClaim sets the name of the cluster claim exposed
+// in the provider_cluster_id label of the managed cluster info metric.
+func (b *Builder) WithProviderClusterIDClaim(claim string) *Builder {
+	b.providerClusterIDClaim = claim
+	return b
+}
+
+// WithAPIURLLabel adds the api_url label with the URL of the kube-apiserver of
+// the cluster to the managed cluster info metric.
+func (b *Builder) WithAPIURLLabel(apiURL bool) *Builder {
+	b.apiURLLabel = apiURL
+	return b
+}
+
+// WithClusterUIDLabel adds the managed_cluster_uid label with the uid of the
+// ManagedCluster to the managed cluster info metric.
+func (b *Builder) WithClusterUIDLabel(clusterUID bool) *Builder {
+	b.clusterUIDLabel = clusterUID
+	return b
+}
+
+// WithInfoLabels restricts the labels of the managed cluster info metric to
+// the given labels, the cluster ids are always kept. An empty list keeps all
+// the labels.
+func (b *Builder) WithInfoLabels(labels []string) *Builder {
+	b.infoLabels = labels
+	return b
+}
+
+// WithAutoscalerClaim sets the name of the cluster claim reporting if the
+// cluster autoscaler is enabled, exposed by acm_managed_cluster_autoscaler_enabled.
+func (b *Builder) WithAutoscalerClaim(claim string) *Builder {
+	b.autoscalerClaim = claim
+	return b
+}
+
+// WithFIPSClaim sets the name of the cluster claim reporting if the FIPS mode
+// is enabled, exposed by acm_managed_cluster_fips.
+func (b *Builder) WithFIPSClaim(claim string) *Builder {
+	b.fipsClaim = claim
+	return b
+}
+
+// WithEtcdEncryptionClaim sets the name of the cluster claim reporting if the
+// etcd encryption is enabled, exposed by
+// acm_managed_cluster_etcd_encryption_enabled.
+func (b *Builder) WithEtcdEncryptionClaim(claim string) *Builder {
+	b.etcdEncryptionClaim = claim
+	return b
+}
+
+// WithHubClusterID overrides the hub_cluster_id of the series, read from the
+// ClusterVersion of the hub by default.
+func (b *Builder) WithHubClusterID(hubClusterID string) *Builder {
+	b.hubClusterID = hubClusterID
+	return b
+}
+
+// WithClusterSet restricts the collectors to the member clusters of the
+// ManagedClusterSet. An empty clusterSet collects all the clusters.
+func (b *Builder) WithClusterSet(clusterSet string) *Builder {
+	b.clusterSet = clusterSet
+	return b
+}
+
+// WithClusterSelector restricts the collectors to the ManagedClusters matching
+// the selector, only those are listed and watched so the instances collecting
+// distinct selectors share the fleet. A nil selector collects all the clusters.
+func (b *Builder) WithClusterSelector(selector labels.Selector) *Builder {
+	b.clusterSelector = selector
+	return b
+}
+
+// WithClusterCreatedWithin restricts the collectors to the ManagedClusters
+// created within the duration before the generation of the metrics. 0
+// collects all the clusters.
+func (b *Builder) WithClusterCreatedWithin(window time.Duration) *Builder {
+	b.clusterCreatedWithin = window
+	return b
+}
+
+// WithExcludedVendors excludes from the collectors the clusters whose
+// ManagedClusterInfo reports one of the kube vendors, case-insensitively. An
+// empty list excludes no cluster.
+func (b *Builder) WithExcludedVendors(vendors []string) *Builder {
+	b.excludeVendors = vendors
+	return b
+}
+
+// WithExcludedClouds excludes from the collectors the clusters whose
+// ManagedClusterInfo reports one of the cloud vendors, case-insensitively.
+// An empty list excludes no cluster.
+func (b *Builder) WithExcludedClouds(clouds []string) *Builder {
+	b.excludeClouds = clouds
+	return b
+}
+
+// clusterFilter returns the filter of the collected clusters.
+func (b *Builder) clusterFilter() clusterFilter {
+	return clusterFilter{
+		selector:       clusterSelectorFor(b.clusterSet, b.clusterSelector),
+		createdWithin:  b.clusterCreatedWithin,
+		excludeVendors: b.excludeVendors,
+		excludeClouds:  b.excludeClouds,
+	}
+}
+
+// WithClusterNameLabel sets the label of the ManagedClusterInfos overriding
+// the name of their ManagedCluster.
+func (b *Builder) WithClusterNameLabel(label string) *Builder {
+	b.clusterNameLabel = label
+	return b
+}
+
+// WithWorkerResourceNames overrides the names of the worker cores, sockets
+// and memory resources read from the ManagedCluster capacity, an empty name
+// keeps the default one.
+func (b *Builder) WithWorkerResourceNames(coreWorker, socketWorker, memoryWorker string) *Builder {
+	b.workerResources = defaultWorkerResourceNames
+	if coreWorker != "" {
+		b.workerResources.coreWorker = mcv1.ResourceName(coreWorker)
+	}
+	if socketWorker != "" {
+		b.workerResources.socketWorker = mcv1.ResourceName(socketWorker)
+	}
+	if memoryWorker != "" {
+		b.workerResources.memoryWorker = mcv1.ResourceName(memoryWorker)
+	}
+	return b
+}
+
+// WithRequestTimeout bounds the lists and the gets sent to the apiserver.
+func (b *Builder) WithRequestTimeout(timeout time.Duration) *Builder {
+	b.requestTimeout = timeout
+	return b
+}
+
+// WithCollapsedPrereleaseVersions collapses the OCP nightly and CI builds to
+// their version and stream in the version label of the managed cluster info
+// metric.
+func (b *Builder) WithCollapsedPrereleaseVersions(enabled bool) *Builder {
+	b.collapsePrereleaseVersions = enabled
+	return b
+}
+
+// clusterSettings returns the settings of the cluster cache and of the
+// rollup families.
+func (b *Builder) clusterSettings() clusterSettings {
+	return clusterSettings{
+		nameLabel:                  b.clusterNameLabel,
+		workerResources:            b.workerResources,
+		collapsePrereleaseVersions: b.collapsePrereleaseVersions,
+	}
+}
+
+// WithListPageSize sets the number of objects requested per page when the
+// reflectors list the resources. 0 lets client-go decide.
+func (b *Builder) WithListPageSize(pageSize int64) *Builder {
+	b.listPageSize = pageSize
+	return b
+}
+
+// WithInstanceTypeMetrics enables the capacity by instance type metrics,
+// they have a series per instance type of each cluster.
+func (b *Builder) WithInstanceTypeMetrics(enabled bool) *Builder {
+	b.instanceTypeMetrics = enabled
+	return b
+}
+
+// WithSplitInfoMetrics enables the version, capacity and provenance info
+// families, splitting the labels of the managed cluster info metric.
+func (b *Builder) WithSplitInfoMetrics(enabled bool) *Builder {
+	b.splitInfoMetrics = enabled
+	return b
+}
+
+// WithRequiredAddOns sets the addons expected on all the clusters, the fleet
+// collector exposes the clusters missing one of them.
+func (b *Builder) WithRequiredAddOns(addons []string) *Builder {
+	b.requiredAddOns = addons
+	return b
+}
+
+// WithCapacityResources sets the resources of the ManagedCluster capacity
+// exposed by acm_managed_cluster_capacity.
+func (b *Builder) WithCapacityResources(resources []string) *Builder {
+	b.capacityResources = resources
+	return b
+}
+
+// WithCapacityViewMaxAge sets the age of the synced condition of the
+// ManagedClusterInfos after which the capacity of their nodes is read from
+// ManagedClusterViews. 0 disables the views.
+func (b *Builder) WithCapacityViewMaxAge(maxAge time.Duration) *Builder {
+	b.capacityViewMaxAge = maxAge
+	return b
+}
+
+// WithMachineSetMetrics enables the MachineSet count metric of the OpenShift
+// clusters, it creates a ManagedClusterView per node of these clusters.
+func (b *Builder) WithMachineSetMetrics(enabled bool) *Builder {
+	b.machineSetMetrics = enabled
+	return b
+}
+
+// WithClusterVersionViews enables the last upgrade metric of the OpenShift
+// clusters, it creates a ManagedClusterView of the ClusterVersion of each of
+// these clusters.
+func (b *Builder) WithClusterVersionViews(enabled bool) *Builder {
+	b.clusterVersionViews = enabled
+	return b
+}
+
+// WithCAPIClusterResource sets the resource of the Cluster API Clusters, the
+// clusters having one in their namespace are created_via CAPI. An empty
+// resource disables the detection.
+func (b *Builder) WithCAPIClusterResource(gvr schema.GroupVersionResource) *Builder {
+	b.capiClusterResource = gvr
+	return b
+}
+
 // Build initializes and registers all enabled collectors.
-func (b *Builder) Build() []*metricsstore.MetricsStore {
+func (b *Builder) Build() []MetricsWriter {
 	if b.whiteBlackList == nil {
 		panic("whiteBlackList should not be nil")
 	}
 
-	collectors := []*metricsstore.MetricsStore{}
+	if len(b.kubeContexts) == 0 {
+		collectors := b.buildCollectors()
+		b.addHubClusters(b.clusters)
+		return collectors
+	}
+
+	// The collectors of the same kind of all the hubs are merged, so
+	// each family is exposed once with the series of all the hubs.
+	hubCollectors := make([][]MetricsWriter, len(b.enabledCollectors))
+	for _, kubeContext := range b.kubeContexts {
+		hb := *b
+		hb.kubeContext = kubeContext
+		hb.kubeContexts = nil
+		// A hub which can not be reached at startup is skipped
+		// so it doesn't prevent the collection of the other hubs.
+		if err := hb.checkHub(); err != nil {
+			klog.Errorf("Skipping hub of context %s: %v", kubeContext, err)
+			continue
+		}
+		klog.Infof("Building collectors for hub of context %s", kubeContext)
+		for i, collector := range hb.buildCollectors() {
+			hubCollectors[i] = append(hubCollectors[i], collector)
+		}
+		b.addHubClusters(hb.clusters)
+	}
+	collectors := []MetricsWriter{}
+	for _, writers := range hubCollectors {
+		if len(writers) > 0 {
+			collectors = append(collectors, newMergedWriter(writers))
+		}
+	}
+	return collectors
+}
+
+// addHubClusters keeps the cluster cache of a hub, none when no collector of
+// the hub needed it.
+func (b *Builder) addHubClusters(clusters *clusterCache) {
+	if clusters != nil {
+		b.hubClusters = append(b.hubClusters, clusters)
+	}
+}
+
+// ClusterIDs returns the values of the managed_cluster_id label of the series
+// of the cluster of the given name, it must be called once the collectors are
+// built. The name is kept as the id of the clusters without cluster id, ie:
+// the non-OpenShift clusters, and the cluster id of the ManagedClusterInfo of
+// the cluster is added for each collected hub.
+func (b *Builder) ClusterIDs(name string) []string {
+	ids := []string{name}
+	for _, clusters := range b.hubClusters {
+		mci, err := clusters.getManagedClusterInfo(name)
+		if err != nil {
+			continue
+		}
+		if id := clusters.getClusterID(mci); id != "" && id != name {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (b *Builder) buildCollectors() []MetricsWriter {
+	collectors := []MetricsWriter{}
 	activeCollectorNames := []string{}
 
+	b.checkAPIVersions()
+
 	for _, c := range b.enabledCollectors {
 		constructor, ok := availableCollectors[c]
 		if !ok {
@@ -96,6 +492,9 @@ func (b *Builder) Build() []*metricsstore.MetricsStore {
 		}
 
 		collector := constructor(b)
+		if b.metricsCacheTTL > 0 {
+			collector = newCachedWriter(collector, b.metricsCacheTTL)
+		}
 		activeCollectorNames = append(activeCollectorNames, c)
 		collectors = append(collectors, collector)
 
@@ -106,24 +505,192 @@ func (b *Builder) Build() []*metricsstore.MetricsStore {
 	return collectors
 }
 
-var availableCollectors = map[string]func(f *Builder) *metricsstore.MetricsStore{
-	"managedclusterinfos": func(b *Builder) *metricsstore.MetricsStore { return b.buildManagedClusterInfoCollector() },
+// checkHub verifies the hub is reachable and its cluster id can be read.
+func (b *Builder) checkHub() error {
+	config, err := b.buildConfig()
+	if err != nil {
+		return err
+	}
+	client, err := dynamic.NewForConfig(withAPIRequestsCount(config))
+	if err != nil {
+		return err
+	}
+	_, err = getHubClusterIDE(client, b.requestTimeout)
+	return err
+}
+
+// buildConfig returns the client configuration of the hub, the kubeContext
+// selects the hub context in the kubeconfig when set.
+func (b *Builder) buildConfig() (*rest.Config, error) {
+	if b.kubeContext == "" {
+		return clientcmd.BuildConfigFromFlags(b.apiserver, b.kubeconfig)
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: b.kubeconfig},
+		&clientcmd.ConfigOverrides{
+			CurrentContext: b.kubeContext,
+			ClusterInfo:    clientcmdapi.Cluster{Server: b.apiserver},
+		}).ClientConfig()
 }
 
-func (b *Builder) buildManagedClusterInfoCollector() *metricsstore.MetricsStore {
-	config, err := clientcmd.BuildConfigFromFlags(b.apiserver, b.kubeconfig)
+// checkAPIVersions warns when the hub serves the ManagedClusterInfos in a
+// version other than the compiled-in one. It doesn't prevent the collection.
+func (b *Builder) checkAPIVersions() {
+	d, err := discovery.NewDiscoveryClientForConfig(b.restConfig())
+	if err != nil {
+		klog.Errorf("Error: %v", err)
+		return
+	}
+	if _, err := checkServedVersion(d, mciGVR); err != nil {
+		klog.Errorf("Error: %v", err)
+	}
+}
+
+// isServed returns true when the hub serves the resource, the optional
+// resources are not cached otherwise as their informer would never sync.
+func (b *Builder) isServed(gvr schema.GroupVersionResource) bool {
+	d, err := discovery.NewDiscoveryClientForConfig(b.restConfig())
+	if err != nil {
+		klog.Errorf("Error: %v", err)
+		return false
+	}
+	served, err := isResourceServed(d, gvr)
+	if err != nil {
+		klog.Errorf("Error: %v", err)
+		return false
+	}
+	if !served {
+		klog.Warningf("The hub doesn't serve %s, its objects are ignored", gvr.String())
+	}
+	return served
+}
+
+// clusterCacheFor returns the cache of the clusters of the hub, it is
+// created and started by the first collector needing it.
+func (b *Builder) clusterCacheFor(client dynamic.Interface) *clusterCache {
+	if b.clusters == nil {
+		b.clusters = newClusterCache(client, b.namespaces, b.clusterSelector, b.listPageSize,
+			b.requestTimeout, b.clusterSettings())
+		if b.capiClusterResource.Resource != "" && b.isServed(b.capiClusterResource) {
+			b.clusters.addCAPIClusters(client, b.capiClusterResource, b.namespaces, b.listPageSize)
+		}
+		b.clusters.run(b.ctx)
+	}
+	return b.clusters
+}
+
+// afterClusterCacheSync calls f once the cluster cache synced, so the objects
+// listed by the reflectors started by f find their cluster in the cache.
+func (b *Builder) afterClusterCacheSync(clusters *clusterCache, f func()) {
+	go func() {
+		if cache.WaitForCacheSync(b.ctx.Done(), clusters.hasSynced) {
+			f()
+		}
+	}()
+}
+
+// hubClusterIDFor returns the hub_cluster_id of the series of the hub, the
+// override of WithHubClusterID when set.
+func (b *Builder) hubClusterIDFor(client dynamic.Interface) string {
+	if b.hubClusterID != "" {
+		return b.hubClusterID
+	}
+	return getHubClusterID(client, b.requestTimeout)
+}
+
+func (b *Builder) restConfig() *rest.Config {
+	config, err := b.buildConfig()
 	if err != nil {
 		klog.Fatalf("cannot create Dynamic client: %v", err)
 	}
-	client := dynamic.NewForConfigOrDie(config)
+	return withAPIRequestsCount(config)
+}
+
+var availableCollectors = map[string]func(f *Builder) MetricsWriter{
+	"managedclusterinfos":    func(b *Builder) MetricsWriter { return b.buildManagedClusterInfoCollector() },
+	"managedclusteraddons":   func(b *Builder) MetricsWriter { return b.buildManagedClusterAddOnCollector() },
+	"fleet":                  func(b *Builder) MetricsWriter { return b.buildFleetCollector() },
+	"managedclusterleases":   func(b *Builder) MetricsWriter { return b.buildManagedClusterLeaseCollector() },
+	"managedclustersets":     func(b *Builder) MetricsWriter { return b.buildManagedClusterSetCollector() },
+	"policies":               func(b *Builder) MetricsWriter { return b.buildPolicyCollector() },
+	"managedserviceaccounts": func(b *Builder) MetricsWriter { return b.buildManagedServiceAccountCollector() },
+	"manifestworks":          func(b *Builder) MetricsWriter { return b.buildManifestWorkCollector() },
+}
+
+func (b *Builder) buildManagedClusterInfoCollector() *metricsstore.MetricsStore {
+	client := dynamic.NewForConfigOrDie(b.restConfig())
 	return b.buildManagedClusterInfoCollectorWithClient(client)
 }
 
 func (b *Builder) buildManagedClusterInfoCollectorWithClient(client dynamic.Interface) *metricsstore.MetricsStore {
-	hubClusterID := getHubClusterID(client)
-	filteredMetricFamilies := metric.FilterMetricFamilies(b.whiteBlackList,
-		getManagedClusterInfoMetricFamilies(hubClusterID, client))
-	composedMetricGenFuncs := metric.ComposeMetricGenFuncs(filteredMetricFamilies)
+	hubClusterID := b.hubClusterIDFor(client)
+	clusters := b.clusterCacheFor(client)
+	nodes := &nodeSummaryPass{}
+	if (b.capacityViewMaxAge > 0 || b.machineSetMetrics || b.clusterVersionViews) && b.isServed(mcvGVR) {
+		nodes.views = newClusterViews(client, clusters, b.namespaces, b.capacityViewMaxAge,
+			b.machineSetMetrics, b.clusterVersionViews, b.listPageSize)
+		nodes.views.run(b.ctx)
+	}
+	families := append(getManagedClusterInfoMetricFamilies(hubClusterID, clusters, nodes, nodes.views, b.providerClusterIDClaim, b.apiURLLabel, b.clusterUIDLabel, b.infoLabels),
+		getManagedClusterStatusMetricFamilies(hubClusterID, clusters)...)
+	if b.splitInfoMetrics {
+		families = append(families, getSplitInfoMetricFamilies(hubClusterID, clusters, nodes, nodes.views)...)
+	}
+	if b.instanceTypeMetrics {
+		families = append(families, getInstanceTypeMetricFamilies(hubClusterID, clusters, nodes)...)
+	}
+	if len(b.capacityResources) > 0 {
+		families = append(families, getCapacityMetricFamilies(hubClusterID, clusters, b.capacityResources)...)
+	}
+	if b.autoscalerClaim != "" {
+		families = append(families, getAutoscalerMetricFamilies(hubClusterID, clusters, b.autoscalerClaim)...)
+	}
+	if b.fipsClaim != "" {
+		families = append(families, getFIPSMetricFamilies(hubClusterID, clusters, b.fipsClaim)...)
+	}
+	if b.etcdEncryptionClaim != "" {
+		families = append(families, getEtcdEncryptionMetricFamilies(hubClusterID, clusters, b.etcdEncryptionClaim)...)
+	}
+	if b.machineSetMetrics && nodes.views != nil {
+		families = append(families, getMachineSetMetricFamilies(hubClusterID, clusters, nodes.views)...)
+	}
+	if b.clusterVersionViews && nodes.views != nil {
+		families = append(families, getLastUpgradeMetricFamilies(hubClusterID, clusters, nodes.views)...)
+	}
+	filteredMetricFamilies := b.familyGenerators(families)
+	composedMetricGenFuncs := withCollectionTimestamp("managedclusterinfos",
+		withUniqueManagedClusterInfo(clusters, len(filteredMetricFamilies),
+			withClusterSet(clusters, b.clusterFilter(), len(filteredMetricFamilies),
+				withNodeSummaryPass(nodes, metric.ComposeMetricGenFuncs(filteredMetricFamilies)))))
+
+	familyHeaders := metric.ExtractMetricFamilyHeaders(filteredMetricFamilies)
+
+	store := metricsstore.NewMetricsStore(
+		familyHeaders,
+		composedMetricGenFuncs,
+	)
+	// The store is fed by the informers of the cluster cache, which
+	// already list and watch the ManagedClusterInfos and ManagedClusters.
+	clusters.addStore(store)
+	if nodes.views != nil {
+		nodes.views.addStore(store)
+	}
+
+	return store
+}
+
+func (b *Builder) buildManagedClusterAddOnCollector() *metricsstore.MetricsStore {
+	client := dynamic.NewForConfigOrDie(b.restConfig())
+	return b.buildManagedClusterAddOnCollectorWithClient(client)
+}
+
+func (b *Builder) buildManagedClusterAddOnCollectorWithClient(client dynamic.Interface) *metricsstore.MetricsStore {
+	hubClusterID := b.hubClusterIDFor(client)
+	clusters := b.clusterCacheFor(client)
+	filteredMetricFamilies := b.familyGenerators(getManagedClusterAddOnMetricFamilies(hubClusterID, clusters))
+	composedMetricGenFuncs := withCollectionTimestamp("managedclusteraddons",
+		withClusterSet(clusters, b.clusterFilter(), len(filteredMetricFamilies),
+			metric.ComposeMetricGenFuncs(filteredMetricFamilies)))
 
 	familyHeaders := metric.ExtractMetricFamilyHeaders(filteredMetricFamilies)
 
@@ -131,29 +698,216 @@ func (b *Builder) buildManagedClusterInfoCollectorWithClient(client dynamic.Inte
 		familyHeaders,
 		composedMetricGenFuncs,
 	)
-	reflectorPerNamespace(b.ctx, &unstructured.Unstructured{}, store,
-		b.apiserver, b.kubeconfig, b.namespaces, createManagedClusterInfoListWatch)
-	reflectorClusterScoped(b.ctx, &unstructured.Unstructured{}, store,
-		b.apiserver, b.kubeconfig, createManagedClusterListWatch)
+	b.afterClusterCacheSync(clusters, func() {
+		reflectorPerNamespace(b.ctx, &unstructured.Unstructured{}, store,
+			b.restConfig(), b.namespaces, createManagedClusterAddOnListWatch, mcaGVR.Resource, b.listPageSize, b.requestTimeout)
+	})
 
 	return store
 }
 
+func (b *Builder) buildPolicyCollector() *metricsstore.MetricsStore {
+	client := dynamic.NewForConfigOrDie(b.restConfig())
+	return b.buildPolicyCollectorWithClient(client)
+}
+
+func (b *Builder) buildPolicyCollectorWithClient(client dynamic.Interface) *metricsstore.MetricsStore {
+	hubClusterID := b.hubClusterIDFor(client)
+	clusters := b.clusterCacheFor(client)
+	filteredMetricFamilies := b.familyGenerators(getPolicyMetricFamilies(hubClusterID, clusters))
+	composedMetricGenFuncs := withCollectionTimestamp("policies",
+		withClusterSet(clusters, b.clusterFilter(), len(filteredMetricFamilies),
+			metric.ComposeMetricGenFuncs(filteredMetricFamilies)))
+
+	familyHeaders := metric.ExtractMetricFamilyHeaders(filteredMetricFamilies)
+
+	store := metricsstore.NewMetricsStore(
+		familyHeaders,
+		composedMetricGenFuncs,
+	)
+	b.afterClusterCacheSync(clusters, func() {
+		reflectorPerNamespace(b.ctx, &unstructured.Unstructured{}, store,
+			b.restConfig(), b.namespaces, createPolicyListWatch, policyGVR.Resource, b.listPageSize, b.requestTimeout)
+	})
+
+	return store
+}
+
+func (b *Builder) buildManagedServiceAccountCollector() *metricsstore.MetricsStore {
+	client := dynamic.NewForConfigOrDie(b.restConfig())
+	return b.buildManagedServiceAccountCollectorWithClient(client)
+}
+
+func (b *Builder) buildManagedServiceAccountCollectorWithClient(client dynamic.Interface) *metricsstore.MetricsStore {
+	hubClusterID := b.hubClusterIDFor(client)
+	clusters := b.clusterCacheFor(client)
+	filteredMetricFamilies := b.familyGenerators(getManagedServiceAccountMetricFamilies(hubClusterID, clusters))
+	composedMetricGenFuncs := withCollectionTimestamp("managedserviceaccounts",
+		withClusterSet(clusters, b.clusterFilter(), len(filteredMetricFamilies),
+			metric.ComposeMetricGenFuncs(filteredMetricFamilies)))
+
+	familyHeaders := metric.ExtractMetricFamilyHeaders(filteredMetricFamilies)
+
+	store := metricsstore.NewMetricsStore(
+		familyHeaders,
+		composedMetricGenFuncs,
+	)
+	b.afterClusterCacheSync(clusters, func() {
+		reflectorPerNamespace(b.ctx, &unstructured.Unstructured{}, store,
+			b.restConfig(), b.namespaces, createManagedServiceAccountListWatch, msaGVR.Resource, b.listPageSize, b.requestTimeout)
+	})
+
+	return store
+}
+
+func (b *Builder) buildFleetCollector() *rollupStore {
+	client := dynamic.NewForConfigOrDie(b.restConfig())
+	return b.buildFleetCollectorWithClient(client)
+}
+
+func (b *Builder) buildFleetCollectorWithClient(client dynamic.Interface) *rollupStore {
+	hubClusterID := b.hubClusterIDFor(client)
+	settings := b.clusterSettings()
+	families := append(getFleetMetricFamilies(hubClusterID, settings), getAddOnUnsupportedConfigMetricFamilies(hubClusterID, settings)...)
+	if len(b.requiredAddOns) > 0 {
+		families = append(families, getRequiredAddOnMetricFamilies(hubClusterID, settings, b.requiredAddOns)...)
+	}
+	filteredMetricFamilies := b.familyGenerators(families)
+	composedMetricGenFuncs := withCollectionTimestamp("fleet",
+		withClusterSetRollup(settings, b.clusterFilter(), withFleet(metric.ComposeMetricGenFuncs(filteredMetricFamilies))))
+
+	familyHeaders := metric.ExtractMetricFamilyHeaders(filteredMetricFamilies)
+
+	store := newRollupStore(
+		familyHeaders,
+		composedMetricGenFuncs,
+	)
+	// The ManagedClusterInfos and ManagedClusters are read from the
+	// informers of the cluster cache instead of being reflected again.
+	store.addInformers(b.clusterCacheFor(client).clusterInformers()...)
+	store.reflectPerNamespace(b.ctx, &unstructured.Unstructured{},
+		b.restConfig(), b.namespaces, createManagedClusterAddOnListWatch, mcaGVR.Resource, b.listPageSize, b.requestTimeout)
+	store.reflectClusterScoped(b.ctx, &unstructured.Unstructured{},
+		b.restConfig(), createClusterManagementAddOnListWatch, cmaGVR.Resource, b.listPageSize, b.requestTimeout)
+
+	return store
+}
+
+func (b *Builder) buildManagedClusterLeaseCollector() *rollupStore {
+	client := dynamic.NewForConfigOrDie(b.restConfig())
+	return b.buildManagedClusterLeaseCollectorWithClient(client)
+}
+
+func (b *Builder) buildManagedClusterLeaseCollectorWithClient(client dynamic.Interface) *rollupStore {
+	hubClusterID := b.hubClusterIDFor(client)
+	settings := b.clusterSettings()
+	filteredMetricFamilies := b.familyGenerators(getManagedClusterLeaseMetricFamilies(hubClusterID, settings))
+	composedMetricGenFuncs := withCollectionTimestamp("managedclusterleases",
+		withClusterSetRollup(settings, b.clusterFilter(), withFleet(metric.ComposeMetricGenFuncs(filteredMetricFamilies))))
+
+	familyHeaders := metric.ExtractMetricFamilyHeaders(filteredMetricFamilies)
+
+	// The lag depends on the scrape time, so the metrics are generated
+	// by the rollup store on each scrape.
+	store := newRollupStore(
+		familyHeaders,
+		composedMetricGenFuncs,
+	)
+	store.addInformers(b.clusterCacheFor(client).clusterInformers()...)
+	store.reflectPerNamespace(b.ctx, &unstructured.Unstructured{},
+		b.restConfig(), b.namespaces, createManagedClusterLeaseListWatch, leaseGVR.Resource, b.listPageSize, b.requestTimeout)
+
+	return store
+}
+
+func (b *Builder) buildManifestWorkCollector() *rollupStore {
+	client := dynamic.NewForConfigOrDie(b.restConfig())
+	return b.buildManifestWorkCollectorWithClient(client)
+}
+
+func (b *Builder) buildManifestWorkCollectorWithClient(client dynamic.Interface) *rollupStore {
+	hubClusterID := b.hubClusterIDFor(client)
+	settings := b.clusterSettings()
+	filteredMetricFamilies := b.familyGenerators(getManifestWorkMetricFamilies(hubClusterID, settings))
+	composedMetricGenFuncs := withCollectionTimestamp("manifestworks",
+		withClusterSetRollup(settings, b.clusterFilter(), withFleet(metric.ComposeMetricGenFuncs(filteredMetricFamilies))))
+
+	familyHeaders := metric.ExtractMetricFamilyHeaders(filteredMetricFamilies)
+
+	store := newRollupStore(
+		familyHeaders,
+		composedMetricGenFuncs,
+	)
+	store.addInformers(b.clusterCacheFor(client).clusterInformers()...)
+	store.reflectPerNamespace(b.ctx, &unstructured.Unstructured{},
+		b.restConfig(), b.namespaces, createManifestWorkListWatch, workGVR.Resource, b.listPageSize, b.requestTimeout)
+
+	return store
+}
+
+func (b *Builder) buildManagedClusterSetCollector() *rollupStore {
+	client := dynamic.NewForConfigOrDie(b.restConfig())
+	return b.buildManagedClusterSetCollectorWithClient(client)
+}
+
+func (b *Builder) buildManagedClusterSetCollectorWithClient(client dynamic.Interface) *rollupStore {
+	hubClusterID := b.hubClusterIDFor(client)
+	settings := b.clusterSettings()
+	filteredMetricFamilies := b.familyGenerators(getManagedClusterSetMetricFamilies(hubClusterID, settings))
+	composedMetricGenFuncs := withCollectionTimestamp("managedclustersets",
+		withClusterSetRollup(settings, b.clusterFilter(), withFleet(metric.ComposeMetricGenFuncs(filteredMetricFamilies))))
+
+	familyHeaders := metric.ExtractMetricFamilyHeaders(filteredMetricFamilies)
+
+	store := newRollupStore(
+		familyHeaders,
+		composedMetricGenFuncs,
+	)
+	store.addInformers(b.clusterCacheFor(client).clusterInformers()...)
+	store.reflectClusterScoped(b.ctx, &unstructured.Unstructured{},
+		b.restConfig(), createManagedClusterSetListWatch, mcsGVR.Resource, b.listPageSize, b.requestTimeout)
+
+	return store
+}
+
+// familyGenerators filters the families with the white/black list and
+// applies the label transformations configured on the builder.
+func (b *Builder) familyGenerators(families []metric.FamilyGenerator) []metric.FamilyGenerator {
+	filtered := metric.FilterMetricFamilies(b.whiteBlackList, families)
+	return withConstLabels(withLabelValueMaxLength(filtered, b.maxLabelValueLength), b.constLabels)
+}
+
+// withCollectionTimestamp wraps the generate function of the collector to
+// update its last collection timestamp and its generation duration on each
+// generation pass.
+func withCollectionTimestamp(collector string,
+	generateFunc func(interface{}) []metricsstore.FamilyByteSlicer) func(interface{}) []metricsstore.FamilyByteSlicer {
+	return func(obj interface{}) []metricsstore.FamilyByteSlicer {
+		start := now()
+		families := generateFunc(obj)
+		CollectorGenerateDurationMetric.WithLabelValues(collector).Observe(now().Sub(start).Seconds())
+		LastCollectionTimestampMetric.WithLabelValues(collector).SetToCurrentTime()
+		return families
+	}
+}
+
 // reflectorPerNamespace creates a Kubernetes client-go reflector with the given
 // listWatchFunc for each given namespace and registers it with the given store.
+// The lists are paginated by pageSize objects and bounded by the timeout, the
+// cached objects are counted in the cache size of the resource.
 func reflectorPerNamespace(
 	ctx context.Context,
 	expectedType interface{},
 	store cache.Store,
-	apiserver string,
-	kubeconfig string,
+	config *rest.Config,
 	namespaces []string,
-	listWatchFunc func(apiserver string, kubeconfig string, ns string) cache.ListWatch,
+	listWatchFunc func(config *rest.Config, ns string, timeout time.Duration) cache.ListWatch,
+	resource string,
+	pageSize int64,
+	timeout time.Duration,
 ) {
 	for _, ns := range namespaces {
-		lw := listWatchFunc(apiserver, kubeconfig, ns)
-		reflector := cache.NewReflector(&lw, expectedType, store, 0)
-		go reflector.Run(ctx.Done())
+		runReflector(ctx, listWatchFunc(config, ns, timeout), expectedType, store, resource, pageSize)
 	}
 }
 
@@ -163,11 +917,11 @@ func reflectorClusterScoped(
 	ctx context.Context,
 	expectedType interface{},
 	store cache.Store,
-	apiserver string,
-	kubeconfig string,
-	listWatchFunc func(apiserver string, kubeconfig string) cache.ListWatch,
+	config *rest.Config,
+	listWatchFunc func(config *rest.Config, timeout time.Duration) cache.ListWatch,
+	resource string,
+	pageSize int64,
+	timeout time.Duration,
 ) {
-	lw := listWatchFunc(apiserver, kubeconfig)
-	reflector := cache.NewReflector(&lw, expectedType, store, 0)
-	go reflector.Run(ctx.Done())
+	runReflector(ctx, listWatchFunc(config, timeout), expectedType, store, resource, pageSize)
 }
@@ -5,15 +5,25 @@ package collectors
 
 import (
 	"bytes"
+	"io/ioutil"
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
 	ocinfrav1 "github.com/openshift/api/config/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"golang.org/x/net/context"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/kube-state-metrics/pkg/metric"
 	metricsstore "k8s.io/kube-state-metrics/pkg/metrics_store"
 	koptions "k8s.io/kube-state-metrics/pkg/options"
 	"k8s.io/kube-state-metrics/pkg/whiteblacklist"
@@ -311,6 +321,109 @@ func TestBuilder_WithWhiteBlackList(t *testing.T) {
 	}
 }
 
+func TestBuilder_buildConfig(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	err := ioutil.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: east
+  cluster:
+    server: https://east:6443
+- name: west
+  cluster:
+    server: https://west:6443
+users:
+- name: admin
+  user:
+    token: token
+contexts:
+- name: hub-east
+  context:
+    cluster: east
+    user: admin
+- name: hub-west
+  context:
+    cluster: west
+    user: admin
+current-context: hub-east
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		kubeContext string
+		want        string
+	}{
+		{
+			name:        "current context",
+			kubeContext: "",
+			want:        "https://east:6443",
+		},
+		{
+			name:        "selected context",
+			kubeContext: "hub-west",
+			want:        "https://west:6443",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Builder{
+				kubeconfig:  kubeconfig,
+				kubeContext: tt.kubeContext,
+			}
+			got, err := b.buildConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Host != tt.want {
+				t.Errorf("Builder.buildConfig() host = %v, want %v", got.Host, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuilder_hubClusterIDFor(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(scheme.Scheme)
+	b := NewBuilder(ctx).WithHubClusterID("override_id")
+	if got := b.hubClusterIDFor(client); got != "override_id" {
+		t.Errorf("hubClusterIDFor() = %v, want override_id", got)
+	}
+}
+
+func TestBuilder_ClusterIDs(t *testing.T) {
+	mciOCP := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "ocp-cluster", Namespace: "ocp-cluster"},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor: mciv1beta1.KubeVendorOpenShift,
+			ClusterID:  "ocp_cluster_id",
+		},
+	})
+	mciEKS := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "eks-cluster", Namespace: "eks-cluster"},
+		Status:     mciv1beta1.ClusterInfoStatus{KubeVendor: mciv1beta1.KubeVendorEKS},
+	})
+	b := NewBuilder(ctx)
+	b.addHubClusters(newTestClusterCache(t, mciOCP, mciEKS))
+
+	tests := []struct {
+		name    string
+		cluster string
+		want    []string
+	}{
+		{name: "openshift", cluster: "ocp-cluster", want: []string{"ocp-cluster", "ocp_cluster_id"}},
+		{name: "non-openshift", cluster: "eks-cluster", want: []string{"eks-cluster"}},
+		{name: "cluster id", cluster: "ocp_cluster_id", want: []string{"ocp_cluster_id"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.ClusterIDs(tt.cluster); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ClusterIDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestBuilder_buildManagedClusterCollectorWithClient(t *testing.T) {
 	const headers = `# HELP acm_managed_cluster_info Managed cluster information
 # TYPE acm_managed_cluster_info gauge
@@ -396,6 +509,8 @@ func TestBuilder_buildManagedClusterCollectorWithClient(t *testing.T) {
 				ctx:               tt.fields.ctx,
 				enabledCollectors: tt.fields.enabledCollectors,
 				whiteBlackList:    tt.fields.whiteBlackList,
+				workerResources:   defaultWorkerResourceNames,
+				requestTimeout:    defaultRequestTimeout,
 			}
 			got := b.buildManagedClusterInfoCollectorWithClient(tt.args.client)
 			if got == nil {
@@ -409,3 +524,25 @@ func TestBuilder_buildManagedClusterCollectorWithClient(t *testing.T) {
 		})
 	}
 }
+
+func Test_withCollectionTimestamp(t *testing.T) {
+	families := getFleetMetricFamilies("mycluster_id", defaultClusterSettings)
+	generateFunc := withCollectionTimestamp("test", withFleet(metric.ComposeMetricGenFuncs(families)))
+
+	before := float64(time.Now().Unix())
+	got := generateFunc([]interface{}{})
+	if len(got) != len(families) {
+		t.Errorf("expected %d families got %d", len(families), len(got))
+	}
+	timestamp := testutil.ToFloat64(LastCollectionTimestampMetric.WithLabelValues("test"))
+	if timestamp < before {
+		t.Errorf("expected the last collection timestamp %f to be updated after %f", timestamp, before)
+	}
+	m := &dto.Metric{}
+	if err := CollectorGenerateDurationMetric.WithLabelValues("test").(prometheus.Histogram).Write(m); err != nil {
+		t.Error(err)
+	}
+	if n := m.GetHistogram().GetSampleCount(); n != 1 {
+		t.Errorf("expected 1 generate duration observation got %d", n)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// countingStore wraps the store of a reflector to count the objects it
+// caches in gauge. The reflectors of a resource share the gauge, so each
+// countingStore only adds the changes of its own objects.
+type countingStore struct {
+	cache.Store
+
+	gauge prometheus.Gauge
+	mutex sync.Mutex
+	keys  map[string]struct{}
+}
+
+func newCountingStore(store cache.Store, resource string) *countingStore {
+	return &countingStore{
+		Store: store,
+		gauge: CacheSizeMetric.WithLabelValues(resource),
+		keys:  map[string]struct{}{},
+	}
+}
+
+// Add implements cache.Store.
+func (s *countingStore) Add(obj interface{}) error {
+	if err := s.Store.Add(obj); err != nil {
+		return err
+	}
+	s.added(obj)
+	return nil
+}
+
+// Update implements cache.Store.
+func (s *countingStore) Update(obj interface{}) error {
+	if err := s.Store.Update(obj); err != nil {
+		return err
+	}
+	s.added(obj)
+	return nil
+}
+
+// Delete implements cache.Store.
+func (s *countingStore) Delete(obj interface{}) error {
+	if err := s.Store.Delete(obj); err != nil {
+		return err
+	}
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		klog.Errorf("Error: %v", err)
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.keys[key]; ok {
+		delete(s.keys, key)
+		s.gauge.Dec()
+	}
+	return nil
+}
+
+// Replace implements cache.Store, the reflector calls it on each list.
+func (s *countingStore) Replace(list []interface{}, resourceVersion string) error {
+	if err := s.Store.Replace(list, resourceVersion); err != nil {
+		return err
+	}
+	keys := make(map[string]struct{}, len(list))
+	for _, obj := range list {
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err != nil {
+			klog.Errorf("Error: %v", err)
+			continue
+		}
+		keys[key] = struct{}{}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.gauge.Add(float64(len(keys) - len(s.keys)))
+	s.keys = keys
+	return nil
+}
+
+func (s *countingStore) added(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		klog.Errorf("Error: %v", err)
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.keys[key]; !ok {
+		s.keys[key] = struct{}{}
+		s.gauge.Inc()
+	}
+}
+
+// countingHandler counts in the cache size of the resource the objects
+// cached by an informer.
+func countingHandler(resource string) cache.ResourceEventHandlerFuncs {
+	gauge := CacheSizeMetric.WithLabelValues(resource)
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { gauge.Inc() },
+		DeleteFunc: func(interface{}) { gauge.Dec() },
+	}
+}
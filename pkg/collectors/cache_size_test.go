@@ -0,0 +1,83 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newNamedU(ns, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetNamespace(ns)
+	u.SetName(name)
+	return u
+}
+
+func Test_countingStore(t *testing.T) {
+	gauge := CacheSizeMetric.WithLabelValues("tests")
+	store1 := newCountingStore(cache.NewStore(cache.MetaNamespaceKeyFunc), "tests")
+	store2 := newCountingStore(cache.NewStore(cache.MetaNamespaceKeyFunc), "tests")
+
+	steps := []struct {
+		name string
+		do   func() error
+		want float64
+	}{
+		{
+			name: "list",
+			do: func() error {
+				return store1.Replace([]interface{}{newNamedU("ns1", "a"), newNamedU("ns1", "b")}, "1")
+			},
+			want: 2,
+		},
+		{
+			name: "list of another reflector",
+			do: func() error {
+				return store2.Replace([]interface{}{newNamedU("ns2", "a")}, "1")
+			},
+			want: 3,
+		},
+		{
+			name: "add",
+			do:   func() error { return store1.Add(newNamedU("ns1", "c")) },
+			want: 4,
+		},
+		{
+			name: "update",
+			do:   func() error { return store1.Update(newNamedU("ns1", "c")) },
+			want: 4,
+		},
+		{
+			name: "delete",
+			do:   func() error { return store1.Delete(newNamedU("ns1", "a")) },
+			want: 3,
+		},
+		{
+			name: "delete again",
+			do:   func() error { return store1.Delete(newNamedU("ns1", "a")) },
+			want: 3,
+		},
+		{
+			name: "relist",
+			do: func() error {
+				return store1.Replace([]interface{}{newNamedU("ns1", "d")}, "2")
+			},
+			want: 2,
+		},
+	}
+	for _, step := range steps {
+		if err := step.do(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := testutil.ToFloat64(gauge); got != step.want {
+			t.Errorf("%s: cache size = %v, want %v", step.name, got, step.want)
+		}
+		if got := float64(len(store1.List()) + len(store2.List())); got != step.want {
+			t.Errorf("%s: stored objects = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// cachedWriter keeps the serialized metrics of a collector for a ttl so
+// frequent scrapes don't serialize the unchanged series again. The series
+// updated during the ttl are exposed on the first scrape after it expires.
+type cachedWriter struct {
+	MetricsWriter
+	ttl time.Duration
+
+	mutex     sync.Mutex
+	cached    []byte
+	expiresAt time.Time
+}
+
+func newCachedWriter(w MetricsWriter, ttl time.Duration) *cachedWriter {
+	return &cachedWriter{
+		MetricsWriter: w,
+		ttl:           ttl,
+	}
+}
+
+// WriteAll writes the cached metrics, the metrics are serialized again
+// once the ttl expired.
+func (c *cachedWriter) WriteAll(w io.Writer) {
+	c.mutex.Lock()
+	if c.cached == nil || !now().Before(c.expiresAt) {
+		buf := new(bytes.Buffer)
+		c.MetricsWriter.WriteAll(buf)
+		c.cached = buf.Bytes()
+		c.expiresAt = now().Add(c.ttl)
+	}
+	cached := c.cached
+	c.mutex.Unlock()
+
+	w.Write(cached)
+}
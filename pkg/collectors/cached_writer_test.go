@@ -0,0 +1,74 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	mcv1 "github.com/open-cluster-management/api/cluster/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+func Test_cachedWriter_WriteAll(t *testing.T) {
+	scrapeTime := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return scrapeTime }
+	defer func() { now = time.Now }()
+
+	families := getFleetMetricFamilies("mycluster_id", defaultClusterSettings)[:1]
+	store := newRollupStore(metric.ExtractMetricFamilyHeaders(families),
+		withFleet(metric.ComposeMetricGenFuncs(families)))
+	src := store.source()
+	if err := src.Replace([]interface{}{}, ""); err != nil {
+		t.Fatal(err)
+	}
+	writer := newCachedWriter(store, time.Minute)
+
+	header := `# HELP acm_fleet_total_clusters Number of managed clusters
+# TYPE acm_fleet_total_clusters gauge
+`
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    string
+	}{
+		{
+			name:    "first scrape",
+			elapsed: 0,
+			want:    header + `acm_fleet_total_clusters{hub_cluster_id="mycluster_id"} 0` + "\n",
+		},
+		{
+			name:    "cached",
+			elapsed: 30 * time.Second,
+			want:    header + `acm_fleet_total_clusters{hub_cluster_id="mycluster_id"} 0` + "\n",
+		},
+		{
+			name:    "expired",
+			elapsed: time.Minute,
+			want:    header + `acm_fleet_total_clusters{hub_cluster_id="mycluster_id"} 1` + "\n",
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scrapeTime = scrapeTime.Add(tt.elapsed)
+			buf := new(bytes.Buffer)
+			writer.WriteAll(buf)
+			if buf.String() != tt.want {
+				t.Errorf("Expected \n%s\ngot\n%s", tt.want, buf.String())
+			}
+			if i == 0 {
+				// The cluster added after the first scrape is exposed once the cache expires
+				mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cluster",
+					},
+				})
+				if err := src.Add(mc); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
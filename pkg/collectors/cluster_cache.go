@@ -0,0 +1,341 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"context"
+	"time"
+
+	mcv1 "github.com/open-cluster-management/api/cluster/v1"
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// clusterSettings are the settings of the Builder reading the clusters, the
+// cluster cache carries them to the family generators.
+type clusterSettings struct {
+	// nameLabel is the label of the ManagedClusterInfos overriding the name
+	// of their ManagedCluster, empty when no override is configured
+	nameLabel string
+	// workerResources are the names of the worker capacity resources read
+	// from the ManagedClusters
+	workerResources workerResourceNames
+	// collapsePrereleaseVersions collapses the OCP nightly and CI builds to
+	// their version and stream in the version labels
+	collapsePrereleaseVersions bool
+}
+
+// defaultClusterSettings are the settings of a Builder without options.
+var defaultClusterSettings = clusterSettings{workerResources: defaultWorkerResourceNames}
+
+// clusterCache caches the ManagedClusterInfos and the ManagedClusters of a
+// hub with shared informers. The collectors read the clusters of their
+// objects from it, so no request is sent to the apiserver while the metrics
+// are generated.
+type clusterCache struct {
+	clusterSettings
+	// requestTimeout bounds the lists of the informers
+	requestTimeout time.Duration
+	// managedClusterInfos has an informer per collected namespace
+	managedClusterInfos []cache.SharedIndexInformer
+	managedClusters     cache.SharedIndexInformer
+	// capiClusters has an informer of the Cluster API Clusters per
+	// collected namespace, none when the detection is disabled
+	capiClusters []cache.SharedIndexInformer
+}
+
+// newClusterCache returns the cache of the ManagedClusterInfos of the given
+// namespaces and of the ManagedClusters, both matching the selector. The lists
+// are paginated by pageSize objects and bounded by the timeout. The informers
+// keep retrying a forbidden list as all the collectors need the clusters.
+func newClusterCache(client dynamic.Interface, namespaces []string, selector labels.Selector, pageSize int64,
+	timeout time.Duration, settings clusterSettings) *clusterCache {
+	c := &clusterCache{clusterSettings: settings, requestTimeout: timeout}
+	for _, ns := range namespaces {
+		lw := withForbidden(withPageSize(createManagedClusterInfoListWatchWithClient(client, ns, selector, timeout), pageSize), mciGVR.Resource, nil)
+		informer := cache.NewSharedIndexInformer(&lw, &unstructured.Unstructured{}, 0,
+			cache.Indexers{
+				clusterNameIndex:     settings.clusterNameIndexFunc,
+				cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+			})
+		informer.AddEventHandler(countingHandler(mciGVR.Resource))
+		c.managedClusterInfos = append(c.managedClusterInfos, informer)
+	}
+	lw := withForbidden(withPageSize(createManagedClusterListWatchWithClient(client, selector, timeout), pageSize), mcGVR.Resource, nil)
+	c.managedClusters = cache.NewSharedIndexInformer(&lw, &unstructured.Unstructured{}, 0, cache.Indexers{})
+	c.managedClusters.AddEventHandler(countingHandler(mcGVR.Resource))
+	return c
+}
+
+// addCAPIClusters caches the Cluster API Clusters of the given resource in
+// the given namespaces, it must be called before the cache is run.
+func (c *clusterCache) addCAPIClusters(client dynamic.Interface, gvr schema.GroupVersionResource, namespaces []string, pageSize int64) {
+	for _, ns := range namespaces {
+		lw := withForbidden(withPageSize(createCAPIClusterListWatchWithClient(client, gvr, ns, c.requestTimeout), pageSize), gvr.Resource, nil)
+		informer := cache.NewSharedIndexInformer(&lw, &unstructured.Unstructured{}, 0,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		informer.AddEventHandler(countingHandler(gvr.Resource))
+		c.capiClusters = append(c.capiClusters, informer)
+	}
+}
+
+func (c *clusterCache) informers() []cache.SharedIndexInformer {
+	return append(c.clusterInformers(), c.capiClusters...)
+}
+
+// clusterInformers returns the informers of the ManagedClusterInfos and of
+// the ManagedClusters, the objects the stores are fed with. The Cluster API
+// Clusters are only looked up.
+func (c *clusterCache) clusterInformers() []cache.SharedIndexInformer {
+	return append(append([]cache.SharedIndexInformer{}, c.managedClusterInfos...), c.managedClusters)
+}
+
+// run starts the informers, they stop when the context is done.
+func (c *clusterCache) run(ctx context.Context) {
+	for _, informer := range c.informers() {
+		go informer.Run(ctx.Done())
+	}
+}
+
+// hasSynced returns true when all the informers completed their first list.
+func (c *clusterCache) hasSynced() bool {
+	for _, informer := range c.informers() {
+		if !informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// addStore feeds the store with the ManagedClusterInfos and the
+// ManagedClusters of the cache. The informers update their cache before
+// notifying the store, so the metrics of an object are generated from its
+// cached version.
+//
+// The metrics of a ManagedClusterInfo depend on its ManagedCluster, so the
+// ManagedClusterInfos of a deleted ManagedCluster are regenerated to remove
+// their metrics instead of reporting the deleted cluster until the
+// ManagedClusterInfo is updated or removed.
+func (c *clusterCache) addStore(store cache.Store) {
+	for _, informer := range c.clusterInformers() {
+		informer.AddEventHandler(storeHandler(store))
+	}
+	c.managedClusters.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			c.refreshManagedClusterInfos(store, obj)
+		},
+	})
+}
+
+// refreshManagedClusterInfos updates the store with the cached
+// ManagedClusterInfos of the deleted ManagedCluster, the informer already
+// removed the ManagedCluster from its cache so their metrics are dropped.
+func (c *clusterCache) refreshManagedClusterInfos(store cache.Store, obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	mc, err := meta.Accessor(obj)
+	if err != nil {
+		klog.Errorf("Error: %v", err)
+		return
+	}
+	for _, informer := range c.managedClusterInfos {
+		mcis, err := informer.GetIndexer().ByIndex(clusterNameIndex, mc.GetName())
+		if err != nil {
+			klog.Errorf("Error: %v", err)
+			continue
+		}
+		for _, mci := range mcis {
+			if err := store.Update(mci); err != nil {
+				klog.Errorf("Error: %v", err)
+			}
+		}
+	}
+}
+
+// getManagedClusterInfo returns the ManagedClusterInfo of the cluster, it is
+// resolved by getManagedClusterInfoU.
+func (c *clusterCache) getManagedClusterInfo(name string) (*mciv1beta1.ManagedClusterInfo, error) {
+	obj, err := c.getManagedClusterInfoU(name)
+	if err != nil {
+		return nil, err
+	}
+	mci, err := toManagedClusterInfo(obj)
+	if err != nil {
+		return nil, err
+	}
+	if c.clusterNameFor(mci) != name {
+		// The collectors resolve the cluster of the returned
+		// ManagedClusterInfo by clusterNameFor, it is named after its
+		// cluster as by the OCM convention.
+		mci.Name = name
+	}
+	return mci, nil
+}
+
+// getManagedClusterInfoU returns the cached ManagedClusterInfo of the
+// cluster, it is resolved by clusterNameFor. The one owned by the
+// ManagedCluster is preferred when several ManagedClusterInfos resolve to the
+// cluster. Without resolved ManagedClusterInfo, the only ManagedClusterInfo
+// of the cluster namespace is returned, whatever its name. The returned
+// object is shared by the cache, it must not be modified.
+func (c *clusterCache) getManagedClusterInfoU(name string) (*unstructured.Unstructured, error) {
+	for _, informer := range c.managedClusterInfos {
+		objs, err := informer.GetIndexer().ByIndex(clusterNameIndex, name)
+		if err != nil {
+			return nil, err
+		}
+		if len(objs) == 0 {
+			continue
+		}
+		obj := objs[0].(*unstructured.Unstructured)
+		for _, o := range objs {
+			if isOwnedByManagedCluster(o.(*unstructured.Unstructured)) {
+				obj = o.(*unstructured.Unstructured)
+				break
+			}
+		}
+		return obj, nil
+	}
+	for _, informer := range c.managedClusterInfos {
+		objs, err := informer.GetIndexer().ByIndex(cache.NamespaceIndex, name)
+		if err != nil {
+			return nil, err
+		}
+		if len(objs) != 1 {
+			continue
+		}
+		return objs[0].(*unstructured.Unstructured), nil
+	}
+	return nil, errors.NewNotFound(mciGVR.GroupResource(), name)
+}
+
+// clusterNameOf returns the name of the cluster of the ManagedClusterInfo as
+// resolved by getManagedClusterInfoU: its clusterNameFor, or the name of its
+// namespace when it is the ManagedClusterInfo resolved for the cluster of its
+// namespace, ie: the only one of the namespace. An empty name is returned for
+// the other ManagedClusterInfos.
+func (c *clusterCache) clusterNameOf(mci metav1.Object) string {
+	if name := c.clusterNameFor(mci); name != "" {
+		return name
+	}
+	resolved, err := c.getManagedClusterInfoU(mci.GetNamespace())
+	if err != nil || resolved.GetNamespace() != mci.GetNamespace() || resolved.GetName() != mci.GetName() {
+		return ""
+	}
+	return mci.GetNamespace()
+}
+
+func toManagedClusterInfo(obj *unstructured.Unstructured) (*mciv1beta1.ManagedClusterInfo, error) {
+	mci := &mciv1beta1.ManagedClusterInfo{}
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), &mci)
+	if err != nil {
+		return nil, err
+	}
+	return mci, nil
+}
+
+// getManagedCluster returns the ManagedCluster of the cluster.
+func (c *clusterCache) getManagedCluster(name string) (*mcv1.ManagedCluster, error) {
+	obj, exists, err := c.managedClusters.GetIndexer().GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(mcGVR.GroupResource(), name)
+	}
+	mc := &mcv1.ManagedCluster{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.(*unstructured.Unstructured).UnstructuredContent(), &mc)
+	if err != nil {
+		return nil, err
+	}
+	return mc, nil
+}
+
+// hasCAPICluster returns true when a Cluster API Cluster is located in the
+// namespace of the cluster, the cluster was then provisioned by Cluster API.
+func (c *clusterCache) hasCAPICluster(name string) bool {
+	for _, informer := range c.capiClusters {
+		objs, err := informer.GetIndexer().ByIndex(cache.NamespaceIndex, name)
+		if err != nil {
+			klog.Errorf("Error: %v", err)
+			continue
+		}
+		if len(objs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// getCreatedVia returns the created_via of the cluster from its annotation,
+// the clusters without annotation are CAPI when a Cluster API Cluster is
+// located in their namespace.
+func (c *clusterCache) getCreatedVia(mc *mcv1.ManagedCluster) string {
+	createdVia := getCreatedVia(mc)
+	if createdVia == createdViaAnnotationOther && c.hasCAPICluster(mc.GetName()) {
+		return createdViaCAPI
+	}
+	return createdVia
+}
+
+func createCAPIClusterListWatchWithClient(client dynamic.Interface, gvr schema.GroupVersionResource, ns string, timeout time.Duration) cache.ListWatch {
+	return cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			ctx, cancel := requestContext(gvr.Resource, timeout)
+			defer cancel()
+			return client.Resource(gvr).Namespace(ns).List(ctx, opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return client.Resource(gvr).Namespace(ns).Watch(context.TODO(), opts)
+		},
+	}
+}
+
+// withPageSize paginates the lists of the informer by pageSize objects, the
+// shared informers don't expose the page size of their reflector.
+func withPageSize(lw cache.ListWatch, pageSize int64) cache.ListWatch {
+	listFunc := lw.ListFunc
+	lw.ListFunc = func(opts metav1.ListOptions) (runtime.Object, error) {
+		// The informer sets a limit when it paginates its lists.
+		if opts.Limit != 0 {
+			opts.Limit = pageSize
+		}
+		return listFunc(opts)
+	}
+	return lw
+}
+
+// storeHandler feeds the store with the events of an informer.
+func storeHandler(store cache.Store) cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if err := store.Add(obj); err != nil {
+				klog.Errorf("Error: %v", err)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if err := store.Update(obj); err != nil {
+				klog.Errorf("Error: %v", err)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if err := store.Delete(obj); err != nil {
+				klog.Errorf("Error: %v", err)
+			}
+		},
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	mcv1 "github.com/open-cluster-management/api/cluster/v1"
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kube-state-metrics/pkg/metric"
+	metricsstore "k8s.io/kube-state-metrics/pkg/metrics_store"
+)
+
+func Test_clusterCache_get(t *testing.T) {
+	mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Namespace: "cluster-1"},
+		Status:     mciv1beta1.ClusterInfoStatus{ClusterID: "cluster_id_1"},
+	})
+	mciOtherNamespace := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-2", Namespace: "other"},
+	})
+	mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1"},
+	})
+	clusters := newTestClusterCache(t, mci, mciOtherNamespace, mc)
+
+	gotMCI, err := clusters.getManagedClusterInfo("cluster-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotMCI.Status.ClusterID != "cluster_id_1" {
+		t.Errorf("cluster id = %s, want cluster_id_1", gotMCI.Status.ClusterID)
+	}
+	if _, err := clusters.getManagedClusterInfo("cluster-2"); !errors.IsNotFound(err) {
+		t.Errorf("expected a not found error for the ManagedClusterInfo outside the cluster namespace, got %v", err)
+	}
+	if _, err := clusters.getManagedCluster("cluster-1"); err != nil {
+		t.Error(err)
+	}
+	if _, err := clusters.getManagedCluster("cluster-2"); !errors.IsNotFound(err) {
+		t.Errorf("expected a not found error for the missing ManagedCluster, got %v", err)
+	}
+}
+
+func Test_clusterCache_getWithClusterNameLabel(t *testing.T) {
+	mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "info", Namespace: "other",
+			Labels: map[string]string{"example.com/cluster-name": "cluster-1"}},
+		Status: mciv1beta1.ClusterInfoStatus{ClusterID: "cluster_id_1"},
+	})
+	clusters := newTestClusterCacheWithSettings(t, clusterSettings{nameLabel: "example.com/cluster-name"}, mci)
+
+	gotMCI, err := clusters.getManagedClusterInfo("cluster-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotMCI.Status.ClusterID != "cluster_id_1" {
+		t.Errorf("cluster id = %s, want cluster_id_1", gotMCI.Status.ClusterID)
+	}
+	if _, err := clusters.getManagedClusterInfo("info"); !errors.IsNotFound(err) {
+		t.Errorf("expected a not found error for the name of the ManagedClusterInfo, got %v", err)
+	}
+}
+
+func Test_clusterCache_getMismatchedName(t *testing.T) {
+	mciOwned := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "info", Namespace: "cluster-1",
+			OwnerReferences: []metav1.OwnerReference{managedClusterOwner("cluster-1")}},
+		Status: mciv1beta1.ClusterInfoStatus{ClusterID: "cluster_id_1"},
+	})
+	mciStale := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Namespace: "cluster-1"},
+		Status:     mciv1beta1.ClusterInfoStatus{ClusterID: "stale_id"},
+	})
+	mciOnly := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "info", Namespace: "cluster-2"},
+		Status:     mciv1beta1.ClusterInfoStatus{ClusterID: "cluster_id_2"},
+	})
+	mciAmbiguous1 := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "info-1", Namespace: "cluster-3"},
+	})
+	mciAmbiguous2 := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "info-2", Namespace: "cluster-3"},
+	})
+	clusters := newTestClusterCache(t, mciOwned, mciStale, mciOnly, mciAmbiguous1, mciAmbiguous2)
+
+	tests := []struct {
+		name          string
+		cluster       string
+		wantClusterID string
+		wantNotFound  bool
+	}{
+		{name: "owned by the cluster", cluster: "cluster-1", wantClusterID: "cluster_id_1"},
+		{name: "only one in the namespace", cluster: "cluster-2", wantClusterID: "cluster_id_2"},
+		{name: "several in the namespace", cluster: "cluster-3", wantNotFound: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotMCI, err := clusters.getManagedClusterInfo(tt.cluster)
+			if tt.wantNotFound {
+				if !errors.IsNotFound(err) {
+					t.Errorf("expected a not found error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotMCI.Status.ClusterID != tt.wantClusterID {
+				t.Errorf("cluster id = %s, want %s", gotMCI.Status.ClusterID, tt.wantClusterID)
+			}
+			if got := clusters.clusterNameFor(gotMCI); got != tt.cluster {
+				t.Errorf("clusterNameFor() = %q, want %q", got, tt.cluster)
+			}
+		})
+	}
+}
+
+func Test_clusterCache_addStore(t *testing.T) {
+	mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Namespace: "cluster-1"},
+	})
+	mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1"},
+	})
+	clusters := newTestClusterCache(t, mci, mc)
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	clusters.addStore(store)
+
+	// The informers notify the handlers added after they synced from
+	// another goroutine.
+	deadline := time.Now().Add(5 * time.Second)
+	for len(store.List()) != 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := store.ListKeys(); len(got) != 2 {
+		t.Errorf("expected the store to be fed with 2 objects, got %v", got)
+	}
+}
+
+func Test_clusterCache_addStore_capiClusters(t *testing.T) {
+	capiGVR := schema.GroupVersionResource{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "clusters"}
+	capiCluster := &unstructured.Unstructured{}
+	capiCluster.SetAPIVersion("cluster.x-k8s.io/v1beta1")
+	capiCluster.SetKind("Cluster")
+	capiCluster.SetNamespace("cluster-1")
+	capiCluster.SetName("cluster-1")
+	capiClusterOther := capiCluster.DeepCopy()
+	capiClusterOther.SetNamespace("capi-clusters")
+	capiClusterOther.SetName("other")
+	mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Namespace: "cluster-1"},
+	})
+	mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1"},
+	})
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			mciGVR:  "ManagedClusterInfoList",
+			mcGVR:   "ManagedClusterList",
+			capiGVR: "ClusterList",
+		}, mci, mc, capiCluster, capiClusterOther)
+	clusters := newClusterCache(client, []string{metav1.NamespaceAll}, nil, 0, defaultRequestTimeout, defaultClusterSettings)
+	clusters.addCAPIClusters(client, capiGVR, []string{metav1.NamespaceAll}, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clusters.run(ctx)
+	if !cache.WaitForCacheSync(ctx.Done(), clusters.hasSynced) {
+		t.Fatal("the cluster cache didn't sync")
+	}
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	clusters.addStore(store)
+
+	// The informers notify the handlers added after they synced from
+	// another goroutine, the Cluster API Clusters must not be notified.
+	deadline := time.Now().Add(5 * time.Second)
+	for len(store.List()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	want := []string{"cluster-1", "cluster-1/cluster-1"}
+	got := store.ListKeys()
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("store keys = %v, want %v", got, want)
+	}
+	// The Cluster API Cluster named after its namespace shares the key of
+	// the ManagedClusterInfo.
+	if obj, _, _ := store.GetByKey("cluster-1/cluster-1"); obj == nil || obj.(*unstructured.Unstructured).GetKind() != "ManagedClusterInfo" {
+		t.Errorf("expected the ManagedClusterInfo in the store, got %v", obj)
+	}
+
+	// A new Cluster API Cluster doesn't change the store either.
+	capiClusterNew := capiCluster.DeepCopy()
+	capiClusterNew.SetNamespace("cluster-2")
+	capiClusterNew.SetName("cluster-2")
+	if _, err := client.Resource(capiGVR).Namespace("cluster-2").Create(ctx, capiClusterNew, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	got = store.ListKeys()
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("store keys = %v, want %v", got, want)
+	}
+}
+
+func Test_clusterCache_addStore_deletedManagedCluster(t *testing.T) {
+	mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Namespace: "cluster-1", UID: "mci-uid"},
+		Status:     mciv1beta1.ClusterInfoStatus{ClusterID: "cluster_id_1"},
+	})
+	mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", UID: "mc-uid"},
+		Status: mcv1.ManagedClusterStatus{
+			Conditions: []metav1.Condition{{Type: mcv1.ManagedClusterConditionAvailable, Status: metav1.ConditionTrue}},
+		},
+	})
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			mciGVR: "ManagedClusterInfoList",
+			mcGVR:  "ManagedClusterList",
+		}, mci, mc)
+	clusters := newClusterCache(client, []string{metav1.NamespaceAll}, nil, 0, defaultRequestTimeout, defaultClusterSettings)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clusters.run(ctx)
+	if !cache.WaitForCacheSync(ctx.Done(), clusters.hasSynced) {
+		t.Fatal("the cluster cache didn't sync")
+	}
+	families := getManagedClusterStatusMetricFamilies("hub_cluster_id", clusters)
+	store := metricsstore.NewMetricsStore(metric.ExtractMetricFamilyHeaders(families),
+		metric.ComposeMetricGenFuncs(families))
+	clusters.addStore(store)
+
+	// waitForMetric polls the store as the informers notify it from another
+	// goroutine.
+	waitForMetric := func(want bool) {
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			buf := new(bytes.Buffer)
+			store.WriteAll(buf)
+			got := strings.Contains(buf.String(), `managed_cluster_id="cluster_id_1"`)
+			if got == want {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected the metric of the cluster to be present %v, got:\n%s", want, buf.String())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitForMetric(true)
+
+	// The ManagedClusterInfo lingers while the ManagedCluster is deleted.
+	if err := client.Resource(mcGVR).Delete(ctx, "cluster-1", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForMetric(false)
+}
+
+func Test_clusterCache_getCreatedVia(t *testing.T) {
+	capiGVR := schema.GroupVersionResource{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "clusters"}
+	capiCluster := &unstructured.Unstructured{}
+	capiCluster.SetAPIVersion("cluster.x-k8s.io/v1beta1")
+	capiCluster.SetKind("Cluster")
+	capiCluster.SetNamespace("capi-cluster")
+	capiCluster.SetName("capi-cluster")
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			mciGVR:  "ManagedClusterInfoList",
+			mcGVR:   "ManagedClusterList",
+			capiGVR: "ClusterList",
+		}, capiCluster)
+	clusters := newClusterCache(client, []string{metav1.NamespaceAll}, nil, 0, defaultRequestTimeout, defaultClusterSettings)
+	clusters.addCAPIClusters(client, capiGVR, []string{metav1.NamespaceAll}, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clusters.run(ctx)
+	if !cache.WaitForCacheSync(ctx.Done(), clusters.hasSynced) {
+		t.Fatal("the cluster cache didn't sync")
+	}
+
+	tests := []struct {
+		name        string
+		cluster     string
+		annotations map[string]string
+		want        string
+	}{
+		{name: "cluster api", cluster: "capi-cluster", want: createdViaCAPI},
+		{name: "hive annotation", cluster: "capi-cluster",
+			annotations: map[string]string{createdViaAnnotation: "hive"}, want: "Hive"},
+		{name: "no cluster api cluster", cluster: "imported-cluster", want: createdViaAnnotationOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := &mcv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: tt.cluster, Annotations: tt.annotations},
+			}
+			if got := clusters.getCreatedVia(mc); got != tt.want {
+				t.Errorf("getCreatedVia() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_withPageSize(t *testing.T) {
+	var got int64
+	lw := withPageSize(cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			got = opts.Limit
+			return nil, nil
+		},
+	}, 100)
+
+	tests := []struct {
+		name  string
+		limit int64
+		want  int64
+	}{
+		{name: "paginated list", limit: 500, want: 100},
+		{name: "full list", limit: 0, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := lw.ListFunc(metav1.ListOptions{Limit: tt.limit}); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("limit = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
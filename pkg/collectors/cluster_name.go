@@ -0,0 +1,68 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// clusterNameIndex indexes the ManagedClusterInfos of the cluster cache by
+// the name of their ManagedCluster.
+const clusterNameIndex = "clusterName"
+
+// clusterNameFor returns the name of the ManagedCluster of the
+// ManagedClusterInfo. By the OCM convention the ManagedClusterInfo is named
+// after its ManagedCluster and located in the namespace of the cluster. The
+// nameLabel label of the ManagedClusterInfo overrides the convention,
+// a ManagedClusterInfo named differently is resolved to the cluster of its
+// namespace when it is owned by the ManagedCluster. An empty name is returned
+// for a ManagedClusterInfo outside of the namespace of its cluster without
+// override. The name of a cluster scoped object, ie: a ManagedCluster, is
+// returned as is.
+func (s clusterSettings) clusterNameFor(mci metav1.Object) string {
+	if s.nameLabel != "" {
+		if name := mci.GetLabels()[s.nameLabel]; name != "" {
+			return name
+		}
+	}
+	if isOwnedByManagedCluster(mci) {
+		return mci.GetNamespace()
+	}
+	if mci.GetNamespace() != "" && mci.GetNamespace() != mci.GetName() {
+		return ""
+	}
+	return mci.GetName()
+}
+
+// isOwnedByManagedCluster returns true when the object has an owner
+// reference to the ManagedCluster named after its namespace.
+func isOwnedByManagedCluster(obj metav1.Object) bool {
+	if obj.GetNamespace() == "" {
+		return false
+	}
+	for _, ref := range obj.GetOwnerReferences() {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			continue
+		}
+		if gv.Group == mcGVR.Group && ref.Kind == "ManagedCluster" && ref.Name == obj.GetNamespace() {
+			return true
+		}
+	}
+	return false
+}
+
+// clusterNameIndexFunc indexes the ManagedClusterInfos by clusterNameFor.
+func (s clusterSettings) clusterNameIndexFunc(obj interface{}) ([]string, error) {
+	mci, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	name := s.clusterNameFor(mci)
+	if name == "" {
+		return []string{}, nil
+	}
+	return []string{name}, nil
+}
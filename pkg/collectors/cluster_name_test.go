@@ -0,0 +1,108 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"testing"
+
+	mcv1 "github.com/open-cluster-management/api/cluster/v1"
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// managedClusterOwner returns an owner reference to the ManagedCluster.
+func managedClusterOwner(name string) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: mcv1.GroupVersion.String(),
+		Kind:       "ManagedCluster",
+		Name:       name,
+	}
+}
+
+func Test_clusterNameFor(t *testing.T) {
+	tests := []struct {
+		name  string
+		label string
+		obj   metav1.Object
+		want  string
+	}{
+		{
+			name: "cluster namespace",
+			obj: &mciv1beta1.ManagedClusterInfo{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Namespace: "cluster-1"},
+			},
+			want: "cluster-1",
+		},
+		{
+			name: "other namespace",
+			obj: &mciv1beta1.ManagedClusterInfo{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Namespace: "other"},
+			},
+			want: "",
+		},
+		{
+			name: "managed cluster",
+			obj: &mcv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster-1"},
+			},
+			want: "cluster-1",
+		},
+		{
+			name:  "override",
+			label: "example.com/cluster-name",
+			obj: &mciv1beta1.ManagedClusterInfo{
+				ObjectMeta: metav1.ObjectMeta{Name: "info", Namespace: "other",
+					Labels: map[string]string{"example.com/cluster-name": "cluster-1"}},
+			},
+			want: "cluster-1",
+		},
+		{
+			name:  "override without label",
+			label: "example.com/cluster-name",
+			obj: &mciv1beta1.ManagedClusterInfo{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Namespace: "cluster-1"},
+			},
+			want: "cluster-1",
+		},
+		{
+			name: "owned by the cluster",
+			obj: &mciv1beta1.ManagedClusterInfo{
+				ObjectMeta: metav1.ObjectMeta{Name: "info", Namespace: "cluster-1",
+					OwnerReferences: []metav1.OwnerReference{managedClusterOwner("cluster-1")}},
+			},
+			want: "cluster-1",
+		},
+		{
+			name: "owned by another cluster",
+			obj: &mciv1beta1.ManagedClusterInfo{
+				ObjectMeta: metav1.ObjectMeta{Name: "info", Namespace: "other",
+					OwnerReferences: []metav1.OwnerReference{managedClusterOwner("cluster-1")}},
+			},
+			want: "",
+		},
+		{
+			name: "owned by another kind",
+			obj: &mciv1beta1.ManagedClusterInfo{
+				ObjectMeta: metav1.ObjectMeta{Name: "info", Namespace: "cluster-1",
+					OwnerReferences: []metav1.OwnerReference{{APIVersion: "v1", Kind: "Namespace", Name: "cluster-1"}}},
+			},
+			want: "",
+		},
+		{
+			name: "label not configured",
+			obj: &mciv1beta1.ManagedClusterInfo{
+				ObjectMeta: metav1.ObjectMeta{Name: "info", Namespace: "other",
+					Labels: map[string]string{"example.com/cluster-name": "cluster-1"}},
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := clusterSettings{nameLabel: tt.label}
+			if got := settings.clusterNameFor(tt.obj); got != tt.want {
+				t.Errorf("clusterNameFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,430 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+var mcvGVR = schema.GroupVersionResource{
+	Group:    "view.open-cluster-management.io",
+	Version:  "v1beta1",
+	Resource: "managedclusterviews",
+}
+
+// clusterViewLabel marks the ManagedClusterViews created by the exporter,
+// the views of the other clients are never listed nor deleted.
+const clusterViewLabel = "clusterlifecycle-state-metrics.open-cluster-management.io/view"
+
+// capacityViewPrefix prefixes the name of the node of a capacity view.
+const capacityViewPrefix = "capacity-"
+
+// clusterVersionViewName is the name of the view of the version
+// ClusterVersion of an OpenShift cluster.
+const clusterVersionViewName = "clusterversion"
+
+// machineAnnotation is set by the OpenShift machine api on the nodes with the
+// namespace/name of their Machine.
+const machineAnnotation = "machine.openshift.io/machine"
+
+// machineSetSuffix matches the random suffix of the names generated for the
+// Machines of a MachineSet.
+var machineSetSuffix = regexp.MustCompile(`-[a-z0-9]{5}$`)
+
+// clusterViewSyncPeriod is the period the views are created for the clusters
+// needing them, ie: the ManagedClusterInfos becoming stale, and deleted for
+// the other ones.
+const clusterViewSyncPeriod = time.Minute
+
+// clusterViews reads the resources of the managed clusters not reported by
+// the ManagedClusterInfos from ManagedClusterViews created in the namespace
+// of the clusters. A view fetches a single resource, so a capacity view of
+// each node of the node list is created while the ManagedClusterInfo is
+// stale, or as long as the cluster is an OpenShift one when the MachineSets
+// are counted, and a view of the version ClusterVersion of the OpenShift
+// clusters when the ClusterVersions are read.
+type clusterViews struct {
+	client   dynamic.Interface
+	clusters *clusterCache
+	// maxAge is the age of the synced condition of a ManagedClusterInfo
+	// after which it is stale, 0 never refreshes the capacity
+	maxAge time.Duration
+	// machineSets keeps the views of the nodes of the OpenShift clusters to
+	// count their MachineSets
+	machineSets bool
+	// clusterVersions keeps a view of the ClusterVersion of the OpenShift
+	// clusters
+	clusterVersions bool
+	// views has an informer of the capacity views per collected namespace
+	views []cache.SharedIndexInformer
+}
+
+// newClusterViews returns the views of the clusters of the cluster cache: the
+// capacity views of the ManagedClusterInfos older than maxAge, and of all the
+// OpenShift clusters when machineSets is true, and the ClusterVersion views
+// of the OpenShift clusters when clusterVersions is true. The views are
+// cached per namespace, their requests are bounded by the request timeout of
+// the cluster cache.
+func newClusterViews(client dynamic.Interface, clusters *clusterCache, namespaces []string,
+	maxAge time.Duration, machineSets, clusterVersions bool, pageSize int64) *clusterViews {
+	v := &clusterViews{client: client, clusters: clusters, maxAge: maxAge,
+		machineSets: machineSets, clusterVersions: clusterVersions}
+	selector := labels.SelectorFromSet(labels.Set{clusterViewLabel: "true"})
+	for _, ns := range namespaces {
+		lw := withForbidden(withPageSize(createClusterViewListWatchWithClient(client, ns, selector, clusters.requestTimeout), pageSize), mcvGVR.Resource, nil)
+		informer := cache.NewSharedIndexInformer(&lw, &unstructured.Unstructured{}, 0,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		informer.AddEventHandler(countingHandler(mcvGVR.Resource))
+		v.views = append(v.views, informer)
+	}
+	return v
+}
+
+// run starts the informers of the views and syncs the views of the clusters
+// every clusterViewSyncPeriod once the caches synced, until the context is
+// done.
+func (v *clusterViews) run(ctx context.Context) {
+	for _, informer := range v.views {
+		go informer.Run(ctx.Done())
+	}
+	go func() {
+		if !cache.WaitForCacheSync(ctx.Done(), v.hasSynced, v.clusters.hasSynced) {
+			return
+		}
+		wait.Until(v.sync, clusterViewSyncPeriod, ctx.Done())
+	}()
+}
+
+// hasSynced returns true when all the informers of the views completed their
+// first list.
+func (v *clusterViews) hasSynced() bool {
+	for _, informer := range v.views {
+		if !informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// addStore updates the ManagedClusterInfo of the namespace of a view in the
+// store when the view changes, so its metrics are generated from the result
+// of the view.
+func (v *clusterViews) addStore(store cache.Store) {
+	refresh := func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		view, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return
+		}
+		mci, err := v.clusters.getManagedClusterInfoU(view.GetNamespace())
+		if err != nil {
+			return
+		}
+		if err := store.Update(mci); err != nil {
+			klog.Errorf("Error: %v", err)
+		}
+	}
+	for _, informer := range v.views {
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    refresh,
+			UpdateFunc: func(_, obj interface{}) { refresh(obj) },
+			DeleteFunc: refresh,
+		})
+	}
+}
+
+// sync creates the missing views of the clusters, and deletes the views they
+// no longer need, ie: the views of the refreshed ManagedClusterInfos and of
+// the removed nodes.
+func (v *clusterViews) sync() {
+	for _, obj := range v.clusters.managedClusters.GetStore().List() {
+		mc, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		mci, err := v.clusters.getManagedClusterInfo(mc.GetName())
+		if err != nil {
+			continue
+		}
+		v.syncCluster(mci)
+	}
+}
+
+// syncCluster syncs the views of the ManagedClusterInfo, a view per node
+// while it is stale or its MachineSets are counted, and a view of its
+// ClusterVersion while they are read, none otherwise. The wanted views are
+// mapped to their scope.
+func (v *clusterViews) syncCluster(mci *mciv1beta1.ManagedClusterInfo) {
+	wanted := map[string]map[string]interface{}{}
+	openShift := mci.Status.KubeVendor == mciv1beta1.KubeVendorOpenShift
+	if v.isStale(mci) || v.machineSets && openShift {
+		for _, n := range mci.Status.NodeList {
+			wanted[capacityViewName(n.Name)] = map[string]interface{}{
+				"version":  "v1",
+				"kind":     "Node",
+				"resource": "nodes",
+				"name":     n.Name,
+			}
+		}
+	}
+	if v.clusterVersions && openShift {
+		wanted[clusterVersionViewName] = map[string]interface{}{
+			"apiGroup": cvGVR.Group,
+			"version":  cvGVR.Version,
+			"kind":     "ClusterVersion",
+			"resource": cvGVR.Resource,
+			"name":     "version",
+		}
+	}
+	existing := map[string]bool{}
+	for _, informer := range v.views {
+		objs, err := informer.GetIndexer().ByIndex(cache.NamespaceIndex, mci.Namespace)
+		if err != nil {
+			klog.Errorf("Error: %v", err)
+			continue
+		}
+		for _, obj := range objs {
+			existing[obj.(*unstructured.Unstructured).GetName()] = true
+		}
+	}
+	for name, scope := range wanted {
+		if existing[name] {
+			continue
+		}
+		if err := v.create(mci.Namespace, name, scope); err != nil && !errors.IsAlreadyExists(err) {
+			klog.Errorf("Failed to create the view %s/%s: %v", mci.Namespace, name, err)
+		}
+	}
+	for name := range existing {
+		if _, ok := wanted[name]; ok {
+			continue
+		}
+		if err := v.delete(mci.Namespace, name); err != nil && !errors.IsNotFound(err) {
+			klog.Errorf("Failed to delete the view %s/%s: %v", mci.Namespace, name, err)
+		}
+	}
+}
+
+// isStale returns true when the ManagedClusterInfo was last synced by its
+// agent more than maxAge ago, never when maxAge is 0.
+func (v *clusterViews) isStale(mci *mciv1beta1.ManagedClusterInfo) bool {
+	synced := getManagedClusterInfoSyncTime(mci)
+	return v.maxAge > 0 && !synced.IsZero() && now().Sub(synced.Time) > v.maxAge
+}
+
+func (v *clusterViews) create(ns, name string, scope map[string]interface{}) error {
+	view := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": mcvGVR.GroupVersion().String(),
+		"kind":       "ManagedClusterView",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": ns,
+			"labels":    map[string]interface{}{clusterViewLabel: "true"},
+		},
+		"spec": map[string]interface{}{
+			"scope": scope,
+		},
+	}}
+	ctx, cancel := requestContext(mcvGVR.Resource, v.clusters.requestTimeout)
+	defer cancel()
+	_, err := v.client.Resource(mcvGVR).Namespace(ns).Create(ctx, view, metav1.CreateOptions{})
+	return err
+}
+
+func (v *clusterViews) delete(ns, name string) error {
+	ctx, cancel := requestContext(mcvGVR.Resource, v.clusters.requestTimeout)
+	defer cancel()
+	return v.client.Resource(mcvGVR).Namespace(ns).Delete(ctx, name, metav1.DeleteOptions{})
+}
+
+// nodeList returns the node list of the ManagedClusterInfo. The cpu and
+// memory capacity of its nodes are read from the result of their view while
+// it is stale, the nodes without result keep the reported capacity. A nil
+// clusterViews returns the reported node list.
+func (v *clusterViews) nodeList(mci *mciv1beta1.ManagedClusterInfo) []mciv1beta1.NodeStatus {
+	if v == nil || !v.isStale(mci) {
+		return mci.Status.NodeList
+	}
+	nodes := make([]mciv1beta1.NodeStatus, len(mci.Status.NodeList))
+	for i, n := range mci.Status.NodeList {
+		nodes[i] = n
+		viewCapacity := v.nodeCapacity(mci.Namespace, n.Name)
+		if len(viewCapacity) == 0 {
+			continue
+		}
+		capacity := mciv1beta1.ResourceList{}
+		for name, q := range n.Capacity {
+			capacity[name] = q
+		}
+		for name, q := range viewCapacity {
+			capacity[name] = q
+		}
+		nodes[i].Capacity = capacity
+	}
+	return nodes
+}
+
+// nodeCapacity returns the cpu and memory capacity of the node in the result
+// of its cached view, none while the view has no result.
+func (v *clusterViews) nodeCapacity(ns, node string) mciv1beta1.ResourceList {
+	result := v.nodeResult(ns, node)
+	if result == nil {
+		return nil
+	}
+	values, _, err := unstructured.NestedStringMap(result, "status", "capacity")
+	if err != nil {
+		klog.Errorf("Error: %v", err)
+		return nil
+	}
+	capacity := mciv1beta1.ResourceList{}
+	for _, name := range []mciv1beta1.ResourceName{mciv1beta1.ResourceCPU, mciv1beta1.ResourceMemory} {
+		value, ok := values[string(name)]
+		if !ok {
+			continue
+		}
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			klog.Errorf("Error: %v", err)
+			continue
+		}
+		capacity[name] = q
+	}
+	return capacity
+}
+
+// machineSetCount returns the number of MachineSets of the worker nodes of
+// the ManagedClusterInfo, read from the machineAnnotation of the nodes in the
+// result of their view. The Machines of a MachineSet are named after it with
+// a generated suffix, the nodes of the other Machines, ie: the control plane
+// ones, are not accounted. It returns false while no view has a result.
+func (v *clusterViews) machineSetCount(mci *mciv1beta1.ManagedClusterInfo) (int, bool) {
+	machineSets := map[string]bool{}
+	found := false
+	for _, n := range mci.Status.NodeList {
+		if isControlPlaneNode(n) {
+			continue
+		}
+		result := v.nodeResult(mci.Namespace, n.Name)
+		if result == nil {
+			continue
+		}
+		found = true
+		machine, _, _ := unstructured.NestedString(result, "metadata", "annotations", machineAnnotation)
+		if machineSetSuffix.MatchString(machine) {
+			machineSets[machineSetSuffix.ReplaceAllString(machine, "")] = true
+		}
+	}
+	return len(machineSets), found
+}
+
+// lastUpgrade returns the version and the completion time of the most recent
+// completed update of the ClusterVersion of the ManagedClusterInfo, read from
+// the history of the ClusterVersion in the result of its view. It returns
+// false while the view has no result or no update completed.
+func (v *clusterViews) lastUpgrade(mci *mciv1beta1.ManagedClusterInfo) (string, time.Time, bool) {
+	result := v.viewResult(mci.Namespace, clusterVersionViewName)
+	if result == nil {
+		return "", time.Time{}, false
+	}
+	history, _, err := unstructured.NestedSlice(result, "status", "history")
+	if err != nil {
+		klog.Errorf("Error: %v", err)
+		return "", time.Time{}, false
+	}
+	version, completion, found := "", time.Time{}, false
+	for _, h := range history {
+		entry, ok := h.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		state, _, _ := unstructured.NestedString(entry, "state")
+		completionTime, _, _ := unstructured.NestedString(entry, "completionTime")
+		if state != "Completed" || completionTime == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, completionTime)
+		if err != nil {
+			klog.Errorf("Error: %v", err)
+			continue
+		}
+		if !found || t.After(completion) {
+			version, _, _ = unstructured.NestedString(entry, "version")
+			completion, found = t, true
+		}
+	}
+	return version, completion, found
+}
+
+// desiredVersion returns the desired version of the ClusterVersion of the
+// ManagedClusterInfo in the result of its view. The version is empty while
+// the view has no result, ie: when the managed cluster has no ClusterVersion,
+// and for nil clusterViews.
+func (v *clusterViews) desiredVersion(mci *mciv1beta1.ManagedClusterInfo) string {
+	if v == nil {
+		return ""
+	}
+	version, _, _ := unstructured.NestedString(v.viewResult(mci.Namespace, clusterVersionViewName),
+		"status", "desired", "version")
+	return version
+}
+
+// nodeResult returns the node in the result of its cached view, nil while
+// the view has no result.
+func (v *clusterViews) nodeResult(ns, node string) map[string]interface{} {
+	return v.viewResult(ns, capacityViewName(node))
+}
+
+// viewResult returns the result of the cached view, nil while the view has
+// no result.
+func (v *clusterViews) viewResult(ns, name string) map[string]interface{} {
+	for _, informer := range v.views {
+		obj, exists, err := informer.GetIndexer().GetByKey(ns + "/" + name)
+		if err != nil || !exists {
+			continue
+		}
+		result, _, err := unstructured.NestedMap(obj.(*unstructured.Unstructured).Object, "status", "result")
+		if err != nil {
+			klog.Errorf("Error: %v", err)
+		}
+		return result
+	}
+	return nil
+}
+
+// capacityViewName returns the name of the capacity view of the node.
+func capacityViewName(node string) string {
+	return capacityViewPrefix + node
+}
+
+// createClusterViewListWatchWithClient lists and watches the
+// ManagedClusterViews of the namespace matching the selector.
+func createClusterViewListWatchWithClient(client dynamic.Interface, ns string, selector labels.Selector, timeout time.Duration) cache.ListWatch {
+	return cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			ctx, cancel := requestContext(mcvGVR.Resource, timeout)
+			defer cancel()
+			return client.Resource(mcvGVR).Namespace(ns).List(ctx, withLabelSelector(opts, selector))
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return client.Resource(mcvGVR).Namespace(ns).Watch(context.TODO(), withLabelSelector(opts, selector))
+		},
+	}
+}
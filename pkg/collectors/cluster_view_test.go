@@ -0,0 +1,293 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	mcv1 "github.com/open-cluster-management/api/cluster/v1"
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+func newClusterViewU(namespace, name string, labels map[string]string) *unstructured.Unstructured {
+	view := &unstructured.Unstructured{}
+	view.SetAPIVersion(mcvGVR.GroupVersion().String())
+	view.SetKind("ManagedClusterView")
+	view.SetNamespace(namespace)
+	view.SetName(name)
+	view.SetLabels(labels)
+	return view
+}
+
+func Test_clusterViews_capacity(t *testing.T) {
+	syncTime := time.Unix(1620000000, 0)
+	now = func() time.Time { return syncTime.Add(2 * time.Hour) }
+	defer func() { now = time.Now }()
+
+	mci := &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Namespace: "cluster-1"},
+		Status: mciv1beta1.ClusterInfoStatus{
+			Conditions: []metav1.Condition{
+				{Type: managedClusterInfoConditionSynced, Status: metav1.ConditionTrue, LastTransitionTime: metav1.NewTime(syncTime)},
+			},
+			NodeList: []mciv1beta1.NodeStatus{
+				{Name: "worker-1", Capacity: mciv1beta1.ResourceList{mciv1beta1.ResourceCPU: resource.MustParse("2")}},
+				{Name: "worker-2", Capacity: mciv1beta1.ResourceList{mciv1beta1.ResourceCPU: resource.MustParse("2")}},
+			},
+		},
+	}
+	mc := &mcv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster-1"}}
+	ours := map[string]string{clusterViewLabel: "true"}
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			mciGVR: "ManagedClusterInfoList",
+			mcGVR:  "ManagedClusterList",
+			mcvGVR: "ManagedClusterViewList",
+		},
+		newManagedClusterInfoU(t, mci.DeepCopy()),
+		newManagedClusterU(t, mc),
+		// The view of a removed node is deleted, the views of the other
+		// clients are kept.
+		newClusterViewU("cluster-1", "capacity-removed", ours),
+		newClusterViewU("cluster-1", "other", nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clusters := newClusterCache(client, []string{metav1.NamespaceAll}, nil, 0, defaultRequestTimeout, defaultClusterSettings)
+	clusters.run(ctx)
+	views := newClusterViews(client, clusters, []string{metav1.NamespaceAll}, time.Hour, false, false, 0)
+	for _, informer := range views.views {
+		go informer.Run(ctx.Done())
+	}
+	if !cache.WaitForCacheSync(ctx.Done(), clusters.hasSynced, views.hasSynced) {
+		t.Fatal("the caches didn't sync")
+	}
+
+	listViews := func() []string {
+		list, err := client.Resource(mcvGVR).Namespace("cluster-1").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := []string{}
+		for _, view := range list.Items {
+			names = append(names, view.GetName())
+		}
+		sort.Strings(names)
+		return names
+	}
+	views.sync()
+	if got, want := listViews(), []string{"capacity-worker-1", "capacity-worker-2", "other"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("views of the stale cluster = %v, want %v", got, want)
+	}
+
+	// The capacity of a node is read from its view once it has a result.
+	result := newClusterViewU("cluster-1", "capacity-worker-1", ours)
+	if err := unstructured.SetNestedStringMap(result.Object, map[string]string{"cpu": "8", "memory": "32Gi"},
+		"status", "result", "status", "capacity"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Resource(mcvGVR).Namespace("cluster-1").Update(ctx, result, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(views.nodeCapacity("cluster-1", "worker-1")) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	nodes := views.nodeList(mci)
+	if got := nodes[0].Capacity[mciv1beta1.ResourceCPU]; got.Cmp(resource.MustParse("8")) != 0 {
+		t.Errorf("cpu of worker-1 = %v, want 8", got.String())
+	}
+	if got := nodes[0].Capacity[mciv1beta1.ResourceMemory]; got.Cmp(resource.MustParse("32Gi")) != 0 {
+		t.Errorf("memory of worker-1 = %v, want 32Gi", got.String())
+	}
+	if got := nodes[1].Capacity[mciv1beta1.ResourceCPU]; got.Cmp(resource.MustParse("2")) != 0 {
+		t.Errorf("cpu of worker-2 = %v, want the reported 2", got.String())
+	}
+	if got := mci.Status.NodeList[0].Capacity[mciv1beta1.ResourceCPU]; got.Cmp(resource.MustParse("2")) != 0 {
+		t.Errorf("the node list of the ManagedClusterInfo was modified, cpu of worker-1 = %v", got.String())
+	}
+
+	// The views are deleted once the ManagedClusterInfo is synced again.
+	now = func() time.Time { return syncTime.Add(time.Minute) }
+	if got := views.nodeList(mci); !reflect.DeepEqual(got, mci.Status.NodeList) {
+		t.Errorf("nodeList() of the synced cluster = %v, want the reported %v", got, mci.Status.NodeList)
+	}
+	// The watch events of the created views preceded the update of the
+	// result, the cache holds them.
+	views.sync()
+	if got, want := listViews(), []string{"other"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("views of the synced cluster = %v, want %v", got, want)
+	}
+}
+
+func Test_getMachineSetMetricFamilies(t *testing.T) {
+	newNodeView := func(namespace, node, machine string) *unstructured.Unstructured {
+		view := newClusterViewU(namespace, capacityViewName(node), map[string]string{clusterViewLabel: "true"})
+		if err := unstructured.SetNestedStringMap(view.Object, map[string]string{machineAnnotation: machine},
+			"status", "result", "metadata", "annotations"); err != nil {
+			t.Fatal(err)
+		}
+		return view
+	}
+	newObjects := func(name string) (*unstructured.Unstructured, *unstructured.Unstructured) {
+		mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: name},
+			Status: mciv1beta1.ClusterInfoStatus{
+				ClusterID:  name + "_id",
+				KubeVendor: mciv1beta1.KubeVendorOpenShift,
+				NodeList: []mciv1beta1.NodeStatus{
+					{Name: "master-0", Labels: map[string]string{"node-role.kubernetes.io/master": ""}},
+					{Name: "worker-1", Labels: map[string]string{workerLabel: ""}},
+					{Name: "worker-2", Labels: map[string]string{workerLabel: ""}},
+					{Name: "worker-3", Labels: map[string]string{workerLabel: ""}},
+				},
+			},
+		})
+		return mci, newManagedClusterU(t, &mcv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	mciViews, mcViews := newObjects("views-cluster")
+	mciNoViews, mcNoViews := newObjects("no-views-cluster")
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			mciGVR: "ManagedClusterInfoList",
+			mcGVR:  "ManagedClusterList",
+			mcvGVR: "ManagedClusterViewList",
+		},
+		mciViews, mcViews, mciNoViews, mcNoViews,
+		// The control plane Machine is not part of a MachineSet, worker-3
+		// is accounted once its view has a result.
+		newNodeView("views-cluster", "master-0", "openshift-machine-api/views-cluster-master-0"),
+		newNodeView("views-cluster", "worker-1", "openshift-machine-api/views-cluster-worker-a-b2x4k"),
+		newNodeView("views-cluster", "worker-2", "openshift-machine-api/views-cluster-worker-a-9zq7m"),
+		newClusterViewU("views-cluster", capacityViewName("worker-3"), map[string]string{clusterViewLabel: "true"}))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clusters := newClusterCache(client, []string{metav1.NamespaceAll}, nil, 0, defaultRequestTimeout, defaultClusterSettings)
+	clusters.run(ctx)
+	views := newClusterViews(client, clusters, []string{metav1.NamespaceAll}, 0, true, false, 0)
+	for _, informer := range views.views {
+		go informer.Run(ctx.Done())
+	}
+	if !cache.WaitForCacheSync(ctx.Done(), clusters.hasSynced, views.hasSynced) {
+		t.Fatal("the caches didn't sync")
+	}
+
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         mciViews,
+			MetricNames: []string{"acm_managed_cluster_machineset_count"},
+			Want:        `acm_managed_cluster_machineset_count{hub_cluster_id="mycluster_id",managed_cluster_id="views-cluster_id"} 1`,
+		},
+		{
+			Obj:         mciNoViews,
+			MetricNames: []string{"acm_managed_cluster_machineset_count"},
+			Want:        "",
+		},
+	}
+	for i, c := range tests {
+		c.Func = metric.ComposeMetricGenFuncs(getMachineSetMetricFamilies("mycluster_id", clusters, views))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+
+	// The views of the OpenShift clusters are kept whatever their age.
+	views.sync()
+	list, err := client.Resource(mcvGVR).Namespace("no-views-cluster").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(list.Items); got != 4 {
+		t.Errorf("views of no-views-cluster = %d, want 4", got)
+	}
+}
+
+func Test_getLastUpgradeMetricFamilies(t *testing.T) {
+	newObjects := func(name string) (*unstructured.Unstructured, *unstructured.Unstructured) {
+		mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: name},
+			Status: mciv1beta1.ClusterInfoStatus{
+				ClusterID:  name + "_id",
+				KubeVendor: mciv1beta1.KubeVendorOpenShift,
+			},
+		})
+		return mci, newManagedClusterU(t, &mcv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	mciUpgraded, mcUpgraded := newObjects("upgraded-cluster")
+	mciNoView, mcNoView := newObjects("no-view-cluster")
+	view := newClusterViewU("upgraded-cluster", clusterVersionViewName, map[string]string{clusterViewLabel: "true"})
+	// The history is ordered from the newest entry, the partial update in
+	// progress is not accounted.
+	if err := unstructured.SetNestedSlice(view.Object, []interface{}{
+		map[string]interface{}{"state": "Partial", "version": "4.12.3", "startedTime": "2023-03-01T10:00:00Z"},
+		map[string]interface{}{"state": "Completed", "version": "4.12.2", "completionTime": "2023-02-01T10:00:00Z"},
+		map[string]interface{}{"state": "Completed", "version": "4.12.1", "completionTime": "2023-01-01T10:00:00Z"},
+	}, "status", "result", "status", "history"); err != nil {
+		t.Fatal(err)
+	}
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			mciGVR: "ManagedClusterInfoList",
+			mcGVR:  "ManagedClusterList",
+			mcvGVR: "ManagedClusterViewList",
+		},
+		mciUpgraded, mcUpgraded, mciNoView, mcNoView, view)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clusters := newClusterCache(client, []string{metav1.NamespaceAll}, nil, 0, defaultRequestTimeout, defaultClusterSettings)
+	clusters.run(ctx)
+	views := newClusterViews(client, clusters, []string{metav1.NamespaceAll}, 0, false, true, 0)
+	for _, informer := range views.views {
+		go informer.Run(ctx.Done())
+	}
+	if !cache.WaitForCacheSync(ctx.Done(), clusters.hasSynced, views.hasSynced) {
+		t.Fatal("the caches didn't sync")
+	}
+
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         mciUpgraded,
+			MetricNames: []string{"acm_managed_cluster_last_upgrade_timestamp_seconds"},
+			Want:        `acm_managed_cluster_last_upgrade_timestamp_seconds{hub_cluster_id="mycluster_id",managed_cluster_id="upgraded-cluster_id",version="4.12.2"} 1.6752456e+09`,
+		},
+		{
+			Obj:         mciNoView,
+			MetricNames: []string{"acm_managed_cluster_last_upgrade_timestamp_seconds"},
+			Want:        "",
+		},
+	}
+	for i, c := range tests {
+		c.Func = metric.ComposeMetricGenFuncs(getLastUpgradeMetricFamilies("mycluster_id", clusters, views))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+
+	// The view of the ClusterVersion is created for the OpenShift clusters.
+	views.sync()
+	got, err := client.Resource(mcvGVR).Namespace("no-view-cluster").Get(ctx, clusterVersionViewName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"apiGroup": "config.openshift.io",
+		"version":  "v1",
+		"kind":     "ClusterVersion",
+		"resource": "clusterversions",
+		"name":     "version",
+	}
+	if scope, _, _ := unstructured.NestedMap(got.Object, "spec", "scope"); !reflect.DeepEqual(scope, want) {
+		t.Errorf("scope = %v, want %v", scope, want)
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/klog/v2"
+	"k8s.io/kube-state-metrics/pkg/metric"
+	metricsstore "k8s.io/kube-state-metrics/pkg/metrics_store"
+)
+
+// excludeAnnotation set to "true" on a ManagedCluster excludes the cluster
+// from all the collectors, ie: the short-lived test clusters.
+const excludeAnnotation = "state-metrics.open-cluster-management.io/exclude"
+
+// clusterSelectorFor returns the selector of the collected clusters, the
+// clusters matching the selector and member of the clusterSet. A nil
+// selector and an empty clusterSet select all the clusters.
+func clusterSelectorFor(clusterSet string, selector labels.Selector) labels.Selector {
+	if selector == nil {
+		selector = labels.Everything()
+	}
+	if clusterSet == "" {
+		return selector
+	}
+	r, err := labels.NewRequirement(clusterSetLabel, selection.Equals, []string{clusterSet})
+	if err != nil {
+		klog.Fatalf("invalid clusterset %s: %v", clusterSet, err)
+	}
+	return selector.Add(*r)
+}
+
+// clusterFilter selects the collected clusters from their ManagedCluster.
+type clusterFilter struct {
+	// selector selects the clusters from their labels
+	selector labels.Selector
+	// createdWithin selects the clusters created within the duration before
+	// the generation of their metrics, 0 selects all the clusters
+	createdWithin time.Duration
+	// excludeVendors excludes the clusters whose ManagedClusterInfo reports
+	// one of these kube vendors, case-insensitively
+	excludeVendors []string
+	// excludeClouds excludes the clusters whose ManagedClusterInfo reports
+	// one of these cloud vendors, case-insensitively
+	excludeClouds []string
+}
+
+// empty returns true when the filter selects all the clusters but the
+// excluded ones.
+func (f clusterFilter) empty() bool {
+	return (f.selector == nil || f.selector.Empty()) && f.createdWithin == 0
+}
+
+// matches returns true when the filter selects the ManagedCluster, the
+// clusters with the excludeAnnotation are never selected.
+func (f clusterFilter) matches(mc metav1.Object) bool {
+	if isExcluded(mc) {
+		return false
+	}
+	if f.selector != nil && !f.selector.Matches(labels.Set(mc.GetLabels())) {
+		return false
+	}
+	if f.createdWithin > 0 && now().Sub(mc.GetCreationTimestamp().Time) > f.createdWithin {
+		return false
+	}
+	return true
+}
+
+// excludesVendor returns true when the kube vendor or the cloud vendor of a
+// ManagedClusterInfo is excluded by the filter.
+func (f clusterFilter) excludesVendor(kubeVendor, cloudVendor string) bool {
+	return containsFold(f.excludeVendors, kubeVendor) || containsFold(f.excludeClouds, cloudVendor)
+}
+
+// excludesVendorOf returns true when the ManagedClusterInfo of the cluster
+// reports an excluded vendor or cloud. The clusters without
+// ManagedClusterInfo are not excluded.
+func (f clusterFilter) excludesVendorOf(clusters *clusterCache, name string) bool {
+	if len(f.excludeVendors) == 0 && len(f.excludeClouds) == 0 {
+		return false
+	}
+	mci, err := clusters.getManagedClusterInfo(name)
+	if err != nil {
+		return false
+	}
+	return f.excludesVendor(string(mci.Status.KubeVendor), string(mci.Status.CloudVendor))
+}
+
+// containsFold returns true if the non-empty value is one of the values,
+// case-insensitively.
+func containsFold(values []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExcluded returns true if the ManagedCluster has the excludeAnnotation.
+func isExcluded(mc metav1.Object) bool {
+	return mc.GetAnnotations()[excludeAnnotation] == "true"
+}
+
+// withClusterSet wraps the generate function of a MetricsStore collector so
+// only the objects of the clusters matching the filter generate metrics,
+// ie: the member clusters of a ManagedClusterSet. The ManagedCluster of a
+// namespaced object is the one named after its namespace, the one of a
+// ManagedClusterInfo is resolved by clusterNameFor. An empty filter only
+// filters out the excluded clusters.
+func withClusterSet(clusters *clusterCache, filter clusterFilter, families int,
+	generateFunc func(interface{}) []metricsstore.FamilyByteSlicer) func(interface{}) []metricsstore.FamilyByteSlicer {
+	return func(obj interface{}) []metricsstore.FamilyByteSlicer {
+		u := obj.(*unstructured.Unstructured)
+		name := u.GetName()
+		if u.GetKind() != "ManagedCluster" {
+			name = u.GetNamespace()
+			if u.GetKind() == "ManagedClusterInfo" || name == "" {
+				name = clusters.clusterNameFor(u)
+			}
+		}
+		// The vendors are checked first, so the excluded clusters skip
+		// the lookups of the families.
+		if filter.excludesVendorOf(clusters, name) {
+			return emptyFamilies(families)
+		}
+		var mc metav1.Object = u
+		if u.GetKind() != "ManagedCluster" {
+			cluster, err := clusters.getManagedCluster(name)
+			if err != nil && filter.empty() {
+				// Without ManagedCluster the cluster can't be excluded,
+				// the families report the missing ManagedCluster.
+				return generateFunc(obj)
+			}
+			if err != nil {
+				if !errors.IsNotFound(err) {
+					countCollectorError(mcGVR.Resource, name, err)
+				}
+				return emptyFamilies(families)
+			}
+			mc = cluster
+		}
+		if !filter.matches(mc) {
+			return emptyFamilies(families)
+		}
+		return generateFunc(obj)
+	}
+}
+
+// withClusterSetRollup wraps the generate function of a rollup collector so
+// the rollups are computed from the objects of the clusters matching the
+// filter. The store must reflect the ManagedClusters, and the
+// ManagedClusterInfos to exclude vendors or clouds, their cluster is resolved
+// with the settings. An empty filter only filters out the excluded clusters.
+func withClusterSetRollup(settings clusterSettings, filter clusterFilter,
+	generateFunc func(interface{}) []metricsstore.FamilyByteSlicer) func(interface{}) []metricsstore.FamilyByteSlicer {
+	return func(obj interface{}) []metricsstore.FamilyByteSlicer {
+		objs := obj.([]interface{})
+		members := map[string]bool{}
+		excluded := map[string]bool{}
+		for _, o := range objs {
+			u := o.(*unstructured.Unstructured)
+			switch u.GetKind() {
+			case "ManagedCluster":
+				if filter.matches(u) {
+					members[u.GetName()] = true
+				} else {
+					excluded[u.GetName()] = true
+				}
+			case "ManagedClusterInfo":
+				kubeVendor, _, _ := unstructured.NestedString(u.Object, "status", "kubeVendor")
+				cloudVendor, _, _ := unstructured.NestedString(u.Object, "status", "cloudVendor")
+				if filter.excludesVendor(kubeVendor, cloudVendor) {
+					excluded[settings.clusterNameFor(u)] = true
+				}
+			}
+		}
+		// An empty filter keeps the objects of the clusters without
+		// ManagedCluster.
+		selected := func(name string) bool {
+			if excluded[name] {
+				return false
+			}
+			if filter.empty() {
+				return true
+			}
+			return members[name]
+		}
+		filtered := []interface{}{}
+		for _, o := range objs {
+			u := o.(*unstructured.Unstructured)
+			switch {
+			case u.GetKind() == "ManagedCluster":
+				if selected(u.GetName()) {
+					filtered = append(filtered, o)
+				}
+			case u.GetKind() == "ManagedClusterInfo":
+				if selected(settings.clusterNameFor(u)) {
+					filtered = append(filtered, o)
+				}
+			case u.GetNamespace() != "":
+				if selected(u.GetNamespace()) {
+					filtered = append(filtered, o)
+				}
+			default:
+				// The cluster scoped objects which are not clusters,
+				// ie: the ManagedClusterSets, are kept.
+				filtered = append(filtered, o)
+			}
+		}
+		return generateFunc(filtered)
+	}
+}
+
+func emptyFamilies(n int) []metricsstore.FamilyByteSlicer {
+	families := make([]metricsstore.FamilyByteSlicer, n)
+	for i := range families {
+		families[i] = &metric.Family{}
+	}
+	return families
+}
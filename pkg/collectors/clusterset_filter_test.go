@@ -0,0 +1,388 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	mcv1 "github.com/open-cluster-management/api/cluster/v1"
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+func newClusterSetTestObjects(t *testing.T) (member, other, memberMCI, otherMCI *unstructured.Unstructured) {
+	member = newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "cluster-dev",
+			Labels: map[string]string{clusterSetLabel: "dev"},
+		},
+	})
+	other = newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "cluster-prod",
+			Labels: map[string]string{clusterSetLabel: "prod"},
+		},
+	})
+	memberMCI = newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-dev", Namespace: "cluster-dev"},
+	})
+	otherMCI = newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-prod", Namespace: "cluster-prod"},
+	})
+	return
+}
+
+func Test_withClusterSet(t *testing.T) {
+	member, other, memberMCI, otherMCI := newClusterSetTestObjects(t)
+	clusters := newTestClusterCache(t, member, other)
+
+	families := []metric.FamilyGenerator{
+		{
+			Name: "acm_test",
+			Type: metric.Gauge,
+			Help: "test",
+			GenerateFunc: func(obj interface{}) *metric.Family {
+				u := obj.(*unstructured.Unstructured)
+				return &metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   []string{"kind", "name"},
+						LabelValues: []string{u.GetKind(), u.GetName()},
+						Value:       1,
+					},
+				}}
+			},
+		},
+	}
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         member,
+			MetricNames: []string{"acm_test"},
+			Want:        `acm_test{kind="ManagedCluster",name="cluster-dev"} 1`,
+		},
+		{
+			Obj:         memberMCI,
+			MetricNames: []string{"acm_test"},
+			Want:        `acm_test{kind="ManagedClusterInfo",name="cluster-dev"} 1`,
+		},
+		{
+			Obj:         other,
+			MetricNames: []string{"acm_test"},
+			Want:        "",
+		},
+		{
+			Obj:         otherMCI,
+			MetricNames: []string{"acm_test"},
+			Want:        "",
+		},
+	}
+	for i, c := range tests {
+		c.Func = withClusterSet(clusters, clusterFilter{selector: clusterSelectorFor("dev", nil)}, len(families), metric.ComposeMetricGenFuncs(families))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
+
+func Test_withClusterSetRollup(t *testing.T) {
+	member, other, memberMCI, otherMCI := newClusterSetTestObjects(t)
+	families := []metric.FamilyGenerator{
+		{
+			Name: "acm_test_objects",
+			Type: metric.Gauge,
+			Help: "test",
+			GenerateFunc: func(obj interface{}) *metric.Family {
+				return &metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   []string{"hub_cluster_id"},
+						LabelValues: []string{"mycluster_id"},
+						Value:       float64(len(obj.([]interface{}))),
+					},
+				}}
+			},
+		},
+	}
+	objs := []interface{}{member, other, memberMCI, otherMCI, newManagedClusterSetU(t, "dev")}
+	tests := []struct {
+		clusterSet string
+		selector   string
+		want       int
+	}{
+		{clusterSet: "dev", want: 3},
+		{clusterSet: "", want: 5},
+		{selector: clusterSetLabel + " in (dev,prod)", want: 5},
+		{clusterSet: "dev", selector: clusterSetLabel + "=prod", want: 1},
+	}
+	for i, tt := range tests {
+		selector, err := labels.Parse(tt.selector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := generateMetricsTestCase{
+			Obj:         objs,
+			MetricNames: []string{"acm_test_objects"},
+			Want:        fmt.Sprintf(`acm_test_objects{hub_cluster_id="mycluster_id"} %d`, tt.want),
+			Func:        withClusterSetRollup(defaultClusterSettings, clusterFilter{selector: clusterSelectorFor(tt.clusterSet, selector)}, metric.ComposeMetricGenFuncs(families)),
+		}
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
+
+func Test_clusterFilter_createdWithin(t *testing.T) {
+	scrapeTime := time.Date(2021, time.May, 10, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return scrapeTime }
+	defer func() { now = time.Now }()
+
+	newCluster := func(name string, created time.Time) *unstructured.Unstructured {
+		return newManagedClusterU(t, &mcv1.ManagedCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Labels:            map[string]string{clusterSetLabel: "dev"},
+				CreationTimestamp: metav1.NewTime(created),
+			},
+		})
+	}
+	recent := newCluster("cluster-recent", scrapeTime.Add(-time.Hour))
+	old := newCluster("cluster-old", scrapeTime.Add(-30*24*time.Hour))
+
+	tests := []struct {
+		name   string
+		filter clusterFilter
+		mc     *unstructured.Unstructured
+		want   bool
+	}{
+		{name: "recent", filter: clusterFilter{createdWithin: 24 * time.Hour}, mc: recent, want: true},
+		{name: "old", filter: clusterFilter{createdWithin: 24 * time.Hour}, mc: old, want: false},
+		{name: "no window", filter: clusterFilter{}, mc: old, want: true},
+		{name: "recent not member", filter: clusterFilter{selector: clusterSelectorFor("prod", nil), createdWithin: 24 * time.Hour}, mc: recent, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.matches(tt.mc); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if !(clusterFilter{selector: labels.Everything()}).empty() {
+		t.Errorf("expected the filter without window to be empty")
+	}
+	if (clusterFilter{selector: labels.Everything(), createdWithin: time.Hour}).empty() {
+		t.Errorf("expected the filter with a window not to be empty")
+	}
+}
+
+func Test_withClusterSet_excluded(t *testing.T) {
+	member, _, memberMCI, _ := newClusterSetTestObjects(t)
+	excluded := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "cluster-test",
+			Labels:      map[string]string{clusterSetLabel: "dev"},
+			Annotations: map[string]string{excludeAnnotation: "true"},
+		},
+	})
+	excludedMCI := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-test", Namespace: "cluster-test"},
+	})
+	orphanMCI := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-orphan", Namespace: "cluster-orphan"},
+	})
+	clusters := newTestClusterCache(t, member, excluded)
+
+	families := []metric.FamilyGenerator{
+		{
+			Name: "acm_test",
+			Type: metric.Gauge,
+			Help: "test",
+			GenerateFunc: func(obj interface{}) *metric.Family {
+				u := obj.(*unstructured.Unstructured)
+				return &metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   []string{"kind", "name"},
+						LabelValues: []string{u.GetKind(), u.GetName()},
+						Value:       1,
+					},
+				}}
+			},
+		},
+	}
+	for _, filter := range []clusterFilter{{selector: clusterSelectorFor("", nil)}, {selector: clusterSelectorFor("dev", nil)}} {
+		tests := []generateMetricsTestCase{
+			{
+				Obj:         memberMCI,
+				MetricNames: []string{"acm_test"},
+				Want:        `acm_test{kind="ManagedClusterInfo",name="cluster-dev"} 1`,
+			},
+			{
+				Obj:         excluded,
+				MetricNames: []string{"acm_test"},
+				Want:        "",
+			},
+			{
+				Obj:         excludedMCI,
+				MetricNames: []string{"acm_test"},
+				Want:        "",
+			},
+		}
+		for i, c := range tests {
+			c.Func = withClusterSet(clusters, filter, len(families), metric.ComposeMetricGenFuncs(families))
+			if err := c.run(); err != nil {
+				t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+			}
+		}
+	}
+
+	// Without filter, the ManagedClusterInfos without ManagedCluster are
+	// still generated, with a filter they are not selected.
+	c := generateMetricsTestCase{
+		Obj:         orphanMCI,
+		MetricNames: []string{"acm_test"},
+		Want:        `acm_test{kind="ManagedClusterInfo",name="cluster-orphan"} 1`,
+		Func:        withClusterSet(clusters, clusterFilter{selector: clusterSelectorFor("", nil)}, len(families), metric.ComposeMetricGenFuncs(families)),
+	}
+	if err := c.run(); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+
+	rollupFamilies := []metric.FamilyGenerator{
+		{
+			Name: "acm_test_objects",
+			Type: metric.Gauge,
+			Help: "test",
+			GenerateFunc: func(obj interface{}) *metric.Family {
+				return &metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   []string{"hub_cluster_id"},
+						LabelValues: []string{"mycluster_id"},
+						Value:       float64(len(obj.([]interface{}))),
+					},
+				}}
+			},
+		},
+	}
+	objs := []interface{}{member, memberMCI, excluded, excludedMCI, orphanMCI}
+	for i, tt := range []struct {
+		clusterSet string
+		want       int
+	}{
+		{clusterSet: "", want: 3},
+		{clusterSet: "dev", want: 2},
+	} {
+		c := generateMetricsTestCase{
+			Obj:         objs,
+			MetricNames: []string{"acm_test_objects"},
+			Want:        fmt.Sprintf(`acm_test_objects{hub_cluster_id="mycluster_id"} %d`, tt.want),
+			Func:        withClusterSetRollup(defaultClusterSettings, clusterFilter{selector: clusterSelectorFor(tt.clusterSet, nil)}, metric.ComposeMetricGenFuncs(rollupFamilies)),
+		}
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
+
+func Test_withClusterSet_excludedVendors(t *testing.T) {
+	newCluster := func(name string, vendor mciv1beta1.KubeVendorType, cloud mciv1beta1.CloudVendorType) (*unstructured.Unstructured, *unstructured.Unstructured) {
+		mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+		})
+		mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: name},
+			Status:     mciv1beta1.ClusterInfoStatus{KubeVendor: vendor, CloudVendor: cloud},
+		})
+		return mc, mci
+	}
+	ocpMC, ocpMCI := newCluster("cluster-ocp", mciv1beta1.KubeVendorOpenShift, mciv1beta1.CloudVendorAWS)
+	eksMC, eksMCI := newCluster("cluster-eks", mciv1beta1.KubeVendorEKS, mciv1beta1.CloudVendorAWS)
+	azureMC, azureMCI := newCluster("cluster-azure", mciv1beta1.KubeVendorOpenShift, mciv1beta1.CloudVendorAzure)
+	clusters := newTestClusterCache(t, ocpMC, ocpMCI, eksMC, eksMCI, azureMC, azureMCI)
+
+	families := []metric.FamilyGenerator{
+		{
+			Name: "acm_test",
+			Type: metric.Gauge,
+			Help: "test",
+			GenerateFunc: func(obj interface{}) *metric.Family {
+				u := obj.(*unstructured.Unstructured)
+				return &metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   []string{"kind", "name"},
+						LabelValues: []string{u.GetKind(), u.GetName()},
+						Value:       1,
+					},
+				}}
+			},
+		},
+	}
+	rollupFamilies := []metric.FamilyGenerator{
+		{
+			Name: "acm_test_objects",
+			Type: metric.Gauge,
+			Help: "test",
+			GenerateFunc: func(obj interface{}) *metric.Family {
+				return &metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   []string{"hub_cluster_id"},
+						LabelValues: []string{"mycluster_id"},
+						Value:       float64(len(obj.([]interface{}))),
+					},
+				}}
+			},
+		},
+	}
+	objs := []interface{}{ocpMC, ocpMCI, eksMC, eksMCI, azureMC, azureMCI}
+	for i, tt := range []struct {
+		name     string
+		filter   clusterFilter
+		excluded []*unstructured.Unstructured
+	}{
+		{
+			name:   "no filtering by default",
+			filter: clusterFilter{},
+		},
+		{
+			name:     "excluded vendor",
+			filter:   clusterFilter{excludeVendors: []string{"eks"}},
+			excluded: []*unstructured.Unstructured{eksMC, eksMCI},
+		},
+		{
+			name:     "excluded cloud",
+			filter:   clusterFilter{excludeClouds: []string{"AZURE", "GCP"}},
+			excluded: []*unstructured.Unstructured{azureMC, azureMCI},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, obj := range objs {
+				u := obj.(*unstructured.Unstructured)
+				want := fmt.Sprintf(`acm_test{kind="%s",name="%s"} 1`, u.GetKind(), u.GetName())
+				for _, e := range tt.excluded {
+					if e == u {
+						want = ""
+					}
+				}
+				c := generateMetricsTestCase{
+					Obj:         u,
+					MetricNames: []string{"acm_test"},
+					Want:        want,
+					Func:        withClusterSet(clusters, tt.filter, len(families), metric.ComposeMetricGenFuncs(families)),
+				}
+				if err := c.run(); err != nil {
+					t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+				}
+			}
+			c := generateMetricsTestCase{
+				Obj:         objs,
+				MetricNames: []string{"acm_test_objects"},
+				Want:        fmt.Sprintf(`acm_test_objects{hub_cluster_id="mycluster_id"} %d`, len(objs)-len(tt.excluded)),
+				Func:        withClusterSetRollup(defaultClusterSettings, tt.filter, metric.ComposeMetricGenFuncs(rollupFamilies)),
+			}
+			if err := c.run(); err != nil {
+				t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+			}
+		})
+	}
+}
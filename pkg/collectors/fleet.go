@@ -0,0 +1,670 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/kube-state-metrics/pkg/metric"
+	metricsstore "k8s.io/kube-state-metrics/pkg/metrics_store"
+
+	addonv1alpha1 "github.com/open-cluster-management/api/addon/v1alpha1"
+	mcv1 "github.com/open-cluster-management/api/cluster/v1"
+	mcv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	workv1 "github.com/open-cluster-management/api/work/v1"
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	"k8s.io/klog/v2"
+)
+
+var (
+	descFleetTotalClustersName     = "acm_fleet_total_clusters"
+	descFleetTotalClustersHelp     = "Number of managed clusters"
+	descFleetAvailableClustersName = "acm_fleet_available_clusters"
+	descFleetAvailableClustersHelp = "Number of available managed clusters"
+	descFleetDefaultLabels         = []string{"hub_cluster_id"}
+
+	descFleetPendingUpgradeName = "acm_fleet_clusters_with_pending_upgrade"
+	descFleetPendingUpgradeHelp = "Number of OpenShift managed clusters having available updates"
+
+	descFleetOCPClustersByMinorName   = "acm_fleet_ocp_clusters_by_minor"
+	descFleetOCPClustersByMinorHelp   = "Number of OpenShift managed clusters per minor version"
+	descFleetOCPClustersByMinorLabels = []string{"hub_cluster_id", "minor"}
+
+	descFleetWorkerCPUName       = "acm_fleet_worker_cpu"
+	descFleetWorkerCPUHelp       = "Cpu capacity of the worker nodes of the managed clusters"
+	descFleetControlPlaneCPUName = "acm_fleet_control_plane_cpu"
+	descFleetControlPlaneCPUHelp = "Cpu capacity of the control plane nodes of the managed clusters"
+
+	descFleetClustersByRegionName   = "acm_fleet_clusters_by_region"
+	descFleetClustersByRegionHelp   = "Number of managed clusters per cloud region"
+	descFleetClustersByRegionLabels = []string{"hub_cluster_id", "cloud", "region"}
+
+	descFleetUnavailableAddOnsName   = "acm_fleet_unavailable_addons"
+	descFleetUnavailableAddOnsHelp   = "Number of managed clusters where the addon is installed but not available"
+	descFleetUnavailableAddOnsLabels = []string{"hub_cluster_id", "addon"}
+
+	descClusterAddOnStatusCountName   = "acm_managed_cluster_addon_status_count"
+	descClusterAddOnStatusCountHelp   = "Number of addons of the managed cluster by status"
+	descClusterAddOnStatusCountLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"status"}
+
+	// addOnStatuses are the statuses of acm_managed_cluster_addon_status_count.
+	addOnStatuses = []string{addOnStatusAvailable, addOnStatusProgressing, addOnStatusDegraded, addOnStatusUnknown}
+
+	descClusterSchedulingDisabledName   = "acm_managed_cluster_scheduling_disabled"
+	descClusterSchedulingDisabledHelp   = "Managed cluster avoided by the placements as not accepted by the hub or tainted NoSelect"
+	descClusterSchedulingDisabledLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	// noSelectTaintEffects are the effects of the taints keeping the
+	// placements from selecting the cluster, PreferNoSelect is only a
+	// preference.
+	noSelectTaintEffects = map[string]bool{
+		"NoSelect":      true,
+		"NoSelectIfNew": true,
+	}
+
+	descClusterTaintName   = "acm_managed_cluster_taint"
+	descClusterTaintHelp   = "Taint of the managed cluster, 1 per taint"
+	descClusterTaintLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"key",
+		"value",
+		"effect"}
+
+	descClusterInfoAgeName   = "acm_managed_cluster_info_age_seconds"
+	descClusterInfoAgeHelp   = "Time elapsed since the ManagedClusterInfo of the managed cluster was last synced by its agent"
+	descClusterInfoAgeLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	descFleetDuplicateClusterIDName = "acm_duplicate_cluster_ids"
+	descFleetDuplicateClusterIDHelp = "Number of cluster ids reported by more than one managed cluster"
+
+	ocpMinorRegexp = regexp.MustCompile(`^v?(\d+\.\d+)(\.|$)`)
+)
+
+// unknownMinor is the minor of the OCP versions which can not be parsed.
+const unknownMinor = "unknown"
+
+// managedClusterInfoConditionSynced is set by the foundation agent when it
+// syncs the status of the ManagedClusterInfo from the managed cluster.
+const managedClusterInfoConditionSynced = "ManagedClusterInfoSynced"
+
+// managedClusterAddOnConditionProgressing is set by the addon agents while
+// they are deployed or upgraded, the addon API doesn't define it.
+const managedClusterAddOnConditionProgressing = "Progressing"
+
+const (
+	addOnStatusAvailable   = "Available"
+	addOnStatusProgressing = "Progressing"
+	addOnStatusDegraded    = "Degraded"
+	addOnStatusUnknown     = "Unknown"
+)
+
+// fleet holds the objects of the fleet rollup store by kind.
+type fleet struct {
+	managedClusters      []*mcv1.ManagedCluster
+	managedClusterInfos  []*mciv1beta1.ManagedClusterInfo
+	leases               []*coordinationv1.Lease
+	managedClusterAddOns []*addonv1alpha1.ManagedClusterAddOn
+	managedClusterSets   []*mcv1alpha1.ManagedClusterSet
+	manifestWorks        []*workv1.ManifestWork
+	// managedClusterTaints are the taints of the ManagedClusters by name
+	managedClusterTaints map[string][]managedClusterTaint
+	// addOnConfigs are the configs of the ManagedClusterAddOns by
+	// namespace/name
+	addOnConfigs map[string][]addOnConfigResource
+	// supportedAddOnConfigs are the supported configs of the
+	// ClusterManagementAddOns by name
+	supportedAddOnConfigs map[string][]addOnConfigResource
+	// nodeSummaries are the summaries of the node lists of the
+	// managedClusterInfos, in their order, once summarized
+	nodeSummaries []nodeSummary
+}
+
+func getFleetMetricFamilies(hubClusterID string, settings clusterSettings) []metric.FamilyGenerator {
+	duplicatesLogger := &duplicateClusterIDsLogger{}
+	return []metric.FamilyGenerator{
+		{
+			Name: descFleetTotalClustersName,
+			Type: metric.Gauge,
+			Help: descFleetTotalClustersHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descFleetDefaultLabels,
+						LabelValues: []string{hubClusterID},
+						Value:       float64(len(f.managedClusters)),
+					},
+				}}
+			}),
+		},
+		{
+			Name: descFleetAvailableClustersName,
+			Type: metric.Gauge,
+			Help: descFleetAvailableClustersHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				available := 0
+				for _, mc := range f.managedClusters {
+					if getAvailableStatus(mc) == "True" {
+						available++
+					}
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descFleetDefaultLabels,
+						LabelValues: []string{hubClusterID},
+						Value:       float64(available),
+					},
+				}}
+			}),
+		},
+		{
+			Name: descFleetPendingUpgradeName,
+			Type: metric.Gauge,
+			Help: descFleetPendingUpgradeHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				pending := 0
+				for _, mci := range f.managedClusterInfos {
+					if settings.clusterNameFor(mci) == "" {
+						continue
+					}
+					if mci.Status.KubeVendor == mciv1beta1.KubeVendorOpenShift &&
+						len(mci.Status.DistributionInfo.OCP.AvailableUpdates) > 0 {
+						pending++
+					}
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descFleetDefaultLabels,
+						LabelValues: []string{hubClusterID},
+						Value:       float64(pending),
+					},
+				}}
+			}),
+		},
+		{
+			Name: descFleetOCPClustersByMinorName,
+			Type: metric.Gauge,
+			Help: descFleetOCPClustersByMinorHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				counts := map[string]int{}
+				for _, mci := range f.managedClusterInfos {
+					if settings.clusterNameFor(mci) == "" {
+						continue
+					}
+					if mci.Status.KubeVendor == mciv1beta1.KubeVendorOpenShift {
+						counts[getOCPMinor(mci.Status.DistributionInfo.OCP.Version)]++
+					}
+				}
+				minors := make([]string, 0, len(counts))
+				for minor := range counts {
+					minors = append(minors, minor)
+				}
+				sort.Strings(minors)
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, minor := range minors {
+					family.Metrics = append(family.Metrics, &metric.Metric{
+						LabelKeys:   descFleetOCPClustersByMinorLabels,
+						LabelValues: []string{hubClusterID, minor},
+						Value:       float64(counts[minor]),
+					})
+				}
+				return family
+			}),
+		},
+		{
+			Name: descFleetWorkerCPUName,
+			Type: metric.Gauge,
+			Help: descFleetWorkerCPUHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descFleetDefaultLabels,
+						LabelValues: []string{hubClusterID},
+						Value:       f.sumNodeCPU(settings, func(s nodeSummary) mciv1beta1.ResourceList { return s.workerCapacity }),
+					},
+				}}
+			}),
+		},
+		{
+			Name: descFleetControlPlaneCPUName,
+			Type: metric.Gauge,
+			Help: descFleetControlPlaneCPUHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descFleetDefaultLabels,
+						LabelValues: []string{hubClusterID},
+						Value:       f.sumNodeCPU(settings, func(s nodeSummary) mciv1beta1.ResourceList { return s.controlPlaneCapacity }),
+					},
+				}}
+			}),
+		},
+		{
+			Name: descFleetClustersByRegionName,
+			Type: metric.Gauge,
+			Help: descFleetClustersByRegionHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				mcs := map[string]*mcv1.ManagedCluster{}
+				for _, mc := range f.managedClusters {
+					mcs[mc.GetName()] = mc
+				}
+				type cloudRegion struct{ cloud, region string }
+				counts := map[cloudRegion]int{}
+				for _, mci := range f.managedClusterInfos {
+					name := settings.clusterNameFor(mci)
+					if name == "" {
+						continue
+					}
+					counts[cloudRegion{getCloud(mci), getRegion(mci, mcs[name])}]++
+				}
+				regions := make([]cloudRegion, 0, len(counts))
+				for r := range counts {
+					regions = append(regions, r)
+				}
+				sort.Slice(regions, func(i, j int) bool {
+					if regions[i].cloud != regions[j].cloud {
+						return regions[i].cloud < regions[j].cloud
+					}
+					return regions[i].region < regions[j].region
+				})
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, r := range regions {
+					family.Metrics = append(family.Metrics, &metric.Metric{
+						LabelKeys:   descFleetClustersByRegionLabels,
+						LabelValues: []string{hubClusterID, r.cloud, r.region},
+						Value:       float64(counts[r]),
+					})
+				}
+				return family
+			}),
+		},
+		{
+			Name: descFleetUnavailableAddOnsName,
+			Type: metric.Gauge,
+			Help: descFleetUnavailableAddOnsHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				// Only the clusters where the addon is installed have a
+				// ManagedClusterAddOn, so the others are not counted.
+				counts := map[string]int{}
+				for _, mca := range f.managedClusterAddOns {
+					if _, ok := counts[mca.GetName()]; !ok {
+						counts[mca.GetName()] = 0
+					}
+					if !meta.IsStatusConditionTrue(mca.Status.Conditions, addonv1alpha1.ManagedClusterAddOnConditionAvailable) {
+						counts[mca.GetName()]++
+					}
+				}
+				addons := make([]string, 0, len(counts))
+				for addon := range counts {
+					addons = append(addons, addon)
+				}
+				sort.Strings(addons)
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, addon := range addons {
+					family.Metrics = append(family.Metrics, &metric.Metric{
+						LabelKeys:   descFleetUnavailableAddOnsLabels,
+						LabelValues: []string{hubClusterID, addon},
+						Value:       float64(counts[addon]),
+					})
+				}
+				return family
+			}),
+		},
+		{
+			Name: descClusterAddOnStatusCountName,
+			Type: metric.Gauge,
+			Help: descClusterAddOnStatusCountHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				counts := map[string]map[string]int{}
+				for _, mca := range f.managedClusterAddOns {
+					if counts[mca.GetNamespace()] == nil {
+						counts[mca.GetNamespace()] = map[string]int{}
+					}
+					counts[mca.GetNamespace()][getAddOnStatus(mca)]++
+				}
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, mci := range f.managedClusterInfos {
+					clusterID := settings.getClusterID(mci)
+					if clusterID == "" {
+						continue
+					}
+					for _, status := range addOnStatuses {
+						family.Metrics = append(family.Metrics, &metric.Metric{
+							LabelKeys:   descClusterAddOnStatusCountLabels,
+							LabelValues: []string{hubClusterID, clusterID, status},
+							Value:       float64(counts[settings.clusterNameFor(mci)][status]),
+						})
+					}
+				}
+				return family
+			}),
+		},
+		{
+			Name: descClusterSchedulingDisabledName,
+			Type: metric.Gauge,
+			Help: descClusterSchedulingDisabledHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				clusterIDs := map[string]string{}
+				for _, mci := range f.managedClusterInfos {
+					clusterIDs[settings.clusterNameFor(mci)] = settings.getClusterID(mci)
+				}
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, mc := range f.managedClusters {
+					clusterID := clusterIDs[mc.GetName()]
+					if clusterID == "" {
+						continue
+					}
+					value := 0.0
+					if isSchedulingDisabled(mc, f.managedClusterTaints[mc.GetName()]) {
+						value = 1
+					}
+					family.Metrics = append(family.Metrics, &metric.Metric{
+						LabelKeys:   descClusterSchedulingDisabledLabels,
+						LabelValues: []string{hubClusterID, clusterID},
+						Value:       value,
+					})
+				}
+				return family
+			}),
+		},
+		{
+			Name: descClusterTaintName,
+			Type: metric.Gauge,
+			Help: descClusterTaintHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				clusterIDs := map[string]string{}
+				for _, mci := range f.managedClusterInfos {
+					clusterIDs[settings.clusterNameFor(mci)] = settings.getClusterID(mci)
+				}
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, mc := range f.managedClusters {
+					clusterID := clusterIDs[mc.GetName()]
+					if clusterID == "" {
+						continue
+					}
+					for _, taint := range f.managedClusterTaints[mc.GetName()] {
+						family.Metrics = append(family.Metrics, &metric.Metric{
+							LabelKeys:   descClusterTaintLabels,
+							LabelValues: []string{hubClusterID, clusterID, taint.Key, taint.Value, taint.Effect},
+							Value:       1,
+						})
+					}
+				}
+				return family
+			}),
+		},
+		{
+			Name: descClusterInfoAgeName,
+			Type: metric.Gauge,
+			Help: descClusterInfoAgeHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, mci := range f.managedClusterInfos {
+					clusterID := settings.getClusterID(mci)
+					if clusterID == "" {
+						continue
+					}
+					synced := getManagedClusterInfoSyncTime(mci)
+					if synced.IsZero() {
+						continue
+					}
+					family.Metrics = append(family.Metrics, &metric.Metric{
+						LabelKeys:   descClusterInfoAgeLabels,
+						LabelValues: []string{hubClusterID, clusterID},
+						Value:       now().Sub(synced.Time).Seconds(),
+					})
+				}
+				return family
+			}),
+		},
+		{
+			Name: descFleetDuplicateClusterIDName,
+			Type: metric.Gauge,
+			Help: descFleetDuplicateClusterIDHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				duplicates := settings.getDuplicateClusterIDs(f.managedClusterInfos)
+				duplicatesLogger.log(duplicates)
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descFleetDefaultLabels,
+						LabelValues: []string{hubClusterID},
+						Value:       float64(len(duplicates)),
+					},
+				}}
+			}),
+		},
+	}
+}
+
+// getAddOnStatus returns the status of the addon from its conditions, a
+// degraded addon is Degraded even if available.
+func getAddOnStatus(mca *addonv1alpha1.ManagedClusterAddOn) string {
+	switch {
+	case meta.IsStatusConditionTrue(mca.Status.Conditions, addonv1alpha1.ManagedClusterAddOnConditionDegraded):
+		return addOnStatusDegraded
+	case meta.IsStatusConditionTrue(mca.Status.Conditions, managedClusterAddOnConditionProgressing):
+		return addOnStatusProgressing
+	case meta.IsStatusConditionTrue(mca.Status.Conditions, addonv1alpha1.ManagedClusterAddOnConditionAvailable):
+		return addOnStatusAvailable
+	}
+	return addOnStatusUnknown
+}
+
+// sumNodeCPU returns the cpu capacity of the nodes selected by capacity summed
+// over the clusters, in cores.
+func (f *fleet) sumNodeCPU(settings clusterSettings, capacity func(nodeSummary) mciv1beta1.ResourceList) float64 {
+	sum := resource.Quantity{}
+	for i, summary := range f.getNodeSummaries() {
+		if settings.clusterNameFor(f.managedClusterInfos[i]) == "" {
+			continue
+		}
+		sum.Add(capacity(summary)[mciv1beta1.ResourceCPU])
+	}
+	return float64(sum.MilliValue()) / 1000
+}
+
+// getNodeSummaries returns the summaries of the node lists of the
+// ManagedClusterInfos of the fleet, in their order. The node lists are
+// summarized once per fleet, the families of a generation pass share them.
+func (f *fleet) getNodeSummaries() []nodeSummary {
+	if f.nodeSummaries == nil {
+		f.nodeSummaries = make([]nodeSummary, 0, len(f.managedClusterInfos))
+		for _, mci := range f.managedClusterInfos {
+			f.nodeSummaries = append(f.nodeSummaries, summarizeNodes(mci.Status.NodeList))
+		}
+	}
+	return f.nodeSummaries
+}
+
+// getManagedClusterInfoSyncTime returns the last transition time of the
+// synced condition of the ManagedClusterInfo, or its creation timestamp
+// while the agent doesn't report the condition.
+func getManagedClusterInfoSyncTime(mci *mciv1beta1.ManagedClusterInfo) metav1.Time {
+	if c := meta.FindStatusCondition(mci.Status.Conditions, managedClusterInfoConditionSynced); c != nil {
+		return c.LastTransitionTime
+	}
+	return mci.CreationTimestamp
+}
+
+// isSchedulingDisabled returns true when the placements avoid the cluster,
+// the hub doesn't accept it or one of its taints has a noSelectTaintEffects.
+func isSchedulingDisabled(mc *mcv1.ManagedCluster, taints []managedClusterTaint) bool {
+	if !mc.Spec.HubAcceptsClient {
+		return true
+	}
+	for _, taint := range taints {
+		if noSelectTaintEffects[taint.Effect] {
+			return true
+		}
+	}
+	return false
+}
+
+// getDuplicateClusterIDs returns the sorted names of the managed clusters
+// by cluster id, for the cluster ids reported by more than one cluster.
+func (s clusterSettings) getDuplicateClusterIDs(mcis []*mciv1beta1.ManagedClusterInfo) map[string][]string {
+	names := map[string][]string{}
+	for _, mci := range mcis {
+		clusterID := s.getClusterID(mci)
+		if clusterID == "" {
+			continue
+		}
+		names[clusterID] = append(names[clusterID], s.clusterNameFor(mci))
+	}
+	for clusterID, n := range names {
+		if len(n) < 2 {
+			delete(names, clusterID)
+			continue
+		}
+		sort.Strings(n)
+	}
+	return names
+}
+
+// duplicateClusterIDsLogger logs the duplicate cluster ids when they change,
+// so the warnings are not repeated on each scrape.
+type duplicateClusterIDsLogger struct {
+	mutex sync.Mutex
+	// last are the warnings last logged
+	last string
+}
+
+// log logs a warning per duplicate cluster id with the names of its managed
+// clusters, unless they were already logged by the previous call.
+func (l *duplicateClusterIDsLogger) log(duplicates map[string][]string) {
+	warnings := []string{}
+	for _, clusterID := range sortedKeys(duplicates) {
+		warnings = append(warnings, fmt.Sprintf("The managed clusters %s report the same cluster id %s",
+			strings.Join(duplicates[clusterID], ","), clusterID))
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if last := strings.Join(warnings, "\n"); last != l.last {
+		l.last = last
+		for _, warning := range warnings {
+			klog.Warning(warning)
+		}
+	}
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// getOCPMinor returns the major.minor of the OCP version, for example 4.12
+// for 4.12.3, or unknownMinor when the version can't be parsed.
+func getOCPMinor(version string) string {
+	m := ocpMinorRegexp.FindStringSubmatch(version)
+	if m == nil {
+		return unknownMinor
+	}
+	return m[1]
+}
+
+// newFleet groups by kind the list of objects of a rollup store.
+func newFleet(objs []interface{}) *fleet {
+	f := &fleet{
+		managedClusters:      []*mcv1.ManagedCluster{},
+		managedClusterInfos:  []*mciv1beta1.ManagedClusterInfo{},
+		leases:               []*coordinationv1.Lease{},
+		managedClusterAddOns: []*addonv1alpha1.ManagedClusterAddOn{},
+		managedClusterSets:   []*mcv1alpha1.ManagedClusterSet{},
+		manifestWorks:        []*workv1.ManifestWork{},
+		managedClusterTaints: map[string][]managedClusterTaint{},
+
+		addOnConfigs:          map[string][]addOnConfigResource{},
+		supportedAddOnConfigs: map[string][]addOnConfigResource{},
+	}
+	for _, obj := range objs {
+		u := obj.(*unstructured.Unstructured)
+		var err error
+		switch u.GetKind() {
+		case "ManagedCluster":
+			mc := &mcv1.ManagedCluster{}
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &mc)
+			if err == nil {
+				f.managedClusters = append(f.managedClusters, mc)
+				f.managedClusterTaints[mc.GetName()], err = getManagedClusterTaints(u)
+			}
+		case "ManagedClusterInfo":
+			mci := &mciv1beta1.ManagedClusterInfo{}
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &mci)
+			if err == nil {
+				f.managedClusterInfos = append(f.managedClusterInfos, mci)
+			}
+		case "ManagedClusterAddOn":
+			mca := &addonv1alpha1.ManagedClusterAddOn{}
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &mca)
+			if err == nil {
+				f.managedClusterAddOns = append(f.managedClusterAddOns, mca)
+				f.addOnConfigs[mca.GetNamespace()+"/"+mca.GetName()], err = getAddOnConfigResources(u, "spec", "configs")
+			}
+		case "ClusterManagementAddOn":
+			f.supportedAddOnConfigs[u.GetName()], err = getAddOnConfigResources(u, "spec", "supportedConfigs")
+		case "ManagedClusterSet":
+			mcs := &mcv1alpha1.ManagedClusterSet{}
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &mcs)
+			if err == nil {
+				f.managedClusterSets = append(f.managedClusterSets, mcs)
+			}
+		case "ManifestWork":
+			mw := &workv1.ManifestWork{}
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &mw)
+			if err == nil {
+				f.manifestWorks = append(f.manifestWorks, mw)
+			}
+		case "Lease":
+			l := &coordinationv1.Lease{}
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &l)
+			if err == nil {
+				f.leases = append(f.leases, l)
+			}
+		}
+		if err != nil {
+			klog.Errorf("Error: %v", err)
+		}
+	}
+	return f
+}
+
+// withFleet wraps the generate function of a rollup collector so the objects
+// of the store are grouped by newFleet once per generation pass, the families
+// wrapped by wrapFleetFunc share the fleet.
+func withFleet(generateFunc func(interface{}) []metricsstore.FamilyByteSlicer) func(interface{}) []metricsstore.FamilyByteSlicer {
+	return func(obj interface{}) []metricsstore.FamilyByteSlicer {
+		return generateFunc(newFleet(obj.([]interface{})))
+	}
+}
+
+// wrapFleetFunc adapts a family of the fleet to the generate function
+// wrapped by withFleet.
+func wrapFleetFunc(f func(*fleet) metric.Family) func(interface{}) *metric.Family {
+	return func(obj interface{}) *metric.Family {
+		metricFamily := f(obj.(*fleet))
+
+		for _, m := range metricFamily.Metrics {
+			m.LabelKeys = append([]string{}, m.LabelKeys...)
+			m.LabelValues = append([]string{}, m.LabelValues...)
+		}
+
+		return &metricFamily
+	}
+}
@@ -0,0 +1,517 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	addonv1alpha1 "github.com/open-cluster-management/api/addon/v1alpha1"
+	mcv1 "github.com/open-cluster-management/api/cluster/v1"
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+func newManagedClusterU(t *testing.T, mc *mcv1.ManagedCluster) *unstructured.Unstructured {
+	mc.TypeMeta = metav1.TypeMeta{
+		APIVersion: mcv1.GroupVersion.String(),
+		Kind:       "ManagedCluster",
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(mc)
+	if err != nil {
+		t.Error(err)
+	}
+	return &unstructured.Unstructured{Object: content}
+}
+
+func newManagedClusterInfoU(t *testing.T, mci *mciv1beta1.ManagedClusterInfo) *unstructured.Unstructured {
+	mci.TypeMeta = metav1.TypeMeta{
+		APIVersion: mciv1beta1.GroupVersion.String(),
+		Kind:       "ManagedClusterInfo",
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(mci)
+	if err != nil {
+		t.Error(err)
+	}
+	return &unstructured.Unstructured{Object: content}
+}
+
+func newAddOnWithConditionU(t *testing.T, namespace, name string, conditions []metav1.Condition) *unstructured.Unstructured {
+	mca := &addonv1alpha1.ManagedClusterAddOn{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: addonv1alpha1.GroupVersion.String(),
+			Kind:       "ManagedClusterAddOn",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Status: addonv1alpha1.ManagedClusterAddOnStatus{
+			Conditions: conditions,
+		},
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(mca)
+	if err != nil {
+		t.Error(err)
+	}
+	return &unstructured.Unstructured{Object: content}
+}
+
+func Test_getFleetMetricFamilies(t *testing.T) {
+	mcAvailable := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster-available",
+		},
+		Status: mcv1.ManagedClusterStatus{
+			Conditions: []metav1.Condition{
+				{
+					Type:   mcv1.ManagedClusterConditionAvailable,
+					Status: metav1.ConditionTrue,
+				},
+			},
+		},
+	})
+	mcUnavailable := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster-unavailable",
+		},
+		Status: mcv1.ManagedClusterStatus{
+			Conditions: []metav1.Condition{
+				{
+					Type:   mcv1.ManagedClusterConditionAvailable,
+					Status: metav1.ConditionFalse,
+				},
+			},
+		},
+	})
+	mcNoCondition := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster-no-condition",
+		},
+	})
+
+	mciPendingUpgrade := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-available",
+			Namespace: "cluster-available",
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor: mciv1beta1.KubeVendorOpenShift,
+			DistributionInfo: mciv1beta1.DistributionInfo{
+				Type: mciv1beta1.DistributionTypeOCP,
+				OCP: mciv1beta1.OCPDistributionInfo{
+					Version:          "4.6.1",
+					AvailableUpdates: []string{"4.6.3", "4.6.4"},
+				},
+			},
+		},
+	})
+	mciUpToDate := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-unavailable",
+			Namespace: "cluster-unavailable",
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor: mciv1beta1.KubeVendorOpenShift,
+			DistributionInfo: mciv1beta1.DistributionInfo{
+				Type: mciv1beta1.DistributionTypeOCP,
+				OCP: mciv1beta1.OCPDistributionInfo{
+					Version: "4.6.4",
+				},
+			},
+		},
+	})
+	mciOther := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-no-condition",
+			Namespace: "cluster-no-condition",
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor: mciv1beta1.KubeVendorEKS,
+			Version:    "v1.19.6",
+		},
+	})
+
+	mciUnknownVersion := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-ocp3",
+			Namespace: "cluster-ocp3",
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor: mciv1beta1.KubeVendorOpenShift,
+			DistributionInfo: mciv1beta1.DistributionInfo{
+				Type: mciv1beta1.DistributionTypeOCP,
+				OCP: mciv1beta1.OCPDistributionInfo{
+					Version: "3",
+				},
+			},
+		},
+	})
+
+	// a copy of the ManagedClusterInfo of cluster-available outside of its
+	// namespace is not counted
+	mciPendingUpgradeCopy := mciPendingUpgrade.DeepCopy()
+	mciPendingUpgradeCopy.SetNamespace("cluster-copy")
+
+	mciDuplicateID := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-restored",
+			Namespace: "cluster-restored",
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor: mciv1beta1.KubeVendorOpenShift,
+			ClusterID:  "duplicated_cluster_id",
+		},
+	})
+	mciDuplicateID2 := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-original",
+			Namespace: "cluster-original",
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor: mciv1beta1.KubeVendorOpenShift,
+			ClusterID:  "duplicated_cluster_id",
+		},
+	})
+
+	mciRegionClaim := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-claim", Namespace: "cluster-claim"},
+		Status:     mciv1beta1.ClusterInfoStatus{CloudVendor: mciv1beta1.CloudVendorAWS},
+	})
+	mcRegionClaim := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-claim"},
+		Status: mcv1.ManagedClusterStatus{ClusterClaims: []mcv1.ManagedClusterClaim{
+			{Name: "region.open-cluster-management.io", Value: "us-east-1"},
+		}},
+	})
+	mciRegionLabel := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-label", Namespace: "cluster-label"},
+		Status: mciv1beta1.ClusterInfoStatus{
+			CloudVendor: mciv1beta1.CloudVendorAWS,
+			NodeList: []mciv1beta1.NodeStatus{
+				{Name: "node-1", Labels: map[string]string{"topology.kubernetes.io/region": "us-east-1"}},
+			},
+		},
+	})
+
+	mciNodes := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-nodes", Namespace: "cluster-nodes"},
+		Status: mciv1beta1.ClusterInfoStatus{
+			NodeList: []mciv1beta1.NodeStatus{
+				{
+					Name:     "master",
+					Labels:   map[string]string{"node-role.kubernetes.io/master": ""},
+					Capacity: mciv1beta1.ResourceList{mciv1beta1.ResourceCPU: resource.MustParse("4")},
+				},
+				{
+					Name:     "worker-1",
+					Labels:   map[string]string{workerLabel: ""},
+					Capacity: mciv1beta1.ResourceList{mciv1beta1.ResourceCPU: resource.MustParse("2")},
+				},
+				{
+					Name:     "worker-2",
+					Labels:   map[string]string{workerLabel: ""},
+					Capacity: mciv1beta1.ResourceList{mciv1beta1.ResourceCPU: resource.MustParse("500m")},
+				},
+			},
+		},
+	})
+	mciControlPlaneWorker := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-compact", Namespace: "cluster-compact"},
+		Status: mciv1beta1.ClusterInfoStatus{
+			NodeList: []mciv1beta1.NodeStatus{
+				{
+					Name: "control-plane-worker",
+					Labels: map[string]string{
+						"node-role.kubernetes.io/control-plane": "",
+						workerLabel:                             "",
+					},
+					Capacity: mciv1beta1.ResourceList{mciv1beta1.ResourceCPU: resource.MustParse("8")},
+				},
+			},
+		},
+	})
+
+	mcTainted := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-no-condition"},
+		Spec:       mcv1.ManagedClusterSpec{HubAcceptsClient: true},
+	})
+	if err := unstructured.SetNestedSlice(mcTainted.Object, []interface{}{
+		map[string]interface{}{"key": "example.com/maintenance", "effect": "NoSelect"},
+	}, "spec", "taints"); err != nil {
+		t.Error(err)
+	}
+	mcAccepted := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-ocp3"},
+		Spec:       mcv1.ManagedClusterSpec{HubAcceptsClient: true},
+	})
+
+	available := []metav1.Condition{{
+		Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+		Status: metav1.ConditionTrue,
+	}}
+	unavailable := []metav1.Condition{{
+		Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+		Status: metav1.ConditionFalse,
+	}}
+	addOns := []interface{}{
+		newAddOnWithConditionU(t, "cluster-available", "work-manager", available),
+		newAddOnWithConditionU(t, "cluster-unavailable", "work-manager", unavailable),
+		newAddOnWithConditionU(t, "cluster-no-condition", "work-manager", nil),
+		newAddOnWithConditionU(t, "cluster-available", "search-collector", available),
+		mcAvailable,
+	}
+
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         []interface{}{mcAvailable, mcUnavailable, mcNoCondition, mciPendingUpgrade, mciUpToDate, mciOther},
+			MetricNames: []string{"acm_fleet_total_clusters"},
+			Want:        `acm_fleet_total_clusters{hub_cluster_id="mycluster_id"} 3`,
+		},
+		{
+			Obj:         []interface{}{mcAvailable, mcUnavailable, mcNoCondition, mciPendingUpgrade, mciUpToDate, mciOther},
+			MetricNames: []string{"acm_fleet_available_clusters"},
+			Want:        `acm_fleet_available_clusters{hub_cluster_id="mycluster_id"} 1`,
+		},
+		{
+			Obj:         []interface{}{mcAvailable, mcUnavailable, mcNoCondition, mciPendingUpgrade, mciPendingUpgradeCopy, mciUpToDate, mciOther},
+			MetricNames: []string{"acm_fleet_clusters_with_pending_upgrade"},
+			Want:        `acm_fleet_clusters_with_pending_upgrade{hub_cluster_id="mycluster_id"} 1`,
+		},
+		{
+			Obj:         []interface{}{mcAvailable, mciNodes, mciControlPlaneWorker, mciOther},
+			MetricNames: []string{"acm_fleet_worker_cpu", "acm_fleet_control_plane_cpu"},
+			Want: `acm_fleet_worker_cpu{hub_cluster_id="mycluster_id"} 10.5
+acm_fleet_control_plane_cpu{hub_cluster_id="mycluster_id"} 12`,
+		},
+		{
+			Obj:         []interface{}{mcTainted, mcAccepted, mciOther, mciUnknownVersion},
+			MetricNames: []string{"acm_managed_cluster_scheduling_disabled"},
+			Want: `acm_managed_cluster_scheduling_disabled{hub_cluster_id="mycluster_id",managed_cluster_id="cluster-no-condition"} 1
+acm_managed_cluster_scheduling_disabled{hub_cluster_id="mycluster_id",managed_cluster_id="cluster-ocp3"} 0`,
+		},
+		{
+			Obj:         []interface{}{mcTainted, mcAccepted, mciOther, mciUnknownVersion},
+			MetricNames: []string{"acm_managed_cluster_taint"},
+			Want:        `acm_managed_cluster_taint{effect="NoSelect",hub_cluster_id="mycluster_id",key="example.com/maintenance",managed_cluster_id="cluster-no-condition",value=""} 1`,
+		},
+		{
+			Obj:         []interface{}{mcRegionClaim, mciRegionClaim, mciRegionLabel, mciOther},
+			MetricNames: []string{"acm_fleet_clusters_by_region"},
+			Want: `acm_fleet_clusters_by_region{cloud="Amazon",hub_cluster_id="mycluster_id",region="us-east-1"} 2
+acm_fleet_clusters_by_region{cloud="unknown",hub_cluster_id="mycluster_id",region="unknown"} 1`,
+		},
+		{
+			Obj:         []interface{}{mcAvailable, mciPendingUpgrade, mciPendingUpgradeCopy, mciUpToDate, mciOther, mciUnknownVersion},
+			MetricNames: []string{"acm_fleet_ocp_clusters_by_minor"},
+			Want: `acm_fleet_ocp_clusters_by_minor{hub_cluster_id="mycluster_id",minor="4.6"} 2
+acm_fleet_ocp_clusters_by_minor{hub_cluster_id="mycluster_id",minor="unknown"} 1`,
+		},
+		{
+			Obj:         []interface{}{mciPendingUpgrade, mciUpToDate, mciOther, mciDuplicateID, mciDuplicateID2},
+			MetricNames: []string{"acm_duplicate_cluster_ids"},
+			Want:        `acm_duplicate_cluster_ids{hub_cluster_id="mycluster_id"} 1`,
+		},
+		{
+			Obj:         []interface{}{mciPendingUpgrade, mciUpToDate, mciOther},
+			MetricNames: []string{"acm_duplicate_cluster_ids"},
+			Want:        `acm_duplicate_cluster_ids{hub_cluster_id="mycluster_id"} 0`,
+		},
+		{
+			Obj:         addOns,
+			MetricNames: []string{"acm_fleet_unavailable_addons"},
+			Want: `acm_fleet_unavailable_addons{hub_cluster_id="mycluster_id",addon="search-collector"} 0
+acm_fleet_unavailable_addons{hub_cluster_id="mycluster_id",addon="work-manager"} 2`,
+		},
+		{
+			Obj: append([]interface{}{mciOther, mciUnknownVersion,
+				newAddOnWithConditionU(t, "cluster-no-condition", "search-collector", []metav1.Condition{
+					{Type: addonv1alpha1.ManagedClusterAddOnConditionAvailable, Status: metav1.ConditionTrue},
+					{Type: addonv1alpha1.ManagedClusterAddOnConditionDegraded, Status: metav1.ConditionTrue},
+				}),
+				newAddOnWithConditionU(t, "cluster-no-condition", "policy-controller", []metav1.Condition{
+					{Type: "Progressing", Status: metav1.ConditionTrue},
+				}),
+			}, addOns...),
+			MetricNames: []string{"acm_managed_cluster_addon_status_count"},
+			Want: `acm_managed_cluster_addon_status_count{hub_cluster_id="mycluster_id",managed_cluster_id="cluster-no-condition",status="Available"} 0
+acm_managed_cluster_addon_status_count{hub_cluster_id="mycluster_id",managed_cluster_id="cluster-no-condition",status="Progressing"} 1
+acm_managed_cluster_addon_status_count{hub_cluster_id="mycluster_id",managed_cluster_id="cluster-no-condition",status="Degraded"} 1
+acm_managed_cluster_addon_status_count{hub_cluster_id="mycluster_id",managed_cluster_id="cluster-no-condition",status="Unknown"} 1
+acm_managed_cluster_addon_status_count{hub_cluster_id="mycluster_id",managed_cluster_id="cluster-ocp3",status="Available"} 0
+acm_managed_cluster_addon_status_count{hub_cluster_id="mycluster_id",managed_cluster_id="cluster-ocp3",status="Progressing"} 0
+acm_managed_cluster_addon_status_count{hub_cluster_id="mycluster_id",managed_cluster_id="cluster-ocp3",status="Degraded"} 0
+acm_managed_cluster_addon_status_count{hub_cluster_id="mycluster_id",managed_cluster_id="cluster-ocp3",status="Unknown"} 0`,
+		},
+		{
+			Obj:         []interface{}{},
+			MetricNames: []string{"acm_fleet_total_clusters", "acm_fleet_available_clusters"},
+			Want: `acm_fleet_total_clusters{hub_cluster_id="mycluster_id"} 0
+acm_fleet_available_clusters{hub_cluster_id="mycluster_id"} 0`,
+		},
+	}
+	for i, c := range tests {
+		c.Func = withFleet(metric.ComposeMetricGenFuncs(getFleetMetricFamilies("mycluster_id", defaultClusterSettings)))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
+
+func Test_getFleetMetricFamilies_infoAge(t *testing.T) {
+	scrapeTime := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return scrapeTime }
+	defer func() { now = time.Now }()
+
+	mciSynced := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "cluster-synced",
+			Namespace:         "cluster-synced",
+			CreationTimestamp: metav1.NewTime(scrapeTime.Add(-time.Hour)),
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			ClusterID: "synced_cluster_id",
+			Conditions: []metav1.Condition{
+				{
+					Type:               managedClusterInfoConditionSynced,
+					Status:             metav1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(scrapeTime.Add(-2 * time.Minute)),
+				},
+			},
+		},
+	})
+	mciNotSynced := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "cluster-not-synced",
+			Namespace:         "cluster-not-synced",
+			CreationTimestamp: metav1.NewTime(scrapeTime.Add(-time.Hour)),
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			ClusterID: "not_synced_cluster_id",
+		},
+	})
+	mciNoClusterID := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "cluster-no-id",
+			Namespace:         "cluster-no-id",
+			CreationTimestamp: metav1.NewTime(scrapeTime.Add(-time.Hour)),
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor: mciv1beta1.KubeVendorOpenShift,
+		},
+	})
+
+	c := generateMetricsTestCase{
+		Obj:         []interface{}{mciSynced, mciNotSynced, mciNoClusterID},
+		MetricNames: []string{"acm_managed_cluster_info_age_seconds"},
+		Want: `acm_managed_cluster_info_age_seconds{hub_cluster_id="mycluster_id",managed_cluster_id="synced_cluster_id"} 120
+acm_managed_cluster_info_age_seconds{hub_cluster_id="mycluster_id",managed_cluster_id="not_synced_cluster_id"} 3600`,
+		Func: withFleet(metric.ComposeMetricGenFuncs(getFleetMetricFamilies("mycluster_id", defaultClusterSettings))),
+	}
+	if err := c.run(); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func Test_getOCPMinor(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{version: "4.12.3", want: "4.12"},
+		{version: "4.7.0-0.nightly-2021-04-22-105612", want: "4.7"},
+		{version: "v4.6", want: "4.6"},
+		{version: "3", want: unknownMinor},
+		{version: "", want: unknownMinor},
+		{version: "4.10x", want: unknownMinor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if got := getOCPMinor(tt.version); got != tt.want {
+				t.Errorf("getOCPMinor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getDuplicateClusterIDs(t *testing.T) {
+	mcis := []*mciv1beta1.ManagedClusterInfo{}
+	for _, c := range []struct{ name, clusterID string }{
+		{"cluster-b", "id-1"},
+		{"cluster-a", "id-1"},
+		{"cluster-c", "id-2"},
+		{"cluster-d", ""},
+	} {
+		mcis = append(mcis, &mciv1beta1.ManagedClusterInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: c.name, Namespace: c.name},
+			Status: mciv1beta1.ClusterInfoStatus{
+				KubeVendor: mciv1beta1.KubeVendorOpenShift,
+				ClusterID:  c.clusterID,
+			},
+		})
+	}
+	want := map[string][]string{"id-1": {"cluster-a", "cluster-b"}}
+	if got := defaultClusterSettings.getDuplicateClusterIDs(mcis); !reflect.DeepEqual(got, want) {
+		t.Errorf("getDuplicateClusterIDs() = %v, want %v", got, want)
+	}
+}
+
+func Test_isSchedulingDisabled(t *testing.T) {
+	tests := []struct {
+		name             string
+		hubAcceptsClient bool
+		taints           []managedClusterTaint
+		want             bool
+	}{
+		{name: "accepted", hubAcceptsClient: true, want: false},
+		{name: "not accepted", hubAcceptsClient: false, want: true},
+		{
+			name:             "NoSelect",
+			hubAcceptsClient: true,
+			taints:           []managedClusterTaint{{Key: "example.com/maintenance", Effect: "NoSelect"}},
+			want:             true,
+		},
+		{
+			name:             "NoSelectIfNew",
+			hubAcceptsClient: true,
+			taints:           []managedClusterTaint{{Key: "example.com/maintenance", Effect: "NoSelectIfNew"}},
+			want:             true,
+		},
+		{
+			name:             "PreferNoSelect",
+			hubAcceptsClient: true,
+			taints:           []managedClusterTaint{{Key: "example.com/maintenance", Effect: "PreferNoSelect"}},
+			want:             false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := &mcv1.ManagedCluster{Spec: mcv1.ManagedClusterSpec{HubAcceptsClient: tt.hubAcceptsClient}}
+			if got := isSchedulingDisabled(mc, tt.taints); got != tt.want {
+				t.Errorf("isSchedulingDisabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_withFleet(t *testing.T) {
+	fleets := []*fleet{}
+	family := metric.FamilyGenerator{
+		Name: "acm_test",
+		Type: metric.Gauge,
+		Help: "test",
+		GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+			fleets = append(fleets, f)
+			return metric.Family{Metrics: []*metric.Metric{}}
+		}),
+	}
+	withFleet(metric.ComposeMetricGenFuncs([]metric.FamilyGenerator{family, family}))([]interface{}{})
+	if len(fleets) != 2 || fleets[0] != fleets[1] {
+		t.Errorf("expected the families of the generation pass to share the fleet")
+	}
+}
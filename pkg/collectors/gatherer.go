@@ -0,0 +1,59 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// metricsGatherer exposes the metrics of the collectors as a
+// prometheus.Gatherer, ie: to push them to a Pushgateway.
+type metricsGatherer struct {
+	collectors []MetricsWriter
+}
+
+// NewGatherer returns a prometheus.Gatherer of the metrics of the collectors.
+func NewGatherer(collectors []MetricsWriter) prometheus.Gatherer {
+	return &metricsGatherer{collectors: collectors}
+}
+
+// Gather parses the text exposition of each collector and merges the series
+// of the families exposed by several collectors, ie: the collectors of the
+// same kind of several hubs, which each write the headers of the family.
+func (g *metricsGatherer) Gather() ([]*dto.MetricFamily, error) {
+	merged := map[string]*dto.MetricFamily{}
+	for _, c := range g.collectors {
+		buf := new(bytes.Buffer)
+		c.WriteAll(buf)
+		var parser expfmt.TextParser
+		families, err := parser.TextToMetricFamilies(buf)
+		if err != nil {
+			return nil, err
+		}
+		for name, f := range families {
+			if m, ok := merged[name]; ok {
+				m.Metric = append(m.Metric, f.GetMetric()...)
+				continue
+			}
+			merged[name] = f
+		}
+	}
+	names := make([]string, 0, len(merged))
+	for name := range merged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]*dto.MetricFamily, 0, len(merged))
+	for _, name := range names {
+		// The families without series can't be pushed
+		if f := merged[name]; len(f.GetMetric()) > 0 {
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,75 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"testing"
+
+	mcv1 "github.com/open-cluster-management/api/cluster/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+func Test_metricsGatherer_Gather(t *testing.T) {
+	families := getFleetMetricFamilies("mycluster_id", defaultClusterSettings)[:2]
+	store := newRollupStore(metric.ExtractMetricFamilyHeaders(families),
+		withFleet(metric.ComposeMetricGenFuncs(families)))
+	mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster",
+		},
+	})
+	if err := store.source().Replace([]interface{}{mc}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewGatherer([]MetricsWriter{store}).Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{
+		"acm_fleet_total_clusters":     1,
+		"acm_fleet_available_clusters": 0,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d families, got %d", len(want), len(got))
+	}
+	for _, f := range got {
+		value, ok := want[f.GetName()]
+		if !ok {
+			t.Errorf("unexpected family %s", f.GetName())
+			continue
+		}
+		if v := f.GetMetric()[0].GetGauge().GetValue(); v != value {
+			t.Errorf("expected %s to be %v, got %v", f.GetName(), value, v)
+		}
+	}
+}
+
+func Test_metricsGatherer_Gather_sameFamily(t *testing.T) {
+	newStore := func(hubClusterID string) *rollupStore {
+		families := getFleetMetricFamilies(hubClusterID, defaultClusterSettings)[:1]
+		store := newRollupStore(metric.ExtractMetricFamilyHeaders(families),
+			withFleet(metric.ComposeMetricGenFuncs(families)))
+		mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "cluster",
+			},
+		})
+		if err := store.source().Replace([]interface{}{mc}, ""); err != nil {
+			t.Fatal(err)
+		}
+		return store
+	}
+
+	got, err := NewGatherer([]MetricsWriter{newStore("hub_1"), newStore("hub_2")}).Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].GetName() != "acm_fleet_total_clusters" {
+		t.Fatalf("expected the acm_fleet_total_clusters family, got %v", got)
+	}
+	if n := len(got[0].GetMetric()); n != 2 {
+		t.Errorf("expected a series per hub, got %d", n)
+	}
+}
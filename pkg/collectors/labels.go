@@ -0,0 +1,91 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"sort"
+
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+// truncationMarker is appended to the label values truncated because they
+// exceed the maximum label value length.
+const truncationMarker = "..."
+
+// withLabelValueMaxLength wraps the family generators so the label values of
+// the generated metrics are truncated to maxLength characters. A maxLength of
+// 0 disables the truncation.
+func withLabelValueMaxLength(families []metric.FamilyGenerator, maxLength int) []metric.FamilyGenerator {
+	if maxLength <= 0 {
+		return families
+	}
+	wrapped := make([]metric.FamilyGenerator, len(families))
+	for i, f := range families {
+		generateFunc := f.GenerateFunc
+		f.GenerateFunc = func(obj interface{}) *metric.Family {
+			family := generateFunc(obj)
+			for _, m := range family.Metrics {
+				values := make([]string, len(m.LabelValues))
+				for j, v := range m.LabelValues {
+					values[j] = truncateLabelValue(v, maxLength)
+				}
+				m.LabelValues = values
+			}
+			return family
+		}
+		wrapped[i] = f
+	}
+	return wrapped
+}
+
+// withConstLabels wraps the family generators so the given labels are added
+// to the generated metrics. A label already set by the family is kept as is.
+func withConstLabels(families []metric.FamilyGenerator, labels map[string]string) []metric.FamilyGenerator {
+	if len(labels) == 0 {
+		return families
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	wrapped := make([]metric.FamilyGenerator, len(families))
+	for i, f := range families {
+		generateFunc := f.GenerateFunc
+		f.GenerateFunc = func(obj interface{}) *metric.Family {
+			family := generateFunc(obj)
+			for _, m := range family.Metrics {
+				keys := append([]string{}, m.LabelKeys...)
+				values := append([]string{}, m.LabelValues...)
+				for _, name := range names {
+					if !hasLabel(m.LabelKeys, name) {
+						keys = append(keys, name)
+						values = append(values, labels[name])
+					}
+				}
+				m.LabelKeys = keys
+				m.LabelValues = values
+			}
+			return family
+		}
+		wrapped[i] = f
+	}
+	return wrapped
+}
+
+func hasLabel(keys []string, name string) bool {
+	for _, k := range keys {
+		if k == name {
+			return true
+		}
+	}
+	return false
+}
+
+func truncateLabelValue(value string, maxLength int) string {
+	r := []rune(value)
+	if len(r) <= maxLength {
+		return value
+	}
+	return string(r[:maxLength]) + truncationMarker
+}
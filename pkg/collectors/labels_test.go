@@ -0,0 +1,146 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"testing"
+
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+func Test_truncateLabelValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		maxLength int
+		want      string
+	}{
+		{
+			name:      "shorter",
+			value:     "4.6.1",
+			maxLength: 10,
+			want:      "4.6.1",
+		},
+		{
+			name:      "equal",
+			value:     "4.6.1",
+			maxLength: 5,
+			want:      "4.6.1",
+		},
+		{
+			name:      "longer",
+			value:     "4.7.0-0.nightly-2021-04-22-105612",
+			maxLength: 5,
+			want:      "4.7.0...",
+		},
+		{
+			name:      "multi-bytes characters",
+			value:     "clusterété",
+			maxLength: 9,
+			want:      "clusterét...",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateLabelValue(tt.value, tt.maxLength); got != tt.want {
+				t.Errorf("truncateLabelValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_withLabelValueMaxLength(t *testing.T) {
+	families := []metric.FamilyGenerator{
+		{
+			Name: "acm_test",
+			Type: metric.Gauge,
+			Help: "test",
+			GenerateFunc: func(obj interface{}) *metric.Family {
+				return &metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   []string{"version"},
+						LabelValues: []string{obj.(string)},
+						Value:       1,
+					},
+				}}
+			},
+		},
+	}
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         "4.7.0-0.nightly-2021-04-22-105612",
+			MetricNames: []string{"acm_test"},
+			Want:        `acm_test{version="4.7.0..."} 1`,
+		},
+		{
+			Obj:         "4.6.1",
+			MetricNames: []string{"acm_test"},
+			Want:        `acm_test{version="4.6.1"} 1`,
+		},
+	}
+	for i, c := range tests {
+		c.Func = metric.ComposeMetricGenFuncs(withLabelValueMaxLength(families, 5))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+	c := generateMetricsTestCase{
+		Obj:         "4.7.0-0.nightly-2021-04-22-105612",
+		MetricNames: []string{"acm_test"},
+		Want:        `acm_test{version="4.7.0-0.nightly-2021-04-22-105612"} 1`,
+		Func:        metric.ComposeMetricGenFuncs(withLabelValueMaxLength(families, 0)),
+	}
+	if err := c.run(); err != nil {
+		t.Errorf("unexpected collecting result without limit:\n%s", err)
+	}
+}
+
+func Test_withConstLabels(t *testing.T) {
+	families := []metric.FamilyGenerator{
+		{
+			Name: "acm_test",
+			Type: metric.Gauge,
+			Help: "test",
+			GenerateFunc: func(obj interface{}) *metric.Family {
+				return &metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   []string{"hub_cluster_id"},
+						LabelValues: []string{obj.(string)},
+						Value:       1,
+					},
+				}}
+			},
+		},
+	}
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         "mycluster_id",
+			MetricNames: []string{"acm_test"},
+			Want:        `acm_test{hub_cluster_id="mycluster_id",datacenter="east",environment="production"} 1`,
+			Func: metric.ComposeMetricGenFuncs(withConstLabels(families, map[string]string{
+				"environment": "production",
+				"datacenter":  "east",
+			})),
+		},
+		{
+			Obj:         "mycluster_id",
+			MetricNames: []string{"acm_test"},
+			Want:        `acm_test{hub_cluster_id="mycluster_id",environment="production"} 1`,
+			Func: metric.ComposeMetricGenFuncs(withConstLabels(families, map[string]string{
+				"environment":    "production",
+				"hub_cluster_id": "ignored",
+			})),
+		},
+		{
+			Obj:         "mycluster_id",
+			MetricNames: []string{"acm_test"},
+			Want:        `acm_test{hub_cluster_id="mycluster_id"} 1`,
+			Func:        metric.ComposeMetricGenFuncs(withConstLabels(families, nil)),
+		},
+	}
+	for i, c := range tests {
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
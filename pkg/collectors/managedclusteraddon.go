@@ -0,0 +1,182 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kube-state-metrics/pkg/metric"
+
+	addonv1alpha1 "github.com/open-cluster-management/api/addon/v1alpha1"
+	"k8s.io/klog/v2"
+)
+
+// clusterProxyAddOnName is the name of the ManagedClusterAddOn of the
+// cluster-proxy addon.
+const clusterProxyAddOnName = "cluster-proxy"
+
+var (
+	descAddOnConfiguredName          = "acm_managed_cluster_addon_configured"
+	descAddOnConfiguredHelp          = "Managed cluster addon configuration completeness"
+	descAddOnConfiguredDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"addon",
+		"install_namespace"}
+
+	descAddOnStatusConditionName          = "acm_managed_cluster_addon_status_condition"
+	descAddOnStatusConditionHelp          = "Status of the conditions of the managed cluster addon"
+	descAddOnStatusConditionDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"addon",
+		"condition",
+		"status"}
+
+	descClusterProxyRouteAvailableName          = "acm_cluster_proxy_route_available"
+	descClusterProxyRouteAvailableHelp          = "Availability of the proxied access to the managed cluster through the cluster-proxy addon"
+	descClusterProxyRouteAvailableDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	mcaGVR = schema.GroupVersionResource{
+		Group:    "addon.open-cluster-management.io",
+		Version:  "v1alpha1",
+		Resource: "managedclusteraddons",
+	}
+)
+
+func getManagedClusterAddOnMetricFamilies(hubClusterID string, clusters *clusterCache) []metric.FamilyGenerator {
+	return []metric.FamilyGenerator{
+		{
+			Name: descAddOnConfiguredName,
+			Type: metric.Gauge,
+			Help: descAddOnConfiguredHelp,
+			GenerateFunc: wrapManagedClusterAddOnFunc(func(mca *addonv1alpha1.ManagedClusterAddOn) metric.Family {
+				clusterID, err := getAddOnClusterID(clusters, mca)
+				if err != nil {
+					countCollectorError(mciGVR.Resource, mca.GetNamespace(), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				configured := 0.0
+				if isAddOnConfigured(mca) {
+					configured = 1
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descAddOnConfiguredDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID, mca.GetName(), mca.Spec.InstallNamespace},
+						Value:       configured,
+					},
+				}}
+			}),
+		},
+		{
+			Name: descAddOnStatusConditionName,
+			Type: metric.Gauge,
+			Help: descAddOnStatusConditionHelp,
+			GenerateFunc: wrapManagedClusterAddOnFunc(func(mca *addonv1alpha1.ManagedClusterAddOn) metric.Family {
+				clusterID, err := getAddOnClusterID(clusters, mca)
+				if err != nil {
+					countCollectorError(mciGVR.Resource, mca.GetNamespace(), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, c := range mca.Status.Conditions {
+					family.Metrics = append(family.Metrics, &metric.Metric{
+						LabelKeys:   descAddOnStatusConditionDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID, mca.GetName(), c.Type, strings.ToLower(string(c.Status))},
+						Value:       1,
+					})
+				}
+				return family
+			}),
+		},
+		{
+			Name: descClusterProxyRouteAvailableName,
+			Type: metric.Gauge,
+			Help: descClusterProxyRouteAvailableHelp,
+			GenerateFunc: wrapManagedClusterAddOnFunc(func(mca *addonv1alpha1.ManagedClusterAddOn) metric.Family {
+				if mca.GetName() != clusterProxyAddOnName {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID, err := getAddOnClusterID(clusters, mca)
+				if err != nil {
+					countCollectorError(mciGVR.Resource, mca.GetNamespace(), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				// The cluster-proxy addon is available once its agent
+				// established the tunnel with the proxy server.
+				available := 0.0
+				if meta.IsStatusConditionTrue(mca.Status.Conditions, addonv1alpha1.ManagedClusterAddOnConditionAvailable) {
+					available = 1
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descClusterProxyRouteAvailableDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID},
+						Value:       available,
+					},
+				}}
+			}),
+		},
+	}
+}
+
+// getAddOnClusterID returns the managed_cluster_id of the cluster the addon
+// is installed on, the addon namespace being the cluster name.
+func getAddOnClusterID(clusters *clusterCache, mca *addonv1alpha1.ManagedClusterAddOn) (string, error) {
+	mci, err := clusters.getManagedClusterInfo(mca.GetNamespace())
+	if err != nil {
+		return "", err
+	}
+	return clusters.getClusterID(mci), nil
+}
+
+// isAddOnConfigured returns true if the addon requires no configuration or
+// if its configuration CR is resolved.
+func isAddOnConfigured(mca *addonv1alpha1.ManagedClusterAddOn) bool {
+	config := mca.Status.AddOnConfiguration
+	return config.CRDName == "" || config.CRName != ""
+}
+
+func wrapManagedClusterAddOnFunc(f func(*addonv1alpha1.ManagedClusterAddOn) metric.Family) func(interface{}) *metric.Family {
+	return func(obj interface{}) *metric.Family {
+		mcaU := obj.(*unstructured.Unstructured)
+		mca := &addonv1alpha1.ManagedClusterAddOn{}
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(mcaU.UnstructuredContent(), &mca)
+		if err != nil {
+			klog.Errorf("Error: %v", err)
+			return &metric.Family{Metrics: []*metric.Metric{}}
+		}
+
+		metricFamily := f(mca)
+
+		for _, m := range metricFamily.Metrics {
+			m.LabelKeys = append([]string{}, m.LabelKeys...)
+			m.LabelValues = append([]string{}, m.LabelValues...)
+		}
+
+		return &metricFamily
+	}
+}
+
+func createManagedClusterAddOnListWatchWithClient(client dynamic.Interface, ns string, timeout time.Duration) cache.ListWatch {
+	return cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			ctx, cancel := requestContext(mcaGVR.Resource, timeout)
+			defer cancel()
+			return client.Resource(mcaGVR).Namespace(ns).List(ctx, opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return client.Resource(mcaGVR).Namespace(ns).Watch(context.TODO(), opts)
+		},
+	}
+}
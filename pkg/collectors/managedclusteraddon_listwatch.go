@@ -0,0 +1,16 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"time"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+func createManagedClusterAddOnListWatch(config *rest.Config, ns string, timeout time.Duration) cache.ListWatch {
+	client := dynamic.NewForConfigOrDie(config)
+	return createManagedClusterAddOnListWatchWithClient(client, ns, timeout)
+}
@@ -0,0 +1,134 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"testing"
+
+	addonv1alpha1 "github.com/open-cluster-management/api/addon/v1alpha1"
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+func newManagedClusterAddOnU(t *testing.T, namespace, name string, config addonv1alpha1.ConfigCoordinates) *unstructured.Unstructured {
+	mca := &addonv1alpha1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+			InstallNamespace: "open-cluster-management-agent-addon",
+		},
+		Status: addonv1alpha1.ManagedClusterAddOnStatus{
+			AddOnConfiguration: config,
+		},
+	}
+	mcaU := &unstructured.Unstructured{}
+	err := scheme.Scheme.Convert(mca, mcaU, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	return mcaU
+}
+
+func Test_getManagedClusterAddOnMetricFamilies(t *testing.T) {
+	s := scheme.Scheme
+
+	s.AddKnownTypes(mciv1beta1.GroupVersion, &mciv1beta1.ManagedClusterInfo{})
+	s.AddKnownTypes(addonv1alpha1.GroupVersion, &addonv1alpha1.ManagedClusterAddOn{})
+
+	mci := &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hive-cluster",
+			Namespace: "hive-cluster",
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor:  mciv1beta1.KubeVendorOpenShift,
+			CloudVendor: mciv1beta1.CloudVendorAWS,
+			ClusterID:   "managed_cluster_id",
+		},
+	}
+
+	mcaConfigured := newManagedClusterAddOnU(t, "hive-cluster", "search-collector", addonv1alpha1.ConfigCoordinates{
+		CRDName: "klusterletaddonconfigs.agent.open-cluster-management.io",
+		CRName:  "hive-cluster",
+	})
+	mcaNotConfigured := newManagedClusterAddOnU(t, "hive-cluster", "policy-controller", addonv1alpha1.ConfigCoordinates{
+		CRDName: "klusterletaddonconfigs.agent.open-cluster-management.io",
+	})
+	mcaNoConfig := newManagedClusterAddOnU(t, "hive-cluster", "work-manager", addonv1alpha1.ConfigCoordinates{})
+	mcaNoCluster := newManagedClusterAddOnU(t, "missing-cluster", "work-manager", addonv1alpha1.ConfigCoordinates{})
+
+	mcaProxyAvailable := newAddOnWithConditionU(t, "hive-cluster", "cluster-proxy", []metav1.Condition{{
+		Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+		Status: metav1.ConditionTrue,
+	}})
+	mcaProxyUnavailable := newAddOnWithConditionU(t, "hive-cluster", "cluster-proxy", []metav1.Condition{{
+		Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+		Status: metav1.ConditionUnknown,
+	}})
+
+	mcaDegraded := newAddOnWithConditionU(t, "hive-cluster", "search-collector", []metav1.Condition{
+		{Type: addonv1alpha1.ManagedClusterAddOnConditionAvailable, Status: metav1.ConditionTrue},
+		{Type: addonv1alpha1.ManagedClusterAddOnConditionDegraded, Status: metav1.ConditionTrue},
+	})
+
+	clusters := newTestClusterCache(t, mci)
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         mcaDegraded,
+			MetricNames: []string{"acm_managed_cluster_addon_status_condition"},
+			Want: `acm_managed_cluster_addon_status_condition{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id",addon="search-collector",condition="Available",status="true"} 1
+acm_managed_cluster_addon_status_condition{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id",addon="search-collector",condition="Degraded",status="true"} 1`,
+		},
+		{
+			Obj:         mcaNoConfig,
+			MetricNames: []string{"acm_managed_cluster_addon_status_condition"},
+			Want:        "",
+		},
+		{
+			Obj:         mcaProxyAvailable,
+			MetricNames: []string{"acm_cluster_proxy_route_available"},
+			Want:        `acm_cluster_proxy_route_available{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id"} 1`,
+		},
+		{
+			Obj:         mcaProxyUnavailable,
+			MetricNames: []string{"acm_cluster_proxy_route_available"},
+			Want:        `acm_cluster_proxy_route_available{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id"} 0`,
+		},
+		{
+			Obj:         mcaConfigured,
+			MetricNames: []string{"acm_cluster_proxy_route_available"},
+			Want:        "",
+		},
+		{
+			Obj:         mcaConfigured,
+			MetricNames: []string{"acm_managed_cluster_addon_configured"},
+			Want:        `acm_managed_cluster_addon_configured{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id",addon="search-collector",install_namespace="open-cluster-management-agent-addon"} 1`,
+		},
+		{
+			Obj:         mcaNotConfigured,
+			MetricNames: []string{"acm_managed_cluster_addon_configured"},
+			Want:        `acm_managed_cluster_addon_configured{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id",addon="policy-controller",install_namespace="open-cluster-management-agent-addon"} 0`,
+		},
+		{
+			Obj:         mcaNoConfig,
+			MetricNames: []string{"acm_managed_cluster_addon_configured"},
+			Want:        `acm_managed_cluster_addon_configured{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id",addon="work-manager",install_namespace="open-cluster-management-agent-addon"} 1`,
+		},
+		{
+			Obj:         mcaNoCluster,
+			MetricNames: []string{"acm_managed_cluster_addon_configured"},
+			Want:        "",
+		},
+	}
+	for i, c := range tests {
+		c.Func = metric.ComposeMetricGenFuncs(getManagedClusterAddOnMetricFamilies("mycluster_id", clusters))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
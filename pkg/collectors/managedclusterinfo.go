@@ -5,16 +5,24 @@ package collectors
 
 import (
 	"context"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/kube-state-metrics/pkg/metric"
+	metricsstore "k8s.io/kube-state-metrics/pkg/metrics_store"
 
 	mcv1 "github.com/open-cluster-management/api/cluster/v1"
 	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
@@ -26,11 +34,69 @@ const (
 
 	resourceCoreWorker   mcv1.ResourceName = "core_worker"
 	resourceSocketWorker mcv1.ResourceName = "socket_worker"
+	resourceMemoryWorker mcv1.ResourceName = "memory_worker"
 )
 
+// workerResourceNames are the names of the worker capacity resources of the
+// ManagedClusters, as written by the registration agent.
+type workerResourceNames struct {
+	coreWorker   mcv1.ResourceName
+	socketWorker mcv1.ResourceName
+	memoryWorker mcv1.ResourceName
+}
+
+// defaultWorkerResourceNames are the names written by the current agents.
+var defaultWorkerResourceNames = workerResourceNames{
+	coreWorker:   resourceCoreWorker,
+	socketWorker: resourceSocketWorker,
+	memoryWorker: resourceMemoryWorker,
+}
+
+// unknownCloud is the cloud of the clusters not reporting their cloud vendor,
+// ie: on-premise OpenShift clusters.
+const unknownCloud = "unknown"
+
+// The reasons of acm_managed_cluster_info_incomplete.
+const (
+	missingClusterID  = "missing_clusterid"
+	missingKubeVendor = "missing_kubevendor"
+)
+
+// unknownRegion is the region of the clusters reporting neither the
+// regionClaim nor the regionLabel on their nodes.
+const unknownRegion = "unknown"
+
+const (
+	// ocpVersionClaim is the well-known cluster claim of the OCP version,
+	// reported by the registration agent of the OpenShift clusters.
+	ocpVersionClaim = "version.openshift.io"
+	// regionClaim is the well-known cluster claim of the cloud region.
+	regionClaim = "region.open-cluster-management.io"
+	// regionLabel is the well-known label set by the cloud providers on the
+	// nodes with their region.
+	regionLabel = "topology.kubernetes.io/region"
+)
+
+// ocpPrereleaseRegexp matches the OCP nightly and CI builds, for example
+// 4.13.0-0.nightly-2023-01-27-165107, capturing their version and stream.
+var ocpPrereleaseRegexp = regexp.MustCompile(`^(\d+\.\d+\.\d+)-0\.([a-z]+)-`)
+
+// unknownVersion is the version of the OpenShift clusters whose status
+// doesn't carry the OCP distribution info yet.
+const unknownVersion = "unknown"
+
 const (
 	createdViaAnnotation      = "open-cluster-management/created-via"
 	createdViaAnnotationOther = "Other"
+	// createdViaCAPI is the created_via of the clusters provisioned by
+	// Cluster API, they have no created-via annotation.
+	createdViaCAPI = "CAPI"
+
+	hostingClusterAnnotation = "import.open-cluster-management.io/hosting-cluster-name"
+
+	// managedClusterConditionClockSynced is set by the registration agent
+	// when the clock of the managed cluster is in sync with the hub one.
+	managedClusterConditionClockSynced = "ManagedClusterConditionClockSynced"
 )
 
 var createdViaMapping map[string]string = map[string]string{
@@ -51,8 +117,168 @@ var (
 		"available",
 		"created_via",
 		"core_worker",
+		"socket_worker",
+		"kubernetes_version",
+		"hosting_cluster"}
+
+	descClusterVersionInfoName          = "acm_managed_cluster_version_info"
+	descClusterVersionInfoHelp          = "Managed cluster vendor and versions, the version part of acm_managed_cluster_info"
+	descClusterVersionInfoDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"vendor",
+		"version",
+		"kubernetes_version"}
+
+	descClusterCapacityInfoName          = "acm_managed_cluster_capacity_info"
+	descClusterCapacityInfoHelp          = "Managed cluster worker capacity, the capacity part of acm_managed_cluster_info"
+	descClusterCapacityInfoDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"core_worker",
 		"socket_worker"}
 
+	descClusterProvenanceInfoName          = "acm_managed_cluster_provenance_info"
+	descClusterProvenanceInfoHelp          = "Managed cluster cloud and provisioning, the provenance part of acm_managed_cluster_info"
+	descClusterProvenanceInfoDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"cloud",
+		"created_via",
+		"hosting_cluster"}
+
+	descClusterInfoIncompleteName          = "acm_managed_cluster_info_incomplete"
+	descClusterInfoIncompleteHelp          = "Managed cluster not reported by acm_managed_cluster_info, one series per missing information"
+	descClusterInfoIncompleteDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"reason"}
+
+	descClusterSpotWorkerCountName          = "acm_managed_cluster_spot_worker_count"
+	descClusterSpotWorkerCountHelp          = "Number of spot or preemptible worker nodes of the managed cluster"
+	descClusterSpotWorkerCountDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	descClusterThreadsPerCoreName          = "acm_managed_cluster_threads_per_core"
+	descClusterThreadsPerCoreHelp          = "Number of cpu threads per core of the worker nodes of the managed cluster, above 1 when hyperthreading is enabled"
+	descClusterThreadsPerCoreDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	descClusterNodePressureCountName          = "acm_managed_cluster_node_pressure_count"
+	descClusterNodePressureCountHelp          = "Number of nodes of the managed cluster under memory, disk or PID pressure"
+	descClusterNodePressureCountDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"pressure"}
+
+	descClusterReadyNodesName          = "acm_managed_cluster_ready_nodes"
+	descClusterReadyNodesHelp          = "Number of nodes of the managed cluster with the Ready condition true"
+	descClusterReadyNodesDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	descClusterWorkerCountName          = "acm_managed_cluster_worker_count"
+	descClusterWorkerCountHelp          = "Number of nodes of the managed cluster with the worker role"
+	descClusterWorkerCountDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	descClusterControlPlaneCountName          = "acm_managed_cluster_control_plane_count"
+	descClusterControlPlaneCountHelp          = "Number of nodes of the managed cluster with the master or control-plane role"
+	descClusterControlPlaneCountDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	descClusterInstanceTypeVarietyName          = "acm_managed_cluster_instance_type_variety"
+	descClusterInstanceTypeVarietyHelp          = "Number of distinct instance types of the nodes of the managed cluster"
+	descClusterInstanceTypeVarietyDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	descClusterKubernetesVersionName          = "acm_managed_cluster_kubernetes_version"
+	descClusterKubernetesVersionHelp          = "Kubernetes version of the managed cluster whatever its vendor"
+	descClusterKubernetesVersionDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"version"}
+
+	descClusterConsoleName          = "acm_managed_cluster_console"
+	descClusterConsoleHelp          = "Console URL of the managed cluster"
+	descClusterConsoleDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"console_url"}
+
+	descClusterOCPChannelName          = "acm_managed_cluster_ocp_channel"
+	descClusterOCPChannelHelp          = "Upgrade channel of the OpenShift managed cluster"
+	descClusterOCPChannelDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"channel"}
+
+	descClusterOCPAvailableUpdatesName          = "acm_managed_cluster_ocp_available_updates"
+	descClusterOCPAvailableUpdatesHelp          = "Number of versions the OpenShift managed cluster can be upgraded to"
+	descClusterOCPAvailableUpdatesDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	descClusterMemoryName          = "acm_managed_cluster_memory_bytes"
+	descClusterMemoryHelp          = "Memory capacity of the managed cluster in bytes"
+	descClusterMemoryDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	descClusterMemoryWorkerName          = "acm_managed_cluster_memory_worker_bytes"
+	descClusterMemoryWorkerHelp          = "Memory capacity of the worker nodes of the managed cluster in bytes"
+	descClusterMemoryWorkerDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	descClusterClockSyncedName          = "acm_managed_cluster_clock_synced"
+	descClusterClockSyncedHelp          = "Status of the clock synchronization of the managed cluster with the hub, one series per status"
+	descClusterClockSyncedDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"status"}
+
+	descClusterInfoLastUpdatedName          = "acm_managed_cluster_info_last_updated"
+	descClusterInfoLastUpdatedHelp          = "Last update timestamp of the ManagedClusterInfo of the managed cluster in unix time"
+	descClusterInfoLastUpdatedDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	descClusterCreatedName          = "acm_managed_cluster_created"
+	descClusterCreatedHelp          = "Creation timestamp of the managed cluster on the hub in unix time"
+	descClusterCreatedDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"managed_cluster_name"}
+
+	descClusterAutoscalerEnabledName          = "acm_managed_cluster_autoscaler_enabled"
+	descClusterAutoscalerEnabledHelp          = "Cluster autoscaler enabled on the managed cluster as reported by the configured cluster claim"
+	descClusterAutoscalerEnabledDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	descClusterFIPSName          = "acm_managed_cluster_fips"
+	descClusterFIPSHelp          = "FIPS mode enabled on the managed cluster as reported by the configured cluster claim"
+	descClusterFIPSDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	descClusterEtcdEncryptionName          = "acm_managed_cluster_etcd_encryption_enabled"
+	descClusterEtcdEncryptionHelp          = "Etcd encryption enabled on the managed cluster as reported by the configured cluster claim"
+	descClusterEtcdEncryptionDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	descClusterMachineSetCountName          = "acm_managed_cluster_machineset_count"
+	descClusterMachineSetCountHelp          = "Number of MachineSets of the worker nodes of the OpenShift managed cluster as read from the views of its nodes"
+	descClusterMachineSetCountDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	descClusterLastUpgradeName          = "acm_managed_cluster_last_upgrade_timestamp_seconds"
+	descClusterLastUpgradeHelp          = "Completion time of the last upgrade of the OpenShift managed cluster as read from the view of its ClusterVersion"
+	descClusterLastUpgradeDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"version"}
+
+	descClusterCPUByInstanceTypeName          = "acm_managed_cluster_cpu_by_instance_type"
+	descClusterCPUByInstanceTypeHelp          = "Cpu capacity of the worker nodes of the managed cluster by instance type"
+	descClusterCPUByInstanceTypeDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"instance_type"}
+
+	descClusterCapacityName          = "acm_managed_cluster_capacity"
+	descClusterCapacityHelp          = "Capacity of the managed cluster reported by the ManagedCluster for the configured resources"
+	descClusterCapacityDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"resource"}
+
+	descClusterTotalNodesName          = "acm_managed_cluster_total_nodes"
+	descClusterTotalNodesHelp          = "Number of nodes of the managed cluster"
+	descClusterTotalNodesDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
 	cvGVR = schema.GroupVersionResource{
 		Group:    "config.openshift.io",
 		Version:  "v1",
@@ -72,66 +298,66 @@ var (
 	}
 )
 
-func getManagedClusterInfoMetricFamilies(hubClusterID string, client dynamic.Interface) []metric.FamilyGenerator {
+// getManagedClusterInfoMetricFamilies returns the ManagedClusterInfo families,
+// the info metric carries the provider_cluster_id label with the value of the
+// providerClusterIDClaim cluster claim when a claim name is provided, and the
+// api_url label with the URL of the kube-apiserver of the cluster when apiURL
+// is true, and the managed_cluster_uid label with the uid of the
+// ManagedCluster when clusterUID is true. The labels of the info metric are
+// restricted to the infoLabels, an empty list keeps all the labels.
+func getManagedClusterInfoMetricFamilies(hubClusterID string, clusters *clusterCache, nodes *nodeSummaryPass, views *clusterViews, providerClusterIDClaim string, apiURL bool, clusterUID bool, infoLabels []string) []metric.FamilyGenerator {
+	labelKeys := append([]string{}, descClusterInfoDefaultLabels...)
+	if providerClusterIDClaim != "" {
+		labelKeys = append(labelKeys, "provider_cluster_id")
+	}
+	if apiURL {
+		labelKeys = append(labelKeys, "api_url")
+	}
+	if clusterUID {
+		labelKeys = append(labelKeys, "managed_cluster_uid")
+	}
+	keptLabels := filterInfoLabels(labelKeys, infoLabels)
+	labelKeys = pickLabels(labelKeys, keptLabels)
 	return []metric.FamilyGenerator{
 		{
 			Name: descClusterInfoName,
 			Type: metric.Gauge,
 			Help: descClusterInfoHelp,
 			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
-				klog.Infof("Wrap %s", obj.GetName())
-				mciU, errMCI := client.Resource(mciGVR).Namespace(obj.GetName()).Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
-				if errMCI != nil {
-					klog.Errorf("Error: %v", errMCI)
-					return metric.Family{Metrics: []*metric.Metric{}}
-				}
-				mci := &mciv1beta1.ManagedClusterInfo{}
-				err := runtime.DefaultUnstructuredConverter.FromUnstructured(mciU.UnstructuredContent(), &mci)
+				klog.V(4).Infof("Wrap %s", obj.GetName())
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
 				if err != nil {
-					klog.Errorf("Error: %v", err)
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
 					return metric.Family{Metrics: []*metric.Metric{}}
 				}
-				mcU, errMC := client.Resource(mcGVR).Get(context.TODO(), mci.GetName(), metav1.GetOptions{})
-				if errMC != nil {
-					klog.Errorf("Error: %v", errMC)
-					return metric.Family{Metrics: []*metric.Metric{}}
-				}
-				klog.Infof("mcU: %v", mcU)
-				mc := &mcv1.ManagedCluster{}
-				err = runtime.DefaultUnstructuredConverter.FromUnstructured(mcU.UnstructuredContent(), &mc)
+				mc, err := clusters.getManagedCluster(clusters.clusterNameFor(mci))
 				if err != nil {
-					klog.Errorf("Error: %v", err)
+					countCollectorError(mcGVR.Resource, clusters.clusterNameFor(mci), err)
 					return metric.Family{Metrics: []*metric.Metric{}}
 				}
+				klog.V(4).Infof("mc: %v", mc)
 				available := getAvailableStatus(mc)
-				// klog.Infof("mc: %v", mc)
-				createdVia := getCreatedVia(mc)
-				clusterID := mci.Status.ClusterID
-				//Cluster ID is not available on non-OCP thus use the name
-				if clusterID == "" &&
-					mci.Status.KubeVendor != mciv1beta1.KubeVendorOpenShift {
-					clusterID = mci.GetName()
-				}
+				createdVia := clusters.getCreatedVia(mc)
+				clusterID := clusters.getClusterID(mci)
 
-				//ClusterID is not available on OCP 3.x thus use the name
-				if clusterID == "" &&
-					mci.Status.KubeVendor == mciv1beta1.KubeVendorOpenShift && mci.Status.DistributionInfo.OCP.Version == "3" {
-					clusterID = mci.GetName()
+				version, mismatch := clusters.getVersion(mci, mc, views)
+				// The family is also regenerated on the updates of the
+				// ManagedCluster, the mismatch is counted once per update
+				// of the ManagedClusterInfo.
+				if mismatch && obj.GetKind() == "ManagedClusterInfo" {
+					klog.Warningf("ManagedClusterInfo %s has vendor %s but no OCP distribution info",
+						mci.GetName(), mci.Status.KubeVendor)
+					DistributionMismatchMetric.Inc()
 				}
-
-				version := getVersion(mci)
-				core_worker, socket_worker := getCapacity(mc)
+				core_worker, socket_worker := getInfoCapacity(mci, mc, nodes.summaryOf(mci), clusters.workerResources)
 
 				nodeListLength := len(mci.Status.NodeList)
 
-				if clusterID == "" ||
-					mci.Status.KubeVendor == "" ||
-					mci.Status.CloudVendor == "" ||
-					version == "" ||
-					nodeListLength == 0 ||
-					((core_worker == 0 || socket_worker == 0) && hasWorker(mci)) {
-					klog.Infof("Not enough information available for %s", mci.GetName())
-					klog.Infof(`\tClusterID=%s,
+				// The clusters being imported are reported as soon as they
+				// are identified, the capacity they don't report yet is 0.
+				if len(clusters.getMissingInfo(mci)) > 0 {
+					klog.V(4).Infof("Not enough information available for %s", mci.GetName())
+					klog.V(4).Infof(`\tClusterID=%s,
 KubeVendor=%s,
 CloudVendor=%s,
 Version=%s,
@@ -152,109 +378,1173 @@ socket_worker=%d`,
 				labelsValues := []string{hubClusterID,
 					clusterID,
 					string(mci.Status.KubeVendor),
-					string(mci.Status.CloudVendor),
+					getCloud(mci),
 					version,
 					available,
 					createdVia,
 					strconv.FormatInt(core_worker, 10),
 					strconv.FormatInt(socket_worker, 10),
+					getKubernetesVersion(mci, mc),
+					getHostingCluster(mc),
+				}
+				if providerClusterIDClaim != "" {
+					labelsValues = append(labelsValues, getClusterClaim(mc, providerClusterIDClaim))
+				}
+				if apiURL {
+					labelsValues = append(labelsValues, getAPIURL(mc))
+				}
+				if clusterUID {
+					labelsValues = append(labelsValues, string(mc.GetUID()))
 				}
 
 				f := metric.Family{Metrics: []*metric.Metric{
 					{
-						LabelKeys:   descClusterInfoDefaultLabels,
-						LabelValues: labelsValues,
+						LabelKeys:   labelKeys,
+						LabelValues: pickLabels(labelsValues, keptLabels),
 						Value:       1,
 					},
 				}}
-				klog.Infof("Returning %v", string(f.ByteSlice()))
+				klog.V(4).Infof("Returning %v", string(f.ByteSlice()))
 				return f
 			}),
 		},
-	}
-}
-
-func getVersion(mci *mciv1beta1.ManagedClusterInfo) string {
-	if mci.Status.KubeVendor == "" {
-		return ""
-	}
-	switch mci.Status.KubeVendor {
-	case mciv1beta1.KubeVendorOpenShift:
-		return mci.Status.DistributionInfo.OCP.Version
-	default:
-		return mci.Status.Version
-	}
-
-}
-
-func hasWorker(mci *mciv1beta1.ManagedClusterInfo) bool {
-	for _, n := range mci.Status.NodeList {
-		if _, ok := n.Labels[workerLabel]; ok {
-			return true
-		}
-	}
-	return false
-}
-
-func getCapacity(mc *mcv1.ManagedCluster) (core_worker, socket_worker int64) {
-	if q, ok := mc.Status.Capacity[resourceCoreWorker]; ok {
-		core_worker = q.Value()
-	}
-	if q, ok := mc.Status.Capacity[resourceSocketWorker]; ok {
-		socket_worker = q.Value()
-	}
-	return
-}
-
-func getAvailableStatus(mc *mcv1.ManagedCluster) string {
-	status := metav1.ConditionUnknown
-	for _, c := range mc.Status.Conditions {
-		if c.Type == mcv1.ManagedClusterConditionAvailable {
-			status = c.Status
-			break
-		}
-	}
-	if status == metav1.ConditionFalse {
-		status = metav1.ConditionUnknown
-	}
-	return string(status)
-}
-
-func wrapManagedClusterInfoFunc(f func(*unstructured.Unstructured) metric.Family) func(interface{}) *metric.Family {
-	return func(obj interface{}) *metric.Family {
-		Cluster := obj.(*unstructured.Unstructured)
-
-		metricFamily := f(Cluster)
-
-		for _, m := range metricFamily.Metrics {
-			m.LabelKeys = append([]string{}, m.LabelKeys...)
-			m.LabelValues = append([]string{}, m.LabelValues...)
-		}
-
-		return &metricFamily
-	}
-}
-
-func createManagedClusterInfoListWatchWithClient(client dynamic.Interface, ns string) cache.ListWatch {
-	return cache.ListWatch{
-		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
-			return client.Resource(mciGVR).Namespace(ns).List(context.TODO(), opts)
+		{
+			Name: descClusterInfoIncompleteName,
+			Type: metric.Gauge,
+			Help: descClusterInfoIncompleteHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				// The cluster name stands for the missing cluster id.
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" {
+					clusterID = clusters.clusterNameFor(mci)
+				}
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, reason := range clusters.getMissingInfo(mci) {
+					family.Metrics = append(family.Metrics, &metric.Metric{
+						LabelKeys:   descClusterInfoIncompleteDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID, reason},
+						Value:       1,
+					})
+				}
+				return family
+			}),
 		},
-		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
-			return client.Resource(mciGVR).Namespace(ns).Watch(context.TODO(), opts)
+		{
+			Name: descClusterSpotWorkerCountName,
+			Type: metric.Gauge,
+			Help: descClusterSpotWorkerCountHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" || len(mci.Status.NodeList) == 0 {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descClusterSpotWorkerCountDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID},
+						Value:       float64(nodes.summaryOf(mci).spotWorkers),
+					},
+				}}
+			}),
 		},
-	}
-}
-
-func createManagedClusterListWatchWithClient(client dynamic.Interface) cache.ListWatch {
-	return cache.ListWatch{
-		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
-			return client.Resource(mcGVR).List(context.TODO(), opts)
+		{
+			Name: descClusterThreadsPerCoreName,
+			Type: metric.Gauge,
+			Help: descClusterThreadsPerCoreHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				mc, err := clusters.getManagedCluster(clusters.clusterNameFor(mci))
+				if err != nil {
+					countCollectorError(mcGVR.Resource, clusters.clusterNameFor(mci), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				threadsPerCore := getThreadsPerCore(mc, nodes.summaryOf(mci), clusters.workerResources)
+				if clusterID == "" || threadsPerCore == 0 {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descClusterThreadsPerCoreDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID},
+						Value:       threadsPerCore,
+					},
+				}}
+			}),
 		},
-		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
-			return client.Resource(mcGVR).Watch(context.TODO(), opts)
+		{
+			Name: descClusterNodePressureCountName,
+			Type: metric.Gauge,
+			Help: descClusterNodePressureCountHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" || len(mci.Status.NodeList) == 0 {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				pressures := nodes.summaryOf(mci).pressures
+				names := make([]string, 0, len(pressures))
+				for pressure := range pressures {
+					names = append(names, pressure)
+				}
+				sort.Strings(names)
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, pressure := range names {
+					family.Metrics = append(family.Metrics, &metric.Metric{
+						LabelKeys:   descClusterNodePressureCountDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID, pressure},
+						Value:       float64(pressures[pressure]),
+					})
+				}
+				return family
+			}),
 		},
-	}
+		{
+			Name: descClusterReadyNodesName,
+			Type: metric.Gauge,
+			Help: descClusterReadyNodesHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" || len(mci.Status.NodeList) == 0 {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descClusterReadyNodesDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID},
+						Value:       float64(nodes.summaryOf(mci).readyNodes),
+					},
+				}}
+			}),
+		},
+		{
+			Name: descClusterTotalNodesName,
+			Type: metric.Gauge,
+			Help: descClusterTotalNodesHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" || len(mci.Status.NodeList) == 0 {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descClusterTotalNodesDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID},
+						Value:       float64(nodes.summaryOf(mci).totalNodes),
+					},
+				}}
+			}),
+		},
+		{
+			Name: descClusterWorkerCountName,
+			Type: metric.Gauge,
+			Help: descClusterWorkerCountHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" || len(mci.Status.NodeList) == 0 {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descClusterWorkerCountDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID},
+						Value:       float64(nodes.summaryOf(mci).workerNodes),
+					},
+				}}
+			}),
+		},
+		{
+			Name: descClusterControlPlaneCountName,
+			Type: metric.Gauge,
+			Help: descClusterControlPlaneCountHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" || len(mci.Status.NodeList) == 0 {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descClusterControlPlaneCountDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID},
+						Value:       float64(nodes.summaryOf(mci).controlPlaneNodes),
+					},
+				}}
+			}),
+		},
+		{
+			Name: descClusterInstanceTypeVarietyName,
+			Type: metric.Gauge,
+			Help: descClusterInstanceTypeVarietyHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" || len(mci.Status.NodeList) == 0 {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descClusterInstanceTypeVarietyDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID},
+						Value:       float64(len(nodes.summaryOf(mci).instanceTypes)),
+					},
+				}}
+			}),
+		},
+		{
+			Name: descClusterKubernetesVersionName,
+			Type: metric.Gauge,
+			Help: descClusterKubernetesVersionHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				mc, err := clusters.getManagedCluster(clusters.clusterNameFor(mci))
+				if err != nil {
+					countCollectorError(mcGVR.Resource, clusters.clusterNameFor(mci), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				version := getKubernetesVersion(mci, mc)
+				if clusterID == "" || version == "" {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descClusterKubernetesVersionDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID, version},
+						Value:       1,
+					},
+				}}
+			}),
+		},
+		{
+			Name: descClusterConsoleName,
+			Type: metric.Gauge,
+			Help: descClusterConsoleHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" || mci.Status.ConsoleURL == "" {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descClusterConsoleDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID, mci.Status.ConsoleURL},
+						Value:       1,
+					},
+				}}
+			}),
+		},
+		{
+			Name: descClusterOCPChannelName,
+			Type: metric.Gauge,
+			Help: descClusterOCPChannelHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mciU, err := clusters.getManagedClusterInfoU(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				mci, err := toManagedClusterInfo(mciU)
+				if err != nil {
+					klog.Errorf("Error: %v", err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				channel := getOCPChannel(mciU)
+				if clusterID == "" || mci.Status.KubeVendor != mciv1beta1.KubeVendorOpenShift || channel == "" {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descClusterOCPChannelDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID, channel},
+						Value:       1,
+					},
+				}}
+			}),
+		},
+		{
+			Name: descClusterOCPAvailableUpdatesName,
+			Type: metric.Gauge,
+			Help: descClusterOCPAvailableUpdatesHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" || mci.Status.KubeVendor != mciv1beta1.KubeVendorOpenShift {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descClusterOCPAvailableUpdatesDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID},
+						Value:       float64(len(mci.Status.DistributionInfo.OCP.AvailableUpdates)),
+					},
+				}}
+			}),
+		},
+		{
+			Name: descClusterMemoryName,
+			Type: metric.Gauge,
+			Help: descClusterMemoryHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				mc, err := clusters.getManagedCluster(clusters.clusterNameFor(mci))
+				if err != nil {
+					countCollectorError(mcGVR.Resource, clusters.clusterNameFor(mci), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				memory, _ := getMemory(mc, clusters.workerResources)
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descClusterMemoryDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID},
+						Value:       float64(memory),
+					},
+				}}
+			}),
+		},
+		{
+			Name: descClusterMemoryWorkerName,
+			Type: metric.Gauge,
+			Help: descClusterMemoryWorkerHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				mc, err := clusters.getManagedCluster(clusters.clusterNameFor(mci))
+				if err != nil {
+					countCollectorError(mcGVR.Resource, clusters.clusterNameFor(mci), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				_, memoryWorker := getMemory(mc, clusters.workerResources)
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descClusterMemoryWorkerDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID},
+						Value:       float64(memoryWorker),
+					},
+				}}
+			}),
+		},
+		{
+			Name: descClusterClockSyncedName,
+			Type: metric.Gauge,
+			Help: descClusterClockSyncedHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				mc, err := clusters.getManagedCluster(clusters.clusterNameFor(mci))
+				if err != nil {
+					countCollectorError(mcGVR.Resource, clusters.clusterNameFor(mci), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				synced := getClockSyncedStatus(mc)
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, status := range []metav1.ConditionStatus{metav1.ConditionTrue, metav1.ConditionFalse, metav1.ConditionUnknown} {
+					value := 0.0
+					if status == synced {
+						value = 1
+					}
+					family.Metrics = append(family.Metrics, &metric.Metric{
+						LabelKeys:   descClusterClockSyncedDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID, strings.ToLower(string(status))},
+						Value:       value,
+					})
+				}
+				return family
+			}),
+		},
+		{
+			Name: descClusterCreatedName,
+			Type: metric.Gauge,
+			Help: descClusterCreatedHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				mc, err := clusters.getManagedCluster(clusters.clusterNameFor(mci))
+				if err != nil {
+					countCollectorError(mcGVR.Resource, clusters.clusterNameFor(mci), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" || mc.CreationTimestamp.IsZero() {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descClusterCreatedDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID, mc.GetName()},
+						Value:       float64(mc.CreationTimestamp.Unix()),
+					},
+				}}
+			}),
+		},
+		{
+			Name: descClusterInfoLastUpdatedName,
+			Type: metric.Gauge,
+			Help: descClusterInfoLastUpdatedHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				lastUpdated := getLastUpdated(mci)
+				if clusterID == "" || lastUpdated.IsZero() {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descClusterInfoLastUpdatedDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID},
+						Value:       float64(lastUpdated.Unix()),
+					},
+				}}
+			}),
+		},
+	}
+}
+
+// getSplitInfoMetricFamilies returns the families splitting the labels of
+// acm_managed_cluster_info by topic, they join on managed_cluster_id. Like the
+// info metric, they are not exposed for the incomplete clusters.
+func getSplitInfoMetricFamilies(hubClusterID string, clusters *clusterCache, nodes *nodeSummaryPass, views *clusterViews) []metric.FamilyGenerator {
+	return []metric.FamilyGenerator{
+		splitInfoFamily(descClusterVersionInfoName, descClusterVersionInfoHelp, descClusterVersionInfoDefaultLabels,
+			hubClusterID, clusters, func(mci *mciv1beta1.ManagedClusterInfo, mc *mcv1.ManagedCluster) []string {
+				version, _ := clusters.getVersion(mci, mc, views)
+				return []string{string(mci.Status.KubeVendor), version, getKubernetesVersion(mci, mc)}
+			}),
+		splitInfoFamily(descClusterCapacityInfoName, descClusterCapacityInfoHelp, descClusterCapacityInfoDefaultLabels,
+			hubClusterID, clusters, func(mci *mciv1beta1.ManagedClusterInfo, mc *mcv1.ManagedCluster) []string {
+				core_worker, socket_worker := getInfoCapacity(mci, mc, nodes.summaryOf(mci), clusters.workerResources)
+				return []string{strconv.FormatInt(core_worker, 10), strconv.FormatInt(socket_worker, 10)}
+			}),
+		splitInfoFamily(descClusterProvenanceInfoName, descClusterProvenanceInfoHelp, descClusterProvenanceInfoDefaultLabels,
+			hubClusterID, clusters, func(mci *mciv1beta1.ManagedClusterInfo, mc *mcv1.ManagedCluster) []string {
+				return []string{getCloud(mci), clusters.getCreatedVia(mc), getHostingCluster(mc)}
+			}),
+	}
+}
+
+// splitInfoFamily returns an info family of the complete clusters, the labels
+// after the cluster ids are returned by values.
+func splitInfoFamily(name, help string, labelKeys []string, hubClusterID string, clusters *clusterCache,
+	values func(*mciv1beta1.ManagedClusterInfo, *mcv1.ManagedCluster) []string) metric.FamilyGenerator {
+	return metric.FamilyGenerator{
+		Name: name,
+		Type: metric.Gauge,
+		Help: help,
+		GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+			mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+			if err != nil {
+				countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+				return metric.Family{Metrics: []*metric.Metric{}}
+			}
+			mc, err := clusters.getManagedCluster(clusters.clusterNameFor(mci))
+			if err != nil {
+				countCollectorError(mcGVR.Resource, clusters.clusterNameFor(mci), err)
+				return metric.Family{Metrics: []*metric.Metric{}}
+			}
+			if len(clusters.getMissingInfo(mci)) > 0 {
+				return metric.Family{Metrics: []*metric.Metric{}}
+			}
+			return metric.Family{Metrics: []*metric.Metric{
+				{
+					LabelKeys:   labelKeys,
+					LabelValues: append([]string{hubClusterID, clusters.getClusterID(mci)}, values(mci, mc)...),
+					Value:       1,
+				},
+			}}
+		}),
+	}
+}
+
+// getInstanceTypeMetricFamilies returns the families exposing the capacity by
+// instance type, they are enabled separately as there is a series per
+// instance type of each cluster.
+func getInstanceTypeMetricFamilies(hubClusterID string, clusters *clusterCache, nodes *nodeSummaryPass) []metric.FamilyGenerator {
+	return []metric.FamilyGenerator{
+		{
+			Name: descClusterCPUByInstanceTypeName,
+			Type: metric.Gauge,
+			Help: descClusterCPUByInstanceTypeHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" || len(mci.Status.NodeList) == 0 {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				cpus := nodes.summaryOf(mci).workerCPUByInstanceType
+				instanceTypes := make([]string, 0, len(cpus))
+				for instanceType := range cpus {
+					instanceTypes = append(instanceTypes, instanceType)
+				}
+				sort.Strings(instanceTypes)
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, instanceType := range instanceTypes {
+					cpu := cpus[instanceType]
+					family.Metrics = append(family.Metrics, &metric.Metric{
+						LabelKeys:   descClusterCPUByInstanceTypeDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID, instanceType},
+						Value:       float64(cpu.MilliValue()) / 1000,
+					})
+				}
+				return family
+			}),
+		},
+	}
+}
+
+// getCapacityMetricFamilies returns the families exposing the given capacity
+// resources of the ManagedCluster, ie: extended resources. The resources a
+// cluster doesn't report have no series.
+func getCapacityMetricFamilies(hubClusterID string, clusters *clusterCache, resources []string) []metric.FamilyGenerator {
+	return []metric.FamilyGenerator{
+		{
+			Name: descClusterCapacityName,
+			Type: metric.Gauge,
+			Help: descClusterCapacityHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				mc, err := clusters.getManagedCluster(clusters.clusterNameFor(mci))
+				if err != nil {
+					countCollectorError(mcGVR.Resource, clusters.clusterNameFor(mci), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, r := range resources {
+					q, ok := mc.Status.Capacity[mcv1.ResourceName(r)]
+					if !ok {
+						continue
+					}
+					family.Metrics = append(family.Metrics, &metric.Metric{
+						LabelKeys:   descClusterCapacityDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID, r},
+						Value:       float64(q.MilliValue()) / 1000,
+					})
+				}
+				return family
+			}),
+		},
+	}
+}
+
+// getAutoscalerMetricFamilies returns the families exposing if the cluster
+// autoscaler is enabled. Neither the ManagedClusterInfo nor the well-known
+// claims report it, so it is read from the claim, true or false. The clusters
+// without the claim, or with another value, have no series.
+func getAutoscalerMetricFamilies(hubClusterID string, clusters *clusterCache, claim string) []metric.FamilyGenerator {
+	return []metric.FamilyGenerator{
+		boolClaimFamily(descClusterAutoscalerEnabledName, descClusterAutoscalerEnabledHelp,
+			descClusterAutoscalerEnabledDefaultLabels, hubClusterID, clusters, claim),
+	}
+}
+
+func getFIPSMetricFamilies(hubClusterID string, clusters *clusterCache, claim string) []metric.FamilyGenerator {
+	return []metric.FamilyGenerator{
+		boolClaimFamily(descClusterFIPSName, descClusterFIPSHelp,
+			descClusterFIPSDefaultLabels, hubClusterID, clusters, claim),
+	}
+}
+
+func getEtcdEncryptionMetricFamilies(hubClusterID string, clusters *clusterCache, claim string) []metric.FamilyGenerator {
+	return []metric.FamilyGenerator{
+		boolClaimFamily(descClusterEtcdEncryptionName, descClusterEtcdEncryptionHelp,
+			descClusterEtcdEncryptionDefaultLabels, hubClusterID, clusters, claim),
+	}
+}
+
+// getMachineSetMetricFamilies returns the family of the number of MachineSets
+// of the OpenShift clusters, the clusters have no series until the views of
+// their nodes have a result.
+func getMachineSetMetricFamilies(hubClusterID string, clusters *clusterCache, views *clusterViews) []metric.FamilyGenerator {
+	return []metric.FamilyGenerator{
+		{
+			Name: descClusterMachineSetCountName,
+			Type: metric.Gauge,
+			Help: descClusterMachineSetCountHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" || mci.Status.KubeVendor != mciv1beta1.KubeVendorOpenShift {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				count, ok := views.machineSetCount(mci)
+				if !ok {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descClusterMachineSetCountDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID},
+						Value:       float64(count),
+					},
+				}}
+			}),
+		},
+	}
+}
+
+// getLastUpgradeMetricFamilies returns the family of the completion time of
+// the last upgrade of the OpenShift clusters, the clusters have no series
+// until the view of their ClusterVersion has a completed update.
+func getLastUpgradeMetricFamilies(hubClusterID string, clusters *clusterCache, views *clusterViews) []metric.FamilyGenerator {
+	return []metric.FamilyGenerator{
+		{
+			Name: descClusterLastUpgradeName,
+			Type: metric.Gauge,
+			Help: descClusterLastUpgradeHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" || mci.Status.KubeVendor != mciv1beta1.KubeVendorOpenShift {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				version, completion, ok := views.lastUpgrade(mci)
+				if !ok {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descClusterLastUpgradeDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID, version},
+						Value:       float64(completion.Unix()),
+					},
+				}}
+			}),
+		},
+	}
+}
+
+// boolClaimFamily returns a family exposing 1 or 0 for the clusters having
+// the claim set to true or false, the clusters without the claim, or with
+// another value, have no series.
+func boolClaimFamily(name, help string, labelKeys []string, hubClusterID string, clusters *clusterCache, claim string) metric.FamilyGenerator {
+	return metric.FamilyGenerator{
+		Name: name,
+		Type: metric.Gauge,
+		Help: help,
+		GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+			mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+			if err != nil {
+				countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+				return metric.Family{Metrics: []*metric.Metric{}}
+			}
+			mc, err := clusters.getManagedCluster(clusters.clusterNameFor(mci))
+			if err != nil {
+				countCollectorError(mcGVR.Resource, clusters.clusterNameFor(mci), err)
+				return metric.Family{Metrics: []*metric.Metric{}}
+			}
+			clusterID := clusters.getClusterID(mci)
+			if clusterID == "" {
+				return metric.Family{Metrics: []*metric.Metric{}}
+			}
+			enabled, err := strconv.ParseBool(getClusterClaim(mc, claim))
+			if err != nil {
+				return metric.Family{Metrics: []*metric.Metric{}}
+			}
+			value := 0.0
+			if enabled {
+				value = 1
+			}
+			return metric.Family{Metrics: []*metric.Metric{
+				{
+					LabelKeys:   labelKeys,
+					LabelValues: []string{hubClusterID, clusterID},
+					Value:       value,
+				},
+			}}
+		}),
+	}
+}
+
+// filterInfoLabels returns the indexes of the labelKeys in the infoLabels,
+// the cluster ids are always kept. An empty infoLabels keeps all the labels.
+func filterInfoLabels(labelKeys []string, infoLabels []string) []int {
+	allowed := map[string]bool{"hub_cluster_id": true, "managed_cluster_id": true}
+	for _, l := range infoLabels {
+		allowed[l] = true
+	}
+	indexes := []int{}
+	for i, k := range labelKeys {
+		if len(infoLabels) == 0 || allowed[k] {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// pickLabels returns the labels at the indexes.
+func pickLabels(labels []string, indexes []int) []string {
+	picked := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		picked = append(picked, labels[i])
+	}
+	return picked
+}
+
+// getMissingInfo returns the reasons why the ManagedClusterInfo is not
+// reported by the info metric, none when it is.
+func (s clusterSettings) getMissingInfo(mci *mciv1beta1.ManagedClusterInfo) []string {
+	reasons := []string{}
+	if s.getClusterID(mci) == "" {
+		reasons = append(reasons, missingClusterID)
+	}
+	if mci.Status.KubeVendor == "" {
+		reasons = append(reasons, missingKubeVendor)
+	}
+	return reasons
+}
+
+func (s clusterSettings) getClusterID(mci *mciv1beta1.ManagedClusterInfo) string {
+	clusterID := mci.Status.ClusterID
+	//Cluster ID is not available on non-OCP thus use the name
+	if clusterID == "" &&
+		mci.Status.KubeVendor != mciv1beta1.KubeVendorOpenShift {
+		clusterID = s.clusterNameFor(mci)
+	}
+
+	//ClusterID is not available on OCP 3.x thus use the name
+	if clusterID == "" &&
+		mci.Status.KubeVendor == mciv1beta1.KubeVendorOpenShift && mci.Status.DistributionInfo.OCP.Version == "3" {
+		clusterID = s.clusterNameFor(mci)
+	}
+	return clusterID
+}
+
+func getCloud(mci *mciv1beta1.ManagedClusterInfo) string {
+	if mci.Status.CloudVendor == "" {
+		return unknownCloud
+	}
+	return string(mci.Status.CloudVendor)
+}
+
+// getRegion returns the cloud region of the cluster from the region cluster
+// claim, or from the region label of its nodes when the claim is not
+// reported. The ManagedCluster may be nil.
+func getRegion(mci *mciv1beta1.ManagedClusterInfo, mc *mcv1.ManagedCluster) string {
+	if mc != nil {
+		if region := getClusterClaim(mc, regionClaim); region != "" {
+			return region
+		}
+	}
+	for _, n := range mci.Status.NodeList {
+		if region := n.Labels[regionLabel]; region != "" {
+			return region
+		}
+	}
+	return unknownRegion
+}
+
+// getVersion returns the version of the distribution of the cluster. While the
+// ManagedClusterInfo doesn't carry the OCP distribution info, the OCP version
+// falls back to the desired version of the ClusterVersion of the managed
+// cluster read from its view, then to the ocpVersionClaim of the
+// ManagedCluster. The views may be nil. mismatch is true when the version of
+// an OpenShift cluster is unknown as its distribution info doesn't match its
+// kube vendor.
+func (s clusterSettings) getVersion(mci *mciv1beta1.ManagedClusterInfo, mc *mcv1.ManagedCluster,
+	views *clusterViews) (version string, mismatch bool) {
+	if mci.Status.KubeVendor == "" {
+		return "", false
+	}
+	switch mci.Status.KubeVendor {
+	case mciv1beta1.KubeVendorOpenShift:
+		if mci.Status.DistributionInfo.OCP.Version == "" {
+			if version := views.desiredVersion(mci); version != "" {
+				return s.collapsePrereleaseVersion(version), false
+			}
+			if version := getClusterClaim(mc, ocpVersionClaim); version != "" {
+				return s.collapsePrereleaseVersion(version), false
+			}
+			return unknownVersion, true
+		}
+		return s.collapsePrereleaseVersion(mci.Status.DistributionInfo.OCP.Version), false
+	default:
+		return mci.Status.Version, false
+	}
+
+}
+
+// collapsePrereleaseVersion returns the version and stream of an OCP nightly
+// or CI build when collapsePrereleaseVersions is set, ie: 4.13.0-nightly for
+// 4.13.0-0.nightly-2023-01-27-165107, as each build has its own version. The
+// released versions and the release candidates are returned as is.
+func (s clusterSettings) collapsePrereleaseVersion(version string) string {
+	if !s.collapsePrereleaseVersions {
+		return version
+	}
+	m := ocpPrereleaseRegexp.FindStringSubmatch(version)
+	if m == nil {
+		return version
+	}
+	return m[1] + "-" + m[2]
+}
+
+// getKubernetesVersion returns the kubernetes version reported by the
+// ManagedClusterInfo and falls back to the one of the ManagedCluster.
+func getKubernetesVersion(mci *mciv1beta1.ManagedClusterInfo, mc *mcv1.ManagedCluster) string {
+	if mci.Status.Version != "" {
+		return mci.Status.Version
+	}
+	return mc.Status.Version.Kubernetes
+}
+
+// getThreadsPerCore returns the cpu capacity of the worker nodes divided by
+// their core_worker capacity read from the named resource, or 0 when one of
+// them is not available.
+func getThreadsPerCore(mc *mcv1.ManagedCluster, nodes nodeSummary, names workerResourceNames) float64 {
+	coreWorker, _ := getCapacity(mc, names)
+	if coreWorker == 0 {
+		return 0
+	}
+	cpu := nodes.workerCapacity[mciv1beta1.ResourceCPU]
+	return float64(cpu.MilliValue()) / 1000 / float64(coreWorker)
+}
+
+// getClusterClaim returns the value of the cluster claim or an empty string
+// when the managed cluster doesn't report the claim.
+func getClusterClaim(mc *mcv1.ManagedCluster, name string) string {
+	for _, c := range mc.Status.ClusterClaims {
+		if c.Name == name {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+// getAPIURL returns the URL of the first client config of the cluster, the
+// URL of its kube-apiserver.
+func getAPIURL(mc *mcv1.ManagedCluster) string {
+	if len(mc.Spec.ManagedClusterClientConfigs) == 0 {
+		return ""
+	}
+	return mc.Spec.ManagedClusterClientConfigs[0].URL
+}
+
+// managedServiceVendors are the vendors of the managed kubernetes services,
+// their ManagedCluster capacity is not populated and their control plane is
+// not part of the nodes.
+var managedServiceVendors = map[mciv1beta1.KubeVendorType]bool{
+	mciv1beta1.KubeVendorAKS: true,
+	mciv1beta1.KubeVendorEKS: true,
+	mciv1beta1.KubeVendorGKE: true,
+	mciv1beta1.KubeVendorIKS: true,
+}
+
+func isManagedServiceVendor(vendor mciv1beta1.KubeVendorType) bool {
+	return managedServiceVendors[vendor]
+}
+
+// getNodeListCores returns the cpu of the worker nodes, or of all the nodes
+// when none has the worker role label as the control plane of the managed
+// services is not part of the nodes.
+func getNodeListCores(nodes nodeSummary) int64 {
+	cpu := nodes.workerCapacity[mciv1beta1.ResourceCPU]
+	if cpu.IsZero() {
+		cpu = nodes.capacity[mciv1beta1.ResourceCPU]
+	}
+	return cpu.Value()
+}
+
+// getInfoCapacity returns the capacity of the info metric. The ManagedCluster
+// capacity is not populated for the managed services, their cores are summed
+// from the nodes.
+func getInfoCapacity(mci *mciv1beta1.ManagedClusterInfo, mc *mcv1.ManagedCluster, nodes nodeSummary,
+	names workerResourceNames) (core_worker, socket_worker int64) {
+	core_worker, socket_worker = getCapacity(mc, names)
+	if isManagedServiceVendor(mci.Status.KubeVendor) && core_worker == 0 {
+		core_worker = getNodeListCores(nodes)
+	}
+	return
+}
+
+// getCapacity returns the worker cores and sockets of the ManagedCluster
+// capacity, 0 when the ManagedCluster doesn't report the named resources.
+func getCapacity(mc *mcv1.ManagedCluster, names workerResourceNames) (core_worker, socket_worker int64) {
+	core_worker = getCapacityValue(mc, names.coreWorker)
+	socket_worker = getCapacityValue(mc, names.socketWorker)
+	return
+}
+
+// getMemory returns the memory capacity of the cluster and of its worker
+// nodes in bytes, 0 when the ManagedCluster doesn't report it.
+func getMemory(mc *mcv1.ManagedCluster, names workerResourceNames) (memory, memoryWorker int64) {
+	if q, ok := mc.Status.Capacity[mcv1.ResourceMemory]; ok {
+		memory = q.Value()
+	}
+	memoryWorker = getCapacityValue(mc, names.memoryWorker)
+	return
+}
+
+// getCapacityValue returns the value of the resource of the ManagedCluster
+// capacity. A missing resource is logged, its name may not match the one
+// written by the agent of the cluster.
+func getCapacityValue(mc *mcv1.ManagedCluster, name mcv1.ResourceName) int64 {
+	q, ok := mc.Status.Capacity[name]
+	if !ok {
+		klog.V(2).Infof("The capacity of the ManagedCluster %s has no %s resource", mc.GetName(), name)
+		return 0
+	}
+	return q.Value()
+}
+
+func getAvailableStatus(mc *mcv1.ManagedCluster) string {
+	status := metav1.ConditionUnknown
+	for _, c := range mc.Status.Conditions {
+		if c.Type == mcv1.ManagedClusterConditionAvailable {
+			status = c.Status
+			break
+		}
+	}
+	if status == metav1.ConditionFalse {
+		status = metav1.ConditionUnknown
+	}
+	return string(status)
+}
+
+// getClockSyncedStatus returns the status of the clock synced condition of
+// the cluster, Unknown when the agent doesn't report it.
+func getClockSyncedStatus(mc *mcv1.ManagedCluster) metav1.ConditionStatus {
+	for _, c := range mc.Status.Conditions {
+		if c.Type == managedClusterConditionClockSynced {
+			return c.Status
+		}
+	}
+	return metav1.ConditionUnknown
+}
+
+// withUniqueManagedClusterInfo wraps the generate function of the
+// ManagedClusterInfo collector so only the ManagedClusterInfos resolved to a
+// cluster by the cluster cache generate metrics. A copy of the
+// ManagedClusterInfo of a cluster in another namespace would duplicate its
+// series. The only ManagedClusterInfo of a namespace is resolved whatever its
+// name, it is renamed after its cluster for the families, like the
+// ManagedClusterInfos returned by getManagedClusterInfo. The ignored
+// ManagedClusterInfos are logged and counted once, not on each regeneration.
+func withUniqueManagedClusterInfo(clusters *clusterCache, families int,
+	generateFunc func(interface{}) []metricsstore.FamilyByteSlicer) func(interface{}) []metricsstore.FamilyByteSlicer {
+	mutex := sync.Mutex{}
+	ignored := map[types.UID]bool{}
+	return func(obj interface{}) []metricsstore.FamilyByteSlicer {
+		u := obj.(*unstructured.Unstructured)
+		if u.GetKind() != "ManagedClusterInfo" {
+			return generateFunc(obj)
+		}
+		name := clusters.clusterNameOf(u)
+		if name == "" {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if !ignored[u.GetUID()] {
+				ignored[u.GetUID()] = true
+				klog.Warningf("Ignoring the ManagedClusterInfo %s/%s, it is not the one of the cluster of the %s namespace",
+					u.GetNamespace(), u.GetName(), u.GetNamespace())
+				DuplicateManagedClusterInfoMetric.Inc()
+			}
+			return emptyFamilies(families)
+		}
+		if clusters.clusterNameFor(u) != name {
+			u = u.DeepCopy()
+			u.SetName(name)
+		}
+		return generateFunc(u)
+	}
+}
+
+// getLastUpdated returns the last time the ManagedClusterInfo was updated,
+// the most recent transition of its conditions, or the most recent time of
+// its managed fields when the conditions have no transition time. A zero
+// time is returned for a ManagedClusterInfo without conditions.
+func getLastUpdated(mci *mciv1beta1.ManagedClusterInfo) metav1.Time {
+	lastUpdated := metav1.Time{}
+	if len(mci.Status.Conditions) == 0 {
+		return lastUpdated
+	}
+	for _, c := range mci.Status.Conditions {
+		if lastUpdated.Before(&c.LastTransitionTime) {
+			lastUpdated = c.LastTransitionTime
+		}
+	}
+	if !lastUpdated.IsZero() {
+		return lastUpdated
+	}
+	for _, f := range mci.GetManagedFields() {
+		if f.Time != nil && lastUpdated.Before(f.Time) {
+			lastUpdated = *f.Time
+		}
+	}
+	return lastUpdated
+}
+
+// getOCPChannel returns the upgrade channel of an OpenShift cluster. The
+// channel is not part of the compiled-in ManagedClusterInfo API, so it is
+// read from the unstructured ManagedClusterInfo.
+func getOCPChannel(mci *unstructured.Unstructured) string {
+	channel, _, _ := unstructured.NestedString(mci.Object, "status", "distributionInfo", "ocp", "channel")
+	return channel
+}
+
+func wrapManagedClusterInfoFunc(f func(*unstructured.Unstructured) metric.Family) func(interface{}) *metric.Family {
+	return func(obj interface{}) *metric.Family {
+		Cluster := obj.(*unstructured.Unstructured)
+
+		metricFamily := f(Cluster)
+
+		for _, m := range metricFamily.Metrics {
+			m.LabelKeys = append([]string{}, m.LabelKeys...)
+			m.LabelValues = append([]string{}, m.LabelValues...)
+		}
+
+		return &metricFamily
+	}
+}
+
+// createManagedClusterInfoListWatchWithClient lists and watches the
+// ManagedClusterInfos of the namespace matching the selector, a nil selector
+// selects all of them. The ManagedClusterInfos carry the labels of their
+// ManagedCluster, so the selector of the ManagedClusters applies to them.
+func createManagedClusterInfoListWatchWithClient(client dynamic.Interface, ns string, selector labels.Selector, timeout time.Duration) cache.ListWatch {
+	return cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			ctx, cancel := requestContext(mciGVR.Resource, timeout)
+			defer cancel()
+			return client.Resource(mciGVR).Namespace(ns).List(ctx, withLabelSelector(opts, selector))
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return client.Resource(mciGVR).Namespace(ns).Watch(context.TODO(), withLabelSelector(opts, selector))
+		},
+	}
+}
+
+// createManagedClusterListWatchWithClient lists and watches the
+// ManagedClusters matching the selector, a nil selector selects all of them.
+func createManagedClusterListWatchWithClient(client dynamic.Interface, selector labels.Selector, timeout time.Duration) cache.ListWatch {
+	return cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			ctx, cancel := requestContext(mcGVR.Resource, timeout)
+			defer cancel()
+			return client.Resource(mcGVR).List(ctx, withLabelSelector(opts, selector))
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return client.Resource(mcGVR).Watch(context.TODO(), withLabelSelector(opts, selector))
+		},
+	}
+}
+
+// withLabelSelector sets the selector on the list options. The reflectors
+// pass new options on each watch, so the selector is set on each call to
+// survive the watch reconnections.
+func withLabelSelector(opts metav1.ListOptions, selector labels.Selector) metav1.ListOptions {
+	if selector != nil && !selector.Empty() {
+		opts.LabelSelector = selector.String()
+	}
+	return opts
+}
+
+// getHostingCluster returns the management cluster hosting the control plane
+// of a cluster in hosted mode, empty for the standalone clusters.
+func getHostingCluster(mc *mcv1.ManagedCluster) string {
+	return mc.GetAnnotations()[hostingClusterAnnotation]
 }
 
 func getCreatedVia(mc *mcv1.ManagedCluster) string {
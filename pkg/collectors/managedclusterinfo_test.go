@@ -4,18 +4,30 @@
 package collectors
 
 import (
+	"bytes"
+	"context"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	mcv1 "github.com/open-cluster-management/api/cluster/v1"
 	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/kube-state-metrics/pkg/metric"
+	metricsstore "k8s.io/kube-state-metrics/pkg/metrics_store"
 )
 
 func Test_getManagedClusterMetricFamilies(t *testing.T) {
@@ -34,10 +46,16 @@ func Test_getManagedClusterMetricFamilies(t *testing.T) {
 			CloudVendor: mciv1beta1.CloudVendorAWS,
 			Version:     "v1.16.2",
 			ClusterID:   "managed_cluster_id",
+			ConsoleURL:  "https://console-openshift-console.apps.hive-cluster.example.com",
+			Conditions: []metav1.Condition{
+				{Type: managedClusterInfoConditionSynced, Status: metav1.ConditionTrue, LastTransitionTime: metav1.NewTime(time.Unix(1620000000, 0))},
+				{Type: "Other", Status: metav1.ConditionTrue, LastTransitionTime: metav1.NewTime(time.Unix(1620000600, 0))},
+			},
 			DistributionInfo: mciv1beta1.DistributionInfo{
 				Type: mciv1beta1.DistributionTypeOCP,
 				OCP: mciv1beta1.OCPDistributionInfo{
-					Version: "4.3.1",
+					Version:          "4.3.1",
+					AvailableUpdates: []string{"4.3.2", "4.3.3"},
 				},
 			},
 			NodeList: []mciv1beta1.NodeStatus{
@@ -59,6 +77,10 @@ func Test_getManagedClusterMetricFamilies(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
+	// The channel is not part of the compiled-in API.
+	if err := unstructured.SetNestedField(mciU.Object, "stable-4.3", "status", "distributionInfo", "ocp", "channel"); err != nil {
+		t.Error(err)
+	}
 
 	mc := &mcv1.ManagedCluster{
 		ObjectMeta: metav1.ObjectMeta{
@@ -71,6 +93,8 @@ func Test_getManagedClusterMetricFamilies(t *testing.T) {
 			Capacity: mcv1.ResourceList{
 				resourceCoreWorker:   *resource.NewQuantity(4, resource.DecimalSI),
 				resourceSocketWorker: *resource.NewQuantity(2, resource.DecimalSI),
+				mcv1.ResourceMemory:  resource.MustParse("32Gi"),
+				resourceMemoryWorker: resource.MustParse("16Gi"),
 			},
 		},
 	}
@@ -170,6 +194,12 @@ func Test_getManagedClusterMetricFamilies(t *testing.T) {
 			Name: "cluster-other",
 		},
 		Status: mcv1.ManagedClusterStatus{
+			ClusterClaims: []mcv1.ManagedClusterClaim{
+				{
+					Name:  "id.provider.example.com",
+					Value: "arn:aws:eks:us-east-1:123456789012:cluster/cluster-other",
+				},
+			},
 			Capacity: mcv1.ResourceList{
 				resourceCoreWorker:   *resource.NewQuantity(4, resource.DecimalSI),
 				resourceSocketWorker: *resource.NewQuantity(2, resource.DecimalSI),
@@ -203,7 +233,8 @@ func Test_getManagedClusterMetricFamilies(t *testing.T) {
 
 	mcMissingInfo := &mcv1.ManagedCluster{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "hive-cluster-2",
+			Name:              "hive-cluster-2",
+			CreationTimestamp: metav1.NewTime(time.Unix(1620000000, 0)),
 		},
 		Status: mcv1.ManagedClusterStatus{
 			Capacity: mcv1.ResourceList{
@@ -219,32 +250,442 @@ func Test_getManagedClusterMetricFamilies(t *testing.T) {
 		t.Error(err)
 	}
 
-	client := fake.NewSimpleDynamicClient(s, mciU, mciUDiscovery, mciUMissingInfo, mciUOther, mcU, mcDiscovery, mcUOther, mcUMissingInfo)
-	clientHive := fake.NewSimpleDynamicClient(s, mciU, mciDiscovery, mcU, mcUOther, mcUMissingInfo)
+	mciMCVersion := &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-mc-version",
+			Namespace: "cluster-mc-version",
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor:  mciv1beta1.KubeVendorOpenShift,
+			CloudVendor: mciv1beta1.CloudVendorAWS,
+			ClusterID:   "mc_version_cluster_id",
+			DistributionInfo: mciv1beta1.DistributionInfo{
+				Type: mciv1beta1.DistributionTypeOCP,
+				OCP: mciv1beta1.OCPDistributionInfo{
+					Version: "4.7.0",
+				},
+			},
+			NodeList: []mciv1beta1.NodeStatus{
+				{
+					Name: "worker-1",
+					Labels: map[string]string{
+						workerLabel: "",
+					},
+				},
+			},
+		},
+	}
+	mciUMCVersion := &unstructured.Unstructured{}
+	err = scheme.Scheme.Convert(mciMCVersion, mciUMCVersion, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	mcMCVersion := &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster-mc-version",
+		},
+		Status: mcv1.ManagedClusterStatus{
+			Capacity: mcv1.ResourceList{
+				resourceCoreWorker:   *resource.NewQuantity(4, resource.DecimalSI),
+				resourceSocketWorker: *resource.NewQuantity(2, resource.DecimalSI),
+			},
+			Version: mcv1.ManagedClusterVersion{
+				Kubernetes: "v1.20.0",
+			},
+		},
+	}
+
+	mcUMCVersion := &unstructured.Unstructured{}
+	err = scheme.Scheme.Convert(mcMCVersion, mcUMCVersion, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	mciOnPrem := &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "on-prem-cluster",
+			Namespace: "on-prem-cluster",
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor: mciv1beta1.KubeVendorOpenShift,
+			Version:    "v1.20.0",
+			ClusterID:  "on_prem_cluster_id",
+			DistributionInfo: mciv1beta1.DistributionInfo{
+				Type: mciv1beta1.DistributionTypeOCP,
+				OCP: mciv1beta1.OCPDistributionInfo{
+					Version: "4.7.2",
+				},
+			},
+			NodeList: []mciv1beta1.NodeStatus{
+				{
+					Name: "worker-1",
+					Labels: map[string]string{
+						workerLabel: "",
+					},
+					Capacity: mciv1beta1.ResourceList{
+						mciv1beta1.ResourceCPU: *resource.NewQuantity(16, resource.DecimalSI),
+					},
+				},
+			},
+		},
+	}
+	mciUOnPrem := &unstructured.Unstructured{}
+	err = scheme.Scheme.Convert(mciOnPrem, mciUOnPrem, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	mcOnPrem := &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "on-prem-cluster",
+		},
+		Status: mcv1.ManagedClusterStatus{
+			Conditions: []metav1.Condition{
+				{Type: "ManagedClusterConditionClockSynced", Status: metav1.ConditionFalse},
+			},
+			Capacity: mcv1.ResourceList{
+				resourceCoreWorker:   *resource.NewQuantity(8, resource.DecimalSI),
+				resourceSocketWorker: *resource.NewQuantity(2, resource.DecimalSI),
+			},
+		},
+	}
+	mcUOnPrem := &unstructured.Unstructured{}
+	err = scheme.Scheme.Convert(mcOnPrem, mcUOnPrem, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	mciPartial := mciOnPrem.DeepCopy()
+	mciPartial.SetName("partial-cluster")
+	mciPartial.SetNamespace("partial-cluster")
+	mciPartial.Status.ClusterID = "partial_cluster_id"
+	mciPartial.Status.DistributionInfo = mciv1beta1.DistributionInfo{}
+	mciUPartial := &unstructured.Unstructured{}
+	err = scheme.Scheme.Convert(mciPartial, mciUPartial, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	mcPartial := mcOnPrem.DeepCopy()
+	mcPartial.SetName("partial-cluster")
+	mcUPartial := &unstructured.Unstructured{}
+	err = scheme.Scheme.Convert(mcPartial, mcUPartial, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	mciHosted := mciOnPrem.DeepCopy()
+	mciHosted.SetName("hosted-cluster")
+	mciHosted.SetNamespace("hosted-cluster")
+	mciHosted.Status.ClusterID = "hosted_cluster_id"
+	mciUHosted := &unstructured.Unstructured{}
+	err = scheme.Scheme.Convert(mciHosted, mciUHosted, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	mcHosted := mcOnPrem.DeepCopy()
+	mcHosted.SetName("hosted-cluster")
+	mcHosted.SetAnnotations(map[string]string{
+		"import.open-cluster-management.io/hosting-cluster-name": "local-cluster",
+	})
+	mcUHosted := &unstructured.Unstructured{}
+	err = scheme.Scheme.Convert(mcHosted, mcUHosted, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	mciEKS := &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "eks-cluster",
+			Namespace: "eks-cluster",
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor:  mciv1beta1.KubeVendorEKS,
+			CloudVendor: mciv1beta1.CloudVendorAWS,
+			Version:     "v1.19.6",
+			ClusterID:   "eks_cluster_id",
+			NodeList: []mciv1beta1.NodeStatus{
+				{
+					Name: "ip-10-0-1-1",
+					Capacity: mciv1beta1.ResourceList{
+						mciv1beta1.ResourceCPU: *resource.NewQuantity(4, resource.DecimalSI),
+					},
+				},
+				{
+					Name: "ip-10-0-1-2",
+					Capacity: mciv1beta1.ResourceList{
+						mciv1beta1.ResourceCPU: *resource.NewQuantity(2, resource.DecimalSI),
+					},
+				},
+			},
+		},
+	}
+	mciUEKS := &unstructured.Unstructured{}
+	err = scheme.Scheme.Convert(mciEKS, mciUEKS, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	mcEKS := &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "eks-cluster",
+		},
+	}
+	mcUEKS := &unstructured.Unstructured{}
+	err = scheme.Scheme.Convert(mcEKS, mcUEKS, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	mciEKSWorkers := mciEKS.DeepCopy()
+	mciEKSWorkers.SetName("eks-workers-cluster")
+	mciEKSWorkers.SetNamespace("eks-workers-cluster")
+	mciEKSWorkers.Status.ClusterID = "eks_workers_cluster_id"
+	mciEKSWorkers.Status.NodeList[0].Labels = map[string]string{workerLabel: ""}
+	mciUEKSWorkers := &unstructured.Unstructured{}
+	err = scheme.Scheme.Convert(mciEKSWorkers, mciUEKSWorkers, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	mcEKSWorkers := mcEKS.DeepCopy()
+	mcEKSWorkers.SetName("eks-workers-cluster")
+	mcUEKSWorkers := &unstructured.Unstructured{}
+	err = scheme.Scheme.Convert(mcEKSWorkers, mcUEKSWorkers, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	mciSpot := &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "spot-cluster",
+			Namespace: "spot-cluster",
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor:  mciv1beta1.KubeVendorEKS,
+			CloudVendor: mciv1beta1.CloudVendorAWS,
+			Version:     "v1.19.6",
+			NodeList: []mciv1beta1.NodeStatus{
+				{
+					Name: "node-1",
+					Labels: map[string]string{
+						"eks.amazonaws.com/capacityType": "SPOT",
+					},
+				},
+				{
+					Name: "node-2",
+					Labels: map[string]string{
+						"eks.amazonaws.com/capacityType": "SPOT",
+					},
+				},
+				{
+					Name: "node-3",
+					Labels: map[string]string{
+						"eks.amazonaws.com/capacityType": "ON_DEMAND",
+					},
+					Conditions: []mciv1beta1.NodeCondition{
+						{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+						{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionTrue},
+					},
+				},
+			},
+		},
+	}
+	mciUSpot := &unstructured.Unstructured{}
+	err = scheme.Scheme.Convert(mciSpot, mciUSpot, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	clusters := newTestClusterCache(t, mciU, mciUDiscovery, mciUMissingInfo, mciUOther, mciUMCVersion, mciUSpot, mciUOnPrem, mciUPartial, mciUHosted, mciUEKS, mciUEKSWorkers, mcU, mcUOnPrem, mcUPartial, mcUHosted, mcUEKS, mcUEKSWorkers, mcDiscovery, mcUOther, mcUMissingInfo, mcUMCVersion)
+	clustersHive := newTestClusterCache(t, mciU, mciDiscovery, mcU, mcUOther, mcUMissingInfo)
 	tests := []generateMetricsTestCase{
 		{
 			Obj:         mciU,
 			MetricNames: []string{"acm_managed_cluster_info"},
-			Want:        `acm_managed_cluster_info{cloud="Amazon",core_worker="4",managed_cluster_id="managed_cluster_id",created_via="Hive",hub_cluster_id="mycluster_id",socket_worker="2",available="Unknown",vendor="OpenShift",version="4.3.1"} 1`,
+			Want:        `acm_managed_cluster_info{hosting_cluster="",cloud="Amazon",core_worker="4",managed_cluster_id="managed_cluster_id",created_via="Hive",hub_cluster_id="mycluster_id",socket_worker="2",available="Unknown",vendor="OpenShift",version="4.3.1",kubernetes_version="v1.16.2"} 1`,
 		},
 		{
 			Obj:         mciUDiscovery,
 			MetricNames: []string{"acm_managed_cluster_info"},
-			Want:        `acm_managed_cluster_info{cloud="Amazon",core_worker="4",managed_cluster_id="managed_cluster_id",created_via="Discovery",hub_cluster_id="mycluster_id",socket_worker="2",available="Unknown",vendor="OpenShift",version="4.3.1"} 1`,
+			Want:        `acm_managed_cluster_info{hosting_cluster="",cloud="Amazon",core_worker="4",managed_cluster_id="managed_cluster_id",created_via="Discovery",hub_cluster_id="mycluster_id",socket_worker="2",available="Unknown",vendor="OpenShift",version="4.3.1",kubernetes_version="v1.16.2"} 1`,
 		},
 		{
 			Obj:         mciUMissingInfo,
 			MetricNames: []string{"acm_managed_cluster_info"},
-			Want:        "",
+			Want:        `acm_managed_cluster_info{hosting_cluster="",cloud="Amazon",core_worker="4",managed_cluster_id="managed_cluster_id",created_via="Other",hub_cluster_id="mycluster_id",socket_worker="3",available="Unknown",vendor="OpenShift",version="unknown",kubernetes_version=""} 1`,
 		},
 		{
 			Obj:         mciUOther,
 			MetricNames: []string{"acm_managed_cluster_info"},
-			Want:        `acm_managed_cluster_info{cloud="Amazon",core_worker="4",managed_cluster_id="cluster-other",created_via="Other",hub_cluster_id="mycluster_id",socket_worker="2",available="Unknown",vendor="Other",version="v1.16.2"} 1`,
+			Want:        `acm_managed_cluster_info{hosting_cluster="",cloud="Amazon",core_worker="4",managed_cluster_id="cluster-other",created_via="Other",hub_cluster_id="mycluster_id",socket_worker="2",available="Unknown",vendor="Other",version="v1.16.2",kubernetes_version="v1.16.2"} 1`,
+		},
+		{
+			Obj:         mciUMCVersion,
+			MetricNames: []string{"acm_managed_cluster_info"},
+			Want:        `acm_managed_cluster_info{hosting_cluster="",cloud="Amazon",core_worker="4",managed_cluster_id="mc_version_cluster_id",created_via="Other",hub_cluster_id="mycluster_id",socket_worker="2",available="Unknown",vendor="OpenShift",version="4.7.0",kubernetes_version="v1.20.0"} 1`,
+		},
+		{
+			Obj:         mciUOnPrem,
+			MetricNames: []string{"acm_managed_cluster_info"},
+			Want:        `acm_managed_cluster_info{hosting_cluster="",cloud="unknown",core_worker="8",managed_cluster_id="on_prem_cluster_id",created_via="Other",hub_cluster_id="mycluster_id",socket_worker="2",available="Unknown",vendor="OpenShift",version="4.7.2",kubernetes_version="v1.20.0"} 1`,
+		},
+		{
+			Obj:         mciUPartial,
+			MetricNames: []string{"acm_managed_cluster_info"},
+			Want:        `acm_managed_cluster_info{hosting_cluster="",cloud="unknown",core_worker="8",managed_cluster_id="partial_cluster_id",created_via="Other",hub_cluster_id="mycluster_id",socket_worker="2",available="Unknown",vendor="OpenShift",version="unknown",kubernetes_version="v1.20.0"} 1`,
+		},
+		{
+			Obj:         mciUHosted,
+			MetricNames: []string{"acm_managed_cluster_info"},
+			Want:        `acm_managed_cluster_info{hosting_cluster="local-cluster",cloud="unknown",core_worker="8",managed_cluster_id="hosted_cluster_id",created_via="Other",hub_cluster_id="mycluster_id",socket_worker="2",available="Unknown",vendor="OpenShift",version="4.7.2",kubernetes_version="v1.20.0"} 1`,
+		},
+		{
+			Obj:         mciUEKS,
+			MetricNames: []string{"acm_managed_cluster_info"},
+			Want:        `acm_managed_cluster_info{hosting_cluster="",cloud="Amazon",core_worker="6",managed_cluster_id="eks_cluster_id",created_via="Other",hub_cluster_id="mycluster_id",socket_worker="0",available="Unknown",vendor="EKS",version="v1.19.6",kubernetes_version="v1.19.6"} 1`,
+		},
+		{
+			Obj:         mciUEKSWorkers,
+			MetricNames: []string{"acm_managed_cluster_info"},
+			Want:        `acm_managed_cluster_info{hosting_cluster="",cloud="Amazon",core_worker="4",managed_cluster_id="eks_workers_cluster_id",created_via="Other",hub_cluster_id="mycluster_id",socket_worker="0",available="Unknown",vendor="EKS",version="v1.19.6",kubernetes_version="v1.19.6"} 1`,
+		},
+		{
+			Obj:         mciUSpot,
+			MetricNames: []string{"acm_managed_cluster_spot_worker_count"},
+			Want:        `acm_managed_cluster_spot_worker_count{hub_cluster_id="mycluster_id",managed_cluster_id="spot-cluster"} 2`,
+		},
+		{
+			Obj:         mciUMissingInfo,
+			MetricNames: []string{"acm_managed_cluster_spot_worker_count"},
+			Want:        "",
+		},
+		{
+			Obj:         mciUSpot,
+			MetricNames: []string{"acm_managed_cluster_ready_nodes", "acm_managed_cluster_total_nodes"},
+			Want: `acm_managed_cluster_ready_nodes{hub_cluster_id="mycluster_id",managed_cluster_id="spot-cluster"} 1
+acm_managed_cluster_total_nodes{hub_cluster_id="mycluster_id",managed_cluster_id="spot-cluster"} 3`,
+		},
+		{
+			Obj:         mciUMissingInfo,
+			MetricNames: []string{"acm_managed_cluster_ready_nodes", "acm_managed_cluster_total_nodes"},
+			Want:        "",
+		},
+		{
+			Obj:         mciU,
+			MetricNames: []string{"acm_managed_cluster_worker_count", "acm_managed_cluster_control_plane_count"},
+			Want: `acm_managed_cluster_worker_count{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id"} 1
+acm_managed_cluster_control_plane_count{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id"} 0`,
+		},
+		{
+			Obj:         mciUMissingInfo,
+			MetricNames: []string{"acm_managed_cluster_worker_count", "acm_managed_cluster_control_plane_count"},
+			Want:        "",
+		},
+		{
+			Obj:         mciU,
+			MetricNames: []string{"acm_managed_cluster_kubernetes_version"},
+			Want:        `acm_managed_cluster_kubernetes_version{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id",version="v1.16.2"} 1`,
+		},
+		{
+			Obj:         mciUMCVersion,
+			MetricNames: []string{"acm_managed_cluster_kubernetes_version"},
+			Want:        `acm_managed_cluster_kubernetes_version{hub_cluster_id="mycluster_id",managed_cluster_id="mc_version_cluster_id",version="v1.20.0"} 1`,
+		},
+		{
+			Obj:         mciUMissingInfo,
+			MetricNames: []string{"acm_managed_cluster_kubernetes_version"},
+			Want:        "",
+		},
+		{
+			Obj:         mciU,
+			MetricNames: []string{"acm_managed_cluster_console"},
+			Want:        `acm_managed_cluster_console{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id",console_url="https://console-openshift-console.apps.hive-cluster.example.com"} 1`,
+		},
+		{
+			Obj:         mciUOther,
+			MetricNames: []string{"acm_managed_cluster_console"},
+			Want:        "",
+		},
+		{
+			Obj:         mciU,
+			MetricNames: []string{"acm_managed_cluster_ocp_channel", "acm_managed_cluster_ocp_available_updates"},
+			Want: `acm_managed_cluster_ocp_channel{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id",channel="stable-4.3"} 1
+acm_managed_cluster_ocp_available_updates{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id"} 2`,
+		},
+		{
+			Obj:         mciUEKS,
+			MetricNames: []string{"acm_managed_cluster_ocp_channel", "acm_managed_cluster_ocp_available_updates"},
+			Want:        "",
+		},
+		{
+			Obj:         mciU,
+			MetricNames: []string{"acm_managed_cluster_memory_bytes", "acm_managed_cluster_memory_worker_bytes"},
+			Want: `acm_managed_cluster_memory_bytes{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id"} 3.4359738368e+10
+acm_managed_cluster_memory_worker_bytes{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id"} 1.7179869184e+10`,
+		},
+		{
+			Obj:         mciUOther,
+			MetricNames: []string{"acm_managed_cluster_memory_bytes", "acm_managed_cluster_memory_worker_bytes"},
+			Want: `acm_managed_cluster_memory_bytes{hub_cluster_id="mycluster_id",managed_cluster_id="cluster-other"} 0
+acm_managed_cluster_memory_worker_bytes{hub_cluster_id="mycluster_id",managed_cluster_id="cluster-other"} 0`,
+		},
+		{
+			Obj:         mciUOnPrem,
+			MetricNames: []string{"acm_managed_cluster_clock_synced"},
+			Want: `acm_managed_cluster_clock_synced{hub_cluster_id="mycluster_id",managed_cluster_id="on_prem_cluster_id",status="true"} 0
+acm_managed_cluster_clock_synced{hub_cluster_id="mycluster_id",managed_cluster_id="on_prem_cluster_id",status="false"} 1
+acm_managed_cluster_clock_synced{hub_cluster_id="mycluster_id",managed_cluster_id="on_prem_cluster_id",status="unknown"} 0`,
+		},
+		{
+			Obj:         mciUOther,
+			MetricNames: []string{"acm_managed_cluster_clock_synced"},
+			Want: `acm_managed_cluster_clock_synced{hub_cluster_id="mycluster_id",managed_cluster_id="cluster-other",status="true"} 0
+acm_managed_cluster_clock_synced{hub_cluster_id="mycluster_id",managed_cluster_id="cluster-other",status="false"} 0
+acm_managed_cluster_clock_synced{hub_cluster_id="mycluster_id",managed_cluster_id="cluster-other",status="unknown"} 1`,
+		},
+		{
+			Obj:         mciUMissingInfo,
+			MetricNames: []string{"acm_managed_cluster_created"},
+			Want:        `acm_managed_cluster_created{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id",managed_cluster_name="hive-cluster-2"} 1.62e+09`,
+		},
+		{
+			Obj:         mciUOther,
+			MetricNames: []string{"acm_managed_cluster_created"},
+			Want:        "",
+		},
+		{
+			Obj:         mciU,
+			MetricNames: []string{"acm_managed_cluster_info_last_updated"},
+			Want:        `acm_managed_cluster_info_last_updated{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id"} 1.6200006e+09`,
+		},
+		{
+			Obj:         mciUEKS,
+			MetricNames: []string{"acm_managed_cluster_info_last_updated"},
+			Want:        "",
+		},
+		{
+			Obj:         mciUOnPrem,
+			MetricNames: []string{"acm_managed_cluster_threads_per_core"},
+			Want:        `acm_managed_cluster_threads_per_core{hub_cluster_id="mycluster_id",managed_cluster_id="on_prem_cluster_id"} 2`,
+		},
+		{
+			Obj:         mciUOther,
+			MetricNames: []string{"acm_managed_cluster_threads_per_core"},
+			Want:        "",
+		},
+		{
+			Obj:         mciUSpot,
+			MetricNames: []string{"acm_managed_cluster_node_pressure_count"},
+			Want: `acm_managed_cluster_node_pressure_count{hub_cluster_id="mycluster_id",managed_cluster_id="spot-cluster",pressure="Disk"} 0
+acm_managed_cluster_node_pressure_count{hub_cluster_id="mycluster_id",managed_cluster_id="spot-cluster",pressure="Memory"} 1
+acm_managed_cluster_node_pressure_count{hub_cluster_id="mycluster_id",managed_cluster_id="spot-cluster",pressure="PID"} 0`,
 		},
 	}
 	for i, c := range tests {
-		c.Func = metric.ComposeMetricGenFuncs(getManagedClusterInfoMetricFamilies("mycluster_id", client))
+		c.Func = metric.ComposeMetricGenFuncs(getManagedClusterInfoMetricFamilies("mycluster_id", clusters, nil, nil, "", false, false, nil))
 		if err := c.run(); err != nil {
 			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
 		}
@@ -253,22 +694,996 @@ func Test_getManagedClusterMetricFamilies(t *testing.T) {
 		{
 			Obj:         mciU,
 			MetricNames: []string{"acm_managed_cluster_info"},
-			Want:        `acm_managed_cluster_info{cloud="Amazon",core_worker="4",managed_cluster_id="managed_cluster_id",created_via="Hive",hub_cluster_id="mycluster_id",socket_worker="2",available="Unknown",vendor="OpenShift",version="4.3.1"} 1`,
+			Want:        `acm_managed_cluster_info{hosting_cluster="",cloud="Amazon",core_worker="4",managed_cluster_id="managed_cluster_id",created_via="Hive",hub_cluster_id="mycluster_id",socket_worker="2",available="Unknown",vendor="OpenShift",version="4.3.1",kubernetes_version="v1.16.2"} 1`,
 		},
 	}
 	for i, c := range tests {
-		c.Func = metric.ComposeMetricGenFuncs(getManagedClusterInfoMetricFamilies("mycluster_id", clientHive))
+		c.Func = metric.ComposeMetricGenFuncs(getManagedClusterInfoMetricFamilies("mycluster_id", clustersHive, nil, nil, "", false, false, nil))
 		if err := c.run(); err != nil {
 			t.Errorf("unexpected collecting result in %vth run:\n%s", i, err)
 		}
 	}
-}
-
-func Test_createManagedClusterInfoListWatchWithClient(t *testing.T) {
-	s := scheme.Scheme
-
-	s.AddKnownTypes(mciv1beta1.GroupVersion, &mciv1beta1.ManagedClusterInfo{})
-	s.AddKnownTypes(mciv1beta1.GroupVersion, &mciv1beta1.ManagedClusterInfoList{})
+	tests = []generateMetricsTestCase{
+		{
+			Obj:         mciUOther,
+			MetricNames: []string{"acm_managed_cluster_info"},
+			Want:        `acm_managed_cluster_info{hosting_cluster="",cloud="Amazon",core_worker="4",managed_cluster_id="cluster-other",created_via="Other",hub_cluster_id="mycluster_id",socket_worker="2",available="Unknown",vendor="Other",version="v1.16.2",kubernetes_version="v1.16.2",provider_cluster_id="arn:aws:eks:us-east-1:123456789012:cluster/cluster-other"} 1`,
+		},
+		{
+			Obj:         mciU,
+			MetricNames: []string{"acm_managed_cluster_info"},
+			Want:        `acm_managed_cluster_info{hosting_cluster="",cloud="Amazon",core_worker="4",managed_cluster_id="managed_cluster_id",created_via="Hive",hub_cluster_id="mycluster_id",socket_worker="2",available="Unknown",vendor="OpenShift",version="4.3.1",kubernetes_version="v1.16.2",provider_cluster_id=""} 1`,
+		},
+	}
+	for i, c := range tests {
+		c.Func = metric.ComposeMetricGenFuncs(getManagedClusterInfoMetricFamilies("mycluster_id", clusters, nil, nil, "id.provider.example.com", false, false, nil))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result with the provider claim in %vth run:\n%s", i, err)
+		}
+	}
+	tests = []generateMetricsTestCase{
+		{
+			Obj:         mciUOther,
+			MetricNames: []string{"acm_managed_cluster_info"},
+			Want:        `acm_managed_cluster_info{cloud="Amazon",managed_cluster_id="cluster-other",hub_cluster_id="mycluster_id",vendor="Other",version="v1.16.2",provider_cluster_id="arn:aws:eks:us-east-1:123456789012:cluster/cluster-other"} 1`,
+		},
+	}
+	for i, c := range tests {
+		c.Func = metric.ComposeMetricGenFuncs(getManagedClusterInfoMetricFamilies("mycluster_id", clusters, nil, nil, "id.provider.example.com", false, false,
+			[]string{"vendor", "cloud", "version", "provider_cluster_id"}))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result with the info labels in %vth run:\n%s", i, err)
+		}
+	}
+}
+
+func Test_getCapacityMetricFamilies(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(mciv1beta1.GroupVersion, &mciv1beta1.ManagedClusterInfo{})
+	s.AddKnownTypes(mcv1.GroupVersion, &mcv1.ManagedCluster{})
+
+	mci := &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fpga-cluster",
+			Namespace: "fpga-cluster",
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			ClusterID: "fpga_cluster_id",
+		},
+	}
+	mciU := &unstructured.Unstructured{}
+	if err := scheme.Scheme.Convert(mci, mciU, nil); err != nil {
+		t.Error(err)
+	}
+	mc := &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "fpga-cluster",
+		},
+		Status: mcv1.ManagedClusterStatus{
+			Capacity: mcv1.ResourceList{
+				"example.com/fpga":   *resource.NewQuantity(4, resource.DecimalSI),
+				"example.com/shares": resource.MustParse("1500m"),
+				resourceCoreWorker:   *resource.NewQuantity(8, resource.DecimalSI),
+			},
+		},
+	}
+	mcU := &unstructured.Unstructured{}
+	if err := scheme.Scheme.Convert(mc, mcU, nil); err != nil {
+		t.Error(err)
+	}
+
+	clusters := newTestClusterCache(t, mciU, mcU)
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         mciU,
+			MetricNames: []string{"acm_managed_cluster_capacity"},
+			Want: `acm_managed_cluster_capacity{hub_cluster_id="mycluster_id",managed_cluster_id="fpga_cluster_id",resource="example.com/fpga"} 4
+acm_managed_cluster_capacity{hub_cluster_id="mycluster_id",managed_cluster_id="fpga_cluster_id",resource="example.com/shares"} 1.5`,
+		},
+	}
+	for i, c := range tests {
+		c.Func = metric.ComposeMetricGenFuncs(getCapacityMetricFamilies("mycluster_id", clusters,
+			[]string{"example.com/fpga", "example.com/shares", "example.com/gpu"}))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
+
+func Test_getManagedClusterInfoMetricFamilies_noCapacity(t *testing.T) {
+	mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "importing-cluster", Namespace: "importing-cluster"},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor:  mciv1beta1.KubeVendorOpenShift,
+			CloudVendor: mciv1beta1.CloudVendorAWS,
+			ClusterID:   "importing_cluster_id",
+			Version:     "v1.20.0",
+			DistributionInfo: mciv1beta1.DistributionInfo{
+				Type: mciv1beta1.DistributionTypeOCP,
+				OCP:  mciv1beta1.OCPDistributionInfo{Version: "4.7.2"},
+			},
+			NodeList: []mciv1beta1.NodeStatus{
+				{Name: "worker-1", Labels: map[string]string{workerLabel: ""}},
+			},
+		},
+	})
+	mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "importing-cluster"},
+	})
+	mciNoVendor := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "unknown-cluster", Namespace: "unknown-cluster"},
+		Status:     mciv1beta1.ClusterInfoStatus{ClusterID: "unknown_cluster_id"},
+	})
+	mcNoVendor := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "unknown-cluster"},
+	})
+	clusters := newTestClusterCache(t, mci, mc, mciNoVendor, mcNoVendor)
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         mci,
+			MetricNames: []string{"acm_managed_cluster_info"},
+			Want:        `acm_managed_cluster_info{hosting_cluster="",cloud="Amazon",core_worker="0",managed_cluster_id="importing_cluster_id",created_via="Other",hub_cluster_id="mycluster_id",socket_worker="0",available="Unknown",vendor="OpenShift",version="4.7.2",kubernetes_version="v1.20.0"} 1`,
+		},
+		{
+			Obj:         mciNoVendor,
+			MetricNames: []string{"acm_managed_cluster_info"},
+			Want:        "",
+		},
+	}
+	for i, c := range tests {
+		c.Func = metric.ComposeMetricGenFuncs(getManagedClusterInfoMetricFamilies("mycluster_id", clusters, nil, nil, "", false, false, nil))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
+
+func Test_getManagedClusterInfoMetricFamilies_incomplete(t *testing.T) {
+	mciNoClusterID := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "no-id-cluster", Namespace: "no-id-cluster"},
+		Status:     mciv1beta1.ClusterInfoStatus{KubeVendor: mciv1beta1.KubeVendorOpenShift},
+	})
+	mciNoVendor := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "no-vendor-cluster", Namespace: "no-vendor-cluster"},
+		Status:     mciv1beta1.ClusterInfoStatus{ClusterID: "no_vendor_cluster_id"},
+	})
+	mciComplete := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "complete-cluster", Namespace: "complete-cluster"},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor: mciv1beta1.KubeVendorEKS,
+			Version:    "v1.19.6",
+		},
+	})
+	clusters := newTestClusterCache(t, mciNoClusterID, mciNoVendor, mciComplete)
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         mciNoClusterID,
+			MetricNames: []string{"acm_managed_cluster_info_incomplete"},
+			Want:        `acm_managed_cluster_info_incomplete{hub_cluster_id="mycluster_id",managed_cluster_id="no-id-cluster",reason="missing_clusterid"} 1`,
+		},
+		{
+			Obj:         mciNoVendor,
+			MetricNames: []string{"acm_managed_cluster_info_incomplete"},
+			Want:        `acm_managed_cluster_info_incomplete{hub_cluster_id="mycluster_id",managed_cluster_id="no_vendor_cluster_id",reason="missing_kubevendor"} 1`,
+		},
+		{
+			Obj:         mciComplete,
+			MetricNames: []string{"acm_managed_cluster_info_incomplete"},
+			Want:        "",
+		},
+	}
+	for i, c := range tests {
+		c.Func = metric.ComposeMetricGenFuncs(getManagedClusterInfoMetricFamilies("mycluster_id", clusters, nil, nil, "", false, false, nil))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
+
+func Test_getManagedClusterInfoMetricFamilies_collectorErrors(t *testing.T) {
+	mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "orphan-cluster", Namespace: "orphan-cluster"},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor: mciv1beta1.KubeVendorEKS,
+			ClusterID:  "orphan_cluster_id",
+		},
+	})
+	clusters := newTestClusterCache(t, mci)
+	collectorErrors := CollectorErrorsMetric.WithLabelValues(mcGVR.Resource, "orphan-cluster")
+	before := testutil.ToFloat64(collectorErrors)
+	c := generateMetricsTestCase{
+		Obj:         mci,
+		MetricNames: []string{"acm_managed_cluster_info"},
+		Want:        "",
+		Func:        metric.ComposeMetricGenFuncs(getManagedClusterInfoMetricFamilies("mycluster_id", clusters, nil, nil, "", false, false, nil)),
+	}
+	if err := c.run(); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+	if got := testutil.ToFloat64(collectorErrors) - before; got == 0 {
+		t.Errorf("collector errors of the missing ManagedCluster were not incremented")
+	}
+}
+
+// Test_getManagedClusterInfoMetricFamilies_byteSlice asserts the exact
+// serialization of acm_managed_cluster_info, label order included, as read
+// from a cluster cache fed by a fake dynamic client.
+func Test_getManagedClusterInfoMetricFamilies_distributionMismatch(t *testing.T) {
+	mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "mismatch-cluster", Namespace: "mismatch-cluster"},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor: mciv1beta1.KubeVendorOpenShift,
+			ClusterID:  "mismatch_cluster_id",
+		},
+	})
+	mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "mismatch-cluster"},
+	})
+	clusters := newTestClusterCache(t, mci, mc)
+	generate := metric.ComposeMetricGenFuncs(getManagedClusterInfoMetricFamilies("mycluster_id", clusters, nil, nil, "", false, false, nil))
+	tests := []struct {
+		name string
+		obj  *unstructured.Unstructured
+		want float64
+	}{
+		{name: "managed cluster info update", obj: mci, want: 1},
+		{name: "managed cluster update", obj: mc, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := testutil.ToFloat64(DistributionMismatchMetric)
+			generate(tt.obj)
+			if got := testutil.ToFloat64(DistributionMismatchMetric) - before; got != tt.want {
+				t.Errorf("distribution mismatch increment = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getManagedClusterInfoMetricFamilies_byteSlice(t *testing.T) {
+	mciHive := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "hive-cluster", Namespace: "hive-cluster"},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor:  mciv1beta1.KubeVendorOpenShift,
+			CloudVendor: mciv1beta1.CloudVendorAWS,
+			Version:     "v1.20.0",
+			ClusterID:   "hive_cluster_id",
+			DistributionInfo: mciv1beta1.DistributionInfo{
+				Type: mciv1beta1.DistributionTypeOCP,
+				OCP:  mciv1beta1.OCPDistributionInfo{Version: "4.7.2"},
+			},
+		},
+	})
+	mcHive := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "hive-cluster",
+			Annotations: map[string]string{createdViaAnnotation: "hive"},
+		},
+		Status: mcv1.ManagedClusterStatus{
+			Capacity: mcv1.ResourceList{
+				resourceCoreWorker:   *resource.NewQuantity(4, resource.DecimalSI),
+				resourceSocketWorker: *resource.NewQuantity(2, resource.DecimalSI),
+			},
+		},
+	})
+	mciEKS := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "eks-cluster", Namespace: "eks-cluster"},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor:  mciv1beta1.KubeVendorEKS,
+			CloudVendor: mciv1beta1.CloudVendorAWS,
+			Version:     "v1.19.6",
+		},
+	})
+	mcEKS := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "eks-cluster"},
+	})
+	mciIncomplete := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "importing-cluster", Namespace: "importing-cluster"},
+		Status:     mciv1beta1.ClusterInfoStatus{KubeVendor: mciv1beta1.KubeVendorOpenShift},
+	})
+	mcIncomplete := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "importing-cluster"},
+	})
+	clusters := newTestClusterCache(t, mciHive, mcHive, mciEKS, mcEKS, mciIncomplete, mcIncomplete)
+	tests := []struct {
+		name string
+		obj  *unstructured.Unstructured
+		want string
+	}{
+		{
+			name: "openshift created via hive",
+			obj:  mciHive,
+			want: `acm_managed_cluster_info{hub_cluster_id="mycluster_id",managed_cluster_id="hive_cluster_id",vendor="OpenShift",cloud="Amazon",version="4.7.2",available="Unknown",created_via="Hive",core_worker="4",socket_worker="2",kubernetes_version="v1.20.0",hosting_cluster=""} 1
+`,
+		},
+		{
+			name: "eks created via other",
+			obj:  mciEKS,
+			want: `acm_managed_cluster_info{hub_cluster_id="mycluster_id",managed_cluster_id="eks-cluster",vendor="EKS",cloud="Amazon",version="v1.19.6",available="Unknown",created_via="Other",core_worker="0",socket_worker="0",kubernetes_version="v1.19.6",hosting_cluster=""} 1
+`,
+		},
+		{
+			name: "incomplete info",
+			obj:  mciIncomplete,
+			want: "",
+		},
+	}
+	generate := metric.ComposeMetricGenFuncs(getManagedClusterInfoMetricFamilies("mycluster_id", clusters, nil, nil, "", false, false, nil))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			for _, f := range generate(tt.obj) {
+				if b := string(f.ByteSlice()); strings.HasPrefix(b, descClusterInfoName+"{") {
+					got = b
+				}
+			}
+			if got != tt.want {
+				t.Errorf("ByteSlice() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getManagedClusterInfoMetricFamilies_apiURL(t *testing.T) {
+	mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "eks-cluster", Namespace: "eks-cluster"},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor:  mciv1beta1.KubeVendorEKS,
+			CloudVendor: mciv1beta1.CloudVendorAWS,
+			Version:     "v1.19.6",
+		},
+	})
+	mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "eks-cluster"},
+		Spec: mcv1.ManagedClusterSpec{
+			ManagedClusterClientConfigs: []mcv1.ClientConfig{{URL: "https://api.eks-cluster.example.com:6443"}},
+		},
+	})
+	mciNoConfig := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "no-config-cluster", Namespace: "no-config-cluster"},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor:  mciv1beta1.KubeVendorEKS,
+			CloudVendor: mciv1beta1.CloudVendorAWS,
+			Version:     "v1.19.6",
+		},
+	})
+	mcNoConfig := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "no-config-cluster"},
+	})
+	clusters := newTestClusterCache(t, mci, mc, mciNoConfig, mcNoConfig)
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         mci,
+			MetricNames: []string{"acm_managed_cluster_info"},
+			Want:        `acm_managed_cluster_info{hosting_cluster="",cloud="Amazon",core_worker="0",managed_cluster_id="eks-cluster",created_via="Other",hub_cluster_id="mycluster_id",socket_worker="0",available="Unknown",vendor="EKS",version="v1.19.6",kubernetes_version="v1.19.6",api_url="https://api.eks-cluster.example.com:6443"} 1`,
+		},
+		{
+			Obj:         mciNoConfig,
+			MetricNames: []string{"acm_managed_cluster_info"},
+			Want:        `acm_managed_cluster_info{hosting_cluster="",cloud="Amazon",core_worker="0",managed_cluster_id="no-config-cluster",created_via="Other",hub_cluster_id="mycluster_id",socket_worker="0",available="Unknown",vendor="EKS",version="v1.19.6",kubernetes_version="v1.19.6",api_url=""} 1`,
+		},
+	}
+	for i, c := range tests {
+		c.Func = metric.ComposeMetricGenFuncs(getManagedClusterInfoMetricFamilies("mycluster_id", clusters, nil, nil, "", true, false, nil))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
+
+func Test_getManagedClusterInfoMetricFamilies_clusterUID(t *testing.T) {
+	mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "eks-cluster", Namespace: "eks-cluster"},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor:  mciv1beta1.KubeVendorEKS,
+			CloudVendor: mciv1beta1.CloudVendorAWS,
+			Version:     "v1.19.6",
+		},
+	})
+	mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "eks-cluster", UID: "0f8d2e3c-7a4b-4d4e-9c1a-5b6f7e8d9a0b"},
+	})
+	clusters := newTestClusterCache(t, mci, mc)
+	tests := []struct {
+		infoLabels []string
+		want       string
+	}{
+		{
+			want: `acm_managed_cluster_info{hosting_cluster="",cloud="Amazon",core_worker="0",managed_cluster_id="eks-cluster",created_via="Other",hub_cluster_id="mycluster_id",socket_worker="0",available="Unknown",vendor="EKS",version="v1.19.6",kubernetes_version="v1.19.6",managed_cluster_uid="0f8d2e3c-7a4b-4d4e-9c1a-5b6f7e8d9a0b"} 1`,
+		},
+		{
+			infoLabels: []string{"vendor"},
+			want:       `acm_managed_cluster_info{managed_cluster_id="eks-cluster",hub_cluster_id="mycluster_id",vendor="EKS"} 1`,
+		},
+	}
+	for i, tt := range tests {
+		c := generateMetricsTestCase{
+			Obj:         mci,
+			MetricNames: []string{"acm_managed_cluster_info"},
+			Want:        tt.want,
+			Func:        metric.ComposeMetricGenFuncs(getManagedClusterInfoMetricFamilies("mycluster_id", clusters, nil, nil, "", false, true, tt.infoLabels)),
+		}
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
+
+func Test_getSplitInfoMetricFamilies(t *testing.T) {
+	mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "hive-cluster", Namespace: "hive-cluster"},
+		Status: mciv1beta1.ClusterInfoStatus{
+			ClusterID:   "managed_cluster_id",
+			KubeVendor:  mciv1beta1.KubeVendorOpenShift,
+			CloudVendor: mciv1beta1.CloudVendorAWS,
+			Version:     "v1.16.2",
+			DistributionInfo: mciv1beta1.DistributionInfo{
+				Type: mciv1beta1.DistributionTypeOCP,
+				OCP:  mciv1beta1.OCPDistributionInfo{Version: "4.3.1"},
+			},
+		},
+	})
+	mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "hive-cluster",
+			Annotations: map[string]string{createdViaAnnotation: "hive"},
+		},
+		Status: mcv1.ManagedClusterStatus{
+			Capacity: mcv1.ResourceList{
+				resourceCoreWorker:   *resource.NewQuantity(4, resource.DecimalSI),
+				resourceSocketWorker: *resource.NewQuantity(2, resource.DecimalSI),
+			},
+		},
+	})
+	mciIncomplete := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "incomplete-cluster", Namespace: "incomplete-cluster"},
+		Status:     mciv1beta1.ClusterInfoStatus{KubeVendor: mciv1beta1.KubeVendorOpenShift},
+	})
+	mcIncomplete := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "incomplete-cluster"},
+	})
+	clusters := newTestClusterCache(t, mci, mc, mciIncomplete, mcIncomplete)
+	names := []string{"acm_managed_cluster_version_info", "acm_managed_cluster_capacity_info", "acm_managed_cluster_provenance_info"}
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         mci,
+			MetricNames: names,
+			Want: `acm_managed_cluster_version_info{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id",vendor="OpenShift",version="4.3.1",kubernetes_version="v1.16.2"} 1
+acm_managed_cluster_capacity_info{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id",core_worker="4",socket_worker="2"} 1
+acm_managed_cluster_provenance_info{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id",cloud="Amazon",created_via="Hive",hosting_cluster=""} 1`,
+		},
+		{
+			Obj:         mciIncomplete,
+			MetricNames: names,
+			Want:        "",
+		},
+	}
+	for i, c := range tests {
+		c.Func = metric.ComposeMetricGenFuncs(getSplitInfoMetricFamilies("mycluster_id", clusters, nil, nil))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
+
+func Test_getAutoscalerMetricFamilies(t *testing.T) {
+	newObjects := func(name string, claims []mcv1.ManagedClusterClaim) (*unstructured.Unstructured, *unstructured.Unstructured) {
+		mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: name},
+			Status:     mciv1beta1.ClusterInfoStatus{ClusterID: name + "_id"},
+		})
+		mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     mcv1.ManagedClusterStatus{ClusterClaims: claims},
+		})
+		return mci, mc
+	}
+	mciEnabled, mcEnabled := newObjects("enabled-cluster", []mcv1.ManagedClusterClaim{
+		{Name: "autoscaler.example.com", Value: "true"},
+	})
+	mciDisabled, mcDisabled := newObjects("disabled-cluster", []mcv1.ManagedClusterClaim{
+		{Name: "autoscaler.example.com", Value: "false"},
+	})
+	mciInvalid, mcInvalid := newObjects("invalid-cluster", []mcv1.ManagedClusterClaim{
+		{Name: "autoscaler.example.com", Value: "sometimes"},
+	})
+	mciNoClaim, mcNoClaim := newObjects("no-claim-cluster", nil)
+
+	clusters := newTestClusterCache(t, mciEnabled, mcEnabled, mciDisabled, mcDisabled,
+		mciInvalid, mcInvalid, mciNoClaim, mcNoClaim)
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         mciEnabled,
+			MetricNames: []string{"acm_managed_cluster_autoscaler_enabled"},
+			Want:        `acm_managed_cluster_autoscaler_enabled{hub_cluster_id="mycluster_id",managed_cluster_id="enabled-cluster_id"} 1`,
+		},
+		{
+			Obj:         mciDisabled,
+			MetricNames: []string{"acm_managed_cluster_autoscaler_enabled"},
+			Want:        `acm_managed_cluster_autoscaler_enabled{hub_cluster_id="mycluster_id",managed_cluster_id="disabled-cluster_id"} 0`,
+		},
+		{
+			Obj:         mciInvalid,
+			MetricNames: []string{"acm_managed_cluster_autoscaler_enabled"},
+			Want:        "",
+		},
+		{
+			Obj:         mciNoClaim,
+			MetricNames: []string{"acm_managed_cluster_autoscaler_enabled"},
+			Want:        "",
+		},
+	}
+	for i, c := range tests {
+		c.Func = metric.ComposeMetricGenFuncs(getAutoscalerMetricFamilies("mycluster_id", clusters, "autoscaler.example.com"))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
+
+func Test_getFIPSMetricFamilies(t *testing.T) {
+	newObjects := func(name string, claims []mcv1.ManagedClusterClaim) (*unstructured.Unstructured, *unstructured.Unstructured) {
+		mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: name},
+			Status:     mciv1beta1.ClusterInfoStatus{ClusterID: name + "_id"},
+		})
+		mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     mcv1.ManagedClusterStatus{ClusterClaims: claims},
+		})
+		return mci, mc
+	}
+	mciFIPS, mcFIPS := newObjects("fips-cluster", []mcv1.ManagedClusterClaim{
+		{Name: "fips.example.com", Value: "true"},
+	})
+	mciNoFIPS, mcNoFIPS := newObjects("no-fips-cluster", []mcv1.ManagedClusterClaim{
+		{Name: "fips.example.com", Value: "false"},
+	})
+	mciNoClaim, mcNoClaim := newObjects("no-claim-cluster", []mcv1.ManagedClusterClaim{
+		{Name: "autoscaler.example.com", Value: "true"},
+	})
+
+	clusters := newTestClusterCache(t, mciFIPS, mcFIPS, mciNoFIPS, mcNoFIPS, mciNoClaim, mcNoClaim)
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         mciFIPS,
+			MetricNames: []string{"acm_managed_cluster_fips"},
+			Want:        `acm_managed_cluster_fips{hub_cluster_id="mycluster_id",managed_cluster_id="fips-cluster_id"} 1`,
+		},
+		{
+			Obj:         mciNoFIPS,
+			MetricNames: []string{"acm_managed_cluster_fips"},
+			Want:        `acm_managed_cluster_fips{hub_cluster_id="mycluster_id",managed_cluster_id="no-fips-cluster_id"} 0`,
+		},
+		{
+			Obj:         mciNoClaim,
+			MetricNames: []string{"acm_managed_cluster_fips"},
+			Want:        "",
+		},
+	}
+	for i, c := range tests {
+		c.Func = metric.ComposeMetricGenFuncs(getFIPSMetricFamilies("mycluster_id", clusters, "fips.example.com"))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
+
+func Test_getEtcdEncryptionMetricFamilies(t *testing.T) {
+	mciEncrypted := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "encrypted-cluster", Namespace: "encrypted-cluster"},
+		Status:     mciv1beta1.ClusterInfoStatus{ClusterID: "encrypted-cluster_id"},
+	})
+	mcEncrypted := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "encrypted-cluster"},
+		Status: mcv1.ManagedClusterStatus{ClusterClaims: []mcv1.ManagedClusterClaim{
+			{Name: "etcd-encryption.example.com", Value: "true"},
+		}},
+	})
+
+	clusters := newTestClusterCache(t, mciEncrypted, mcEncrypted)
+	c := generateMetricsTestCase{
+		Obj:         mciEncrypted,
+		MetricNames: []string{"acm_managed_cluster_etcd_encryption_enabled"},
+		Want:        `acm_managed_cluster_etcd_encryption_enabled{hub_cluster_id="mycluster_id",managed_cluster_id="encrypted-cluster_id"} 1`,
+		Func:        metric.ComposeMetricGenFuncs(getEtcdEncryptionMetricFamilies("mycluster_id", clusters, "etcd-encryption.example.com")),
+	}
+	if err := c.run(); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func Test_getInstanceTypeMetricFamilies(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(mciv1beta1.GroupVersion, &mciv1beta1.ManagedClusterInfo{})
+
+	mci := &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "aws-cluster",
+			Namespace: "aws-cluster",
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			ClusterID: "aws_cluster_id",
+			NodeList: []mciv1beta1.NodeStatus{
+				{
+					Name: "master",
+					Labels: map[string]string{
+						instanceTypeLabel: "m5.2xlarge",
+					},
+					Capacity: mciv1beta1.ResourceList{
+						mciv1beta1.ResourceCPU: *resource.NewQuantity(8, resource.DecimalSI),
+					},
+				},
+				{
+					Name: "worker-1",
+					Labels: map[string]string{
+						workerLabel:       "",
+						instanceTypeLabel: "m5.xlarge",
+					},
+					Capacity: mciv1beta1.ResourceList{
+						mciv1beta1.ResourceCPU: *resource.NewQuantity(4, resource.DecimalSI),
+					},
+				},
+				{
+					Name: "worker-2",
+					Labels: map[string]string{
+						workerLabel:       "",
+						instanceTypeLabel: "m5.xlarge",
+					},
+					Capacity: mciv1beta1.ResourceList{
+						mciv1beta1.ResourceCPU: *resource.NewQuantity(4, resource.DecimalSI),
+					},
+				},
+				{
+					Name: "worker-3",
+					Labels: map[string]string{
+						workerLabel:       "",
+						instanceTypeLabel: "c5.large",
+					},
+					Capacity: mciv1beta1.ResourceList{
+						mciv1beta1.ResourceCPU: resource.MustParse("1500m"),
+					},
+				},
+			},
+		},
+	}
+	mciU := &unstructured.Unstructured{}
+	if err := scheme.Scheme.Convert(mci, mciU, nil); err != nil {
+		t.Error(err)
+	}
+	mciNoNodes := &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "no-nodes-cluster",
+			Namespace: "no-nodes-cluster",
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			ClusterID: "no_nodes_cluster_id",
+		},
+	}
+	mciUNoNodes := &unstructured.Unstructured{}
+	if err := scheme.Scheme.Convert(mciNoNodes, mciUNoNodes, nil); err != nil {
+		t.Error(err)
+	}
+
+	clusters := newTestClusterCache(t, mciU, mciUNoNodes)
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         mciU,
+			MetricNames: []string{"acm_managed_cluster_cpu_by_instance_type"},
+			Want: `acm_managed_cluster_cpu_by_instance_type{hub_cluster_id="mycluster_id",managed_cluster_id="aws_cluster_id",instance_type="c5.large"} 1.5
+acm_managed_cluster_cpu_by_instance_type{hub_cluster_id="mycluster_id",managed_cluster_id="aws_cluster_id",instance_type="m5.xlarge"} 8`,
+		},
+		{
+			Obj:         mciUNoNodes,
+			MetricNames: []string{"acm_managed_cluster_cpu_by_instance_type"},
+			Want:        "",
+		},
+	}
+	for i, c := range tests {
+		c.Func = metric.ComposeMetricGenFuncs(getInstanceTypeMetricFamilies("mycluster_id", clusters, nil))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
+
+func Test_withUniqueManagedClusterInfo(t *testing.T) {
+	mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Namespace: "cluster-1"},
+	})
+	mciDuplicate := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Namespace: "cluster-2", UID: "duplicate-uid"},
+	})
+	mciOverride := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "info", Namespace: "cluster-2",
+			Labels: map[string]string{"example.com/cluster-name": "cluster-2"}},
+	})
+	mciOwned := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "info", Namespace: "cluster-3",
+			OwnerReferences: []metav1.OwnerReference{managedClusterOwner("cluster-3")}},
+	})
+	mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1"},
+	})
+	clusters := newTestClusterCacheWithSettings(t, clusterSettings{nameLabel: "example.com/cluster-name"},
+		mci, mciDuplicate, mciOverride, mciOwned, mc)
+	generated := 0
+	generateFunc := withUniqueManagedClusterInfo(clusters, 1, func(interface{}) []metricsstore.FamilyByteSlicer {
+		generated++
+		return emptyFamilies(1)
+	})
+
+	tests := []struct {
+		name          string
+		obj           *unstructured.Unstructured
+		wantGenerated int
+		wantDuplicate float64
+	}{
+		{name: "cluster namespace", obj: mci, wantGenerated: 1},
+		{name: "other namespace", obj: mciDuplicate, wantDuplicate: 1},
+		{name: "other namespace regenerated", obj: mciDuplicate},
+		{name: "managed cluster", obj: mc, wantGenerated: 1},
+		{name: "cluster name label", obj: mciOverride, wantGenerated: 1},
+		{name: "owned by the cluster", obj: mciOwned, wantGenerated: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generated = 0
+			before := testutil.ToFloat64(DuplicateManagedClusterInfoMetric)
+			if got := generateFunc(tt.obj); len(got) != 1 {
+				t.Errorf("expected 1 family got %d", len(got))
+			}
+			if generated != tt.wantGenerated {
+				t.Errorf("generated = %d, want %d", generated, tt.wantGenerated)
+			}
+			if got := testutil.ToFloat64(DuplicateManagedClusterInfoMetric) - before; got != tt.wantDuplicate {
+				t.Errorf("duplicate increment = %v, want %v", got, tt.wantDuplicate)
+			}
+		})
+	}
+}
+
+func Test_withUniqueManagedClusterInfo_onlyInNamespace(t *testing.T) {
+	mciOnly := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "info", Namespace: "cluster-2"},
+		Status:     mciv1beta1.ClusterInfoStatus{ClusterID: "cluster_id_2"},
+	})
+	mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-2"},
+		Status: mcv1.ManagedClusterStatus{
+			Conditions: []metav1.Condition{{Type: mcv1.ManagedClusterConditionAvailable, Status: metav1.ConditionTrue}},
+		},
+	})
+	clusters := newTestClusterCache(t, mciOnly, mc)
+	families := getManagedClusterStatusMetricFamilies("hub_cluster_id", clusters)
+	store := metricsstore.NewMetricsStore(metric.ExtractMetricFamilyHeaders(families),
+		withUniqueManagedClusterInfo(clusters, len(families), metric.ComposeMetricGenFuncs(families)))
+
+	before := testutil.ToFloat64(DuplicateManagedClusterInfoMetric)
+	if err := store.Add(mciOnly); err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	store.WriteAll(buf)
+	if !strings.Contains(buf.String(), `managed_cluster_id="cluster_id_2"`) {
+		t.Errorf("expected the metrics of the only ManagedClusterInfo of the namespace, got:\n%s", buf.String())
+	}
+	if got := testutil.ToFloat64(DuplicateManagedClusterInfoMetric) - before; got != 0 {
+		t.Errorf("duplicate increment = %v, want 0", got)
+	}
+}
+
+func Test_getVersion(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       mciv1beta1.ClusterInfoStatus
+		claims       []mcv1.ManagedClusterClaim
+		desired      string
+		want         string
+		wantMismatch bool
+	}{
+		{
+			name: "openshift",
+			status: mciv1beta1.ClusterInfoStatus{
+				KubeVendor: mciv1beta1.KubeVendorOpenShift,
+				DistributionInfo: mciv1beta1.DistributionInfo{
+					Type: mciv1beta1.DistributionTypeOCP,
+					OCP:  mciv1beta1.OCPDistributionInfo{Version: "4.7.2"},
+				},
+			},
+			want: "4.7.2",
+		},
+		{
+			name: "openshift without distribution info",
+			status: mciv1beta1.ClusterInfoStatus{
+				KubeVendor: mciv1beta1.KubeVendorOpenShift,
+				Version:    "v1.20.0",
+			},
+			want:         unknownVersion,
+			wantMismatch: true,
+		},
+		{
+			name: "openshift version claim",
+			status: mciv1beta1.ClusterInfoStatus{
+				KubeVendor: mciv1beta1.KubeVendorOpenShift,
+				Version:    "v1.20.0",
+			},
+			claims: []mcv1.ManagedClusterClaim{{Name: "version.openshift.io", Value: "4.7.2"}},
+			want:   "4.7.2",
+		},
+		{
+			name: "openshift clusterversion view over version claim",
+			status: mciv1beta1.ClusterInfoStatus{
+				KubeVendor: mciv1beta1.KubeVendorOpenShift,
+				Version:    "v1.20.0",
+			},
+			claims:  []mcv1.ManagedClusterClaim{{Name: "version.openshift.io", Value: "4.7.2"}},
+			desired: "4.7.3",
+			want:    "4.7.3",
+		},
+		{
+			name: "other vendor",
+			status: mciv1beta1.ClusterInfoStatus{
+				KubeVendor: mciv1beta1.KubeVendorEKS,
+				Version:    "v1.19.6",
+			},
+			want: "v1.19.6",
+		},
+		{
+			name: "gke without version",
+			status: mciv1beta1.ClusterInfoStatus{
+				KubeVendor: mciv1beta1.KubeVendorGKE,
+			},
+			want: "",
+		},
+		{
+			name: "no vendor",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mci := &mciv1beta1.ManagedClusterInfo{ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-1"}, Status: tt.status}
+			mc := &mcv1.ManagedCluster{Status: mcv1.ManagedClusterStatus{ClusterClaims: tt.claims}}
+			var views *clusterViews
+			if tt.desired != "" {
+				view := newClusterViewU("cluster-1", clusterVersionViewName, nil)
+				view.Object["status"] = map[string]interface{}{"result": map[string]interface{}{
+					"status": map[string]interface{}{"desired": map[string]interface{}{"version": tt.desired}},
+				}}
+				informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &unstructured.Unstructured{}, 0, cache.Indexers{})
+				if err := informer.GetIndexer().Add(view); err != nil {
+					t.Fatal(err)
+				}
+				views = &clusterViews{views: []cache.SharedIndexInformer{informer}}
+			}
+			got, mismatch := defaultClusterSettings.getVersion(mci, mc, views)
+			if got != tt.want {
+				t.Errorf("getVersion() = %v, want %v", got, tt.want)
+			}
+			if mismatch != tt.wantMismatch {
+				t.Errorf("getVersion() mismatch = %v, want %v", mismatch, tt.wantMismatch)
+			}
+		})
+	}
+}
+
+func Test_getLastUpdated(t *testing.T) {
+	conditionTime := metav1.NewTime(time.Unix(1620000000, 0))
+	fieldsTime := metav1.NewTime(time.Unix(1620000300, 0))
+	tests := []struct {
+		name string
+		mci  *mciv1beta1.ManagedClusterInfo
+		want metav1.Time
+	}{
+		{
+			name: "no conditions",
+			mci: &mciv1beta1.ManagedClusterInfo{
+				ObjectMeta: metav1.ObjectMeta{ManagedFields: []metav1.ManagedFieldsEntry{{Time: &fieldsTime}}},
+			},
+			want: metav1.Time{},
+		},
+		{
+			name: "condition transition",
+			mci: &mciv1beta1.ManagedClusterInfo{
+				ObjectMeta: metav1.ObjectMeta{ManagedFields: []metav1.ManagedFieldsEntry{{Time: &fieldsTime}}},
+				Status: mciv1beta1.ClusterInfoStatus{
+					Conditions: []metav1.Condition{{Type: managedClusterInfoConditionSynced, LastTransitionTime: conditionTime}},
+				},
+			},
+			want: conditionTime,
+		},
+		{
+			name: "managed fields without condition transition",
+			mci: &mciv1beta1.ManagedClusterInfo{
+				ObjectMeta: metav1.ObjectMeta{ManagedFields: []metav1.ManagedFieldsEntry{{}, {Time: &fieldsTime}}},
+				Status: mciv1beta1.ClusterInfoStatus{
+					Conditions: []metav1.Condition{{Type: managedClusterInfoConditionSynced}},
+				},
+			},
+			want: fieldsTime,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getLastUpdated(tt.mci); !got.Equal(&tt.want) {
+				t.Errorf("getLastUpdated() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getCapacity(t *testing.T) {
+	mc := &mcv1.ManagedCluster{
+		Status: mcv1.ManagedClusterStatus{
+			Capacity: mcv1.ResourceList{
+				resourceCoreWorker:   *resource.NewQuantity(4, resource.DecimalSI),
+				resourceSocketWorker: *resource.NewQuantity(2, resource.DecimalSI),
+				"cores":              *resource.NewQuantity(8, resource.DecimalSI),
+			},
+		},
+	}
+	tests := []struct {
+		name       string
+		names      workerResourceNames
+		wantCore   int64
+		wantSocket int64
+	}{
+		{
+			name:       "default names",
+			names:      defaultWorkerResourceNames,
+			wantCore:   4,
+			wantSocket: 2,
+		},
+		{
+			name:       "overridden names",
+			names:      workerResourceNames{coreWorker: "cores", socketWorker: "sockets"},
+			wantCore:   8,
+			wantSocket: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotCore, gotSocket := getCapacity(mc, tt.names)
+			if gotCore != tt.wantCore || gotSocket != tt.wantSocket {
+				t.Errorf("getCapacity() = %d, %d, want %d, %d", gotCore, gotSocket, tt.wantCore, tt.wantSocket)
+			}
+		})
+	}
+}
+
+func Test_collapsePrereleaseVersion(t *testing.T) {
+	settings := clusterSettings{collapsePrereleaseVersions: true}
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{version: "4.13.0-0.nightly-2023-01-27-165107", want: "4.13.0-nightly"},
+		{version: "4.12.0-0.nightly-arm64-2022-12-05-155739", want: "4.12.0-nightly"},
+		{version: "4.13.0-0.ci-2023-01-26-202347", want: "4.13.0-ci"},
+		{version: "4.12.0-0.okd-2023-01-21-055900", want: "4.12.0-okd"},
+		{version: "4.13.0-rc.2", want: "4.13.0-rc.2"},
+		{version: "4.13.0-ec.3", want: "4.13.0-ec.3"},
+		{version: "4.12.3", want: "4.12.3"},
+		{version: unknownVersion, want: unknownVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if got := settings.collapsePrereleaseVersion(tt.version); got != tt.want {
+				t.Errorf("collapsePrereleaseVersion() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+	if got := defaultClusterSettings.collapsePrereleaseVersion("4.13.0-0.nightly-2023-01-27-165107"); got != "4.13.0-0.nightly-2023-01-27-165107" {
+		t.Errorf("expected the version to be kept without the option, got %s", got)
+	}
+}
+
+func Test_createManagedClusterInfoListWatchWithClient(t *testing.T) {
+	s := scheme.Scheme
+
+	s.AddKnownTypes(mciv1beta1.GroupVersion, &mciv1beta1.ManagedClusterInfo{})
+	s.AddKnownTypes(mciv1beta1.GroupVersion, &mciv1beta1.ManagedClusterInfoList{})
 
 	mc := &mciv1beta1.ManagedClusterInfo{
 		TypeMeta: metav1.TypeMeta{
@@ -315,7 +1730,7 @@ func Test_createManagedClusterInfoListWatchWithClient(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := createManagedClusterInfoListWatchWithClient(tt.args.client, tt.args.ns)
+			got := createManagedClusterInfoListWatchWithClient(tt.args.client, tt.args.ns, nil, defaultRequestTimeout)
 			l, err := got.ListFunc(metav1.ListOptions{})
 			if (err != nil) != tt.wantErr {
 				t.Error(err)
@@ -338,3 +1753,147 @@ func Test_createManagedClusterInfoListWatchWithClient(t *testing.T) {
 		})
 	}
 }
+
+func Test_createManagedClusterListWatchWithClient_selector(t *testing.T) {
+	shard := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Labels: map[string]string{"shard": "a"}},
+	})
+	other := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-2", Labels: map[string]string{"shard": "b"}},
+	})
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{mcGVR: "ManagedClusterList"}, shard, other)
+	selector, err := labels.Parse("shard=a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lw := createManagedClusterListWatchWithClient(client, selector, defaultRequestTimeout)
+	l, err := lw.ListFunc(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items := l.(*unstructured.UnstructuredList).Items; len(items) != 1 || items[0].GetName() != "cluster-1" {
+		t.Errorf("expected only cluster-1 to be listed, got %v", items)
+	}
+	// The reflector watches again with new options after a disconnection.
+	for i := 0; i < 2; i++ {
+		w, err := lw.WatchFunc(metav1.ListOptions{ResourceVersion: "1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Stop()
+	}
+	watches := 0
+	for _, action := range client.Actions() {
+		if watch, ok := action.(clienttesting.WatchAction); ok {
+			watches++
+			if got := watch.GetWatchRestrictions().Labels.String(); got != "shard=a" {
+				t.Errorf("watch selector = %q, want shard=a", got)
+			}
+		}
+	}
+	if watches != 2 {
+		t.Errorf("expected 2 watches, got %d", watches)
+	}
+}
+
+func Test_createManagedClusterInfoListWatchWithClient_selector(t *testing.T) {
+	shard := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Namespace: "cluster-1", Labels: map[string]string{"shard": "a"}},
+	})
+	other := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-2", Namespace: "cluster-2", Labels: map[string]string{"shard": "b"}},
+	})
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{mciGVR: "ManagedClusterInfoList"}, shard, other)
+	selector, err := labels.Parse("shard=a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lw := createManagedClusterInfoListWatchWithClient(client, metav1.NamespaceAll, selector, defaultRequestTimeout)
+	l, err := lw.ListFunc(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items := l.(*unstructured.UnstructuredList).Items; len(items) != 1 || items[0].GetName() != "cluster-1" {
+		t.Errorf("expected only cluster-1 to be listed, got %v", items)
+	}
+	// The reflector watches again with new options after a disconnection.
+	for i := 0; i < 2; i++ {
+		w, err := lw.WatchFunc(metav1.ListOptions{ResourceVersion: "1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Stop()
+	}
+	watches := 0
+	for _, action := range client.Actions() {
+		if watch, ok := action.(clienttesting.WatchAction); ok {
+			watches++
+			if got := watch.GetWatchRestrictions().Labels.String(); got != "shard=a" {
+				t.Errorf("watch selector = %q, want shard=a", got)
+			}
+		}
+	}
+	if watches != 2 {
+		t.Errorf("expected 2 watches, got %d", watches)
+	}
+}
+
+// Benchmark_getManagedClusterInfoMetricFamilies measures the generation of
+// the metrics of a cluster, its ManagedClusterInfo and ManagedCluster are
+// read from the cluster cache without request to the apiserver.
+func Benchmark_getManagedClusterInfoMetricFamilies(b *testing.B) {
+	mci := &mciv1beta1.ManagedClusterInfo{
+		TypeMeta:   metav1.TypeMeta{APIVersion: mciv1beta1.GroupVersion.String(), Kind: "ManagedClusterInfo"},
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Namespace: "cluster-1"},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor:  mciv1beta1.KubeVendorOpenShift,
+			CloudVendor: mciv1beta1.CloudVendorAWS,
+			Version:     "v1.20.0",
+			ClusterID:   "cluster_id_1",
+			DistributionInfo: mciv1beta1.DistributionInfo{
+				Type: mciv1beta1.DistributionTypeOCP,
+				OCP:  mciv1beta1.OCPDistributionInfo{Version: "4.7.0"},
+			},
+		},
+	}
+	mc := &mcv1.ManagedCluster{
+		TypeMeta:   metav1.TypeMeta{APIVersion: mcv1.GroupVersion.String(), Kind: "ManagedCluster"},
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1"},
+		Status: mcv1.ManagedClusterStatus{
+			Capacity: mcv1.ResourceList{
+				resourceCoreWorker:   *resource.NewQuantity(8, resource.DecimalSI),
+				resourceSocketWorker: *resource.NewQuantity(2, resource.DecimalSI),
+			},
+		},
+	}
+	objs := []runtime.Object{}
+	for _, obj := range []interface{}{mci, mc} {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			b.Fatal(err)
+		}
+		objs = append(objs, &unstructured.Unstructured{Object: content})
+	}
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			mciGVR: "ManagedClusterInfoList",
+			mcGVR:  "ManagedClusterList",
+		}, objs...)
+	clusters := newClusterCache(client, []string{metav1.NamespaceAll}, nil, 0, defaultRequestTimeout, defaultClusterSettings)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clusters.run(ctx)
+	if !cache.WaitForCacheSync(ctx.Done(), clusters.hasSynced) {
+		b.Fatal("the cluster cache didn't sync")
+	}
+	generate := metric.ComposeMetricGenFuncs(getManagedClusterInfoMetricFamilies("mycluster_id", clusters, nil, nil, "", false, false, nil))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		generate(objs[0])
+	}
+}
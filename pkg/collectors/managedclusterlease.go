@@ -0,0 +1,153 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+// unreachableTaintKey is the taint set by the hub on the ManagedClusters
+// whose agent stopped renewing its lease.
+const unreachableTaintKey = "cluster.open-cluster-management.io/unreachable"
+
+// managedClusterLeaseName is the name of the lease renewed by the
+// registration agent in the namespace of its managed cluster on the hub.
+const managedClusterLeaseName = "managed-cluster-lease"
+
+var (
+	descClusterHeartbeatLagName          = "acm_managed_cluster_heartbeat_lag_seconds"
+	descClusterHeartbeatLagHelp          = "Time elapsed since the registration agent of the managed cluster last renewed its lease"
+	descClusterHeartbeatLagDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	descClusterUnreachableName          = "acm_managed_cluster_unreachable_seconds"
+	descClusterUnreachableHelp          = "Time elapsed since the managed cluster was tainted unreachable"
+	descClusterUnreachableDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	leaseGVR = schema.GroupVersionResource{
+		Group:    "coordination.k8s.io",
+		Version:  "v1",
+		Resource: "leases",
+	}
+)
+
+// getManagedClusterLeaseMetricFamilies returns the families generated at
+// scrape time from the managed cluster leases and the ManagedClusterInfos
+// providing their managed_cluster_id.
+func getManagedClusterLeaseMetricFamilies(hubClusterID string, settings clusterSettings) []metric.FamilyGenerator {
+	return []metric.FamilyGenerator{
+		{
+			Name: descClusterHeartbeatLagName,
+			Type: metric.Gauge,
+			Help: descClusterHeartbeatLagHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				clusterIDs := map[string]string{}
+				for _, mci := range f.managedClusterInfos {
+					clusterIDs[settings.clusterNameFor(mci)] = settings.getClusterID(mci)
+				}
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, l := range f.leases {
+					clusterID := clusterIDs[l.GetNamespace()]
+					if clusterID == "" || l.Spec.RenewTime == nil {
+						continue
+					}
+					family.Metrics = append(family.Metrics, &metric.Metric{
+						LabelKeys:   descClusterHeartbeatLagDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID},
+						Value:       now().Sub(l.Spec.RenewTime.Time).Seconds(),
+					})
+				}
+				return family
+			}),
+		},
+		{
+			Name: descClusterUnreachableName,
+			Type: metric.Gauge,
+			Help: descClusterUnreachableHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				clusterIDs := map[string]string{}
+				for _, mci := range f.managedClusterInfos {
+					clusterIDs[settings.clusterNameFor(mci)] = settings.getClusterID(mci)
+				}
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, mc := range f.managedClusters {
+					clusterID := clusterIDs[mc.GetName()]
+					if clusterID == "" {
+						continue
+					}
+					for _, taint := range f.managedClusterTaints[mc.GetName()] {
+						if taint.Key != unreachableTaintKey || taint.TimeAdded.IsZero() {
+							continue
+						}
+						family.Metrics = append(family.Metrics, &metric.Metric{
+							LabelKeys:   descClusterUnreachableDefaultLabels,
+							LabelValues: []string{hubClusterID, clusterID},
+							Value:       now().Sub(taint.TimeAdded.Time).Seconds(),
+						})
+						break
+					}
+				}
+				return family
+			}),
+		},
+	}
+}
+
+// managedClusterTaint is a taint of the ManagedCluster spec. The taints are
+// not part of the compiled-in ManagedCluster API, so they are read from the
+// unstructured object.
+type managedClusterTaint struct {
+	Key       string      `json:"key"`
+	Value     string      `json:"value,omitempty"`
+	Effect    string      `json:"effect"`
+	TimeAdded metav1.Time `json:"timeAdded"`
+}
+
+// getManagedClusterTaints returns the taints of the ManagedCluster.
+func getManagedClusterTaints(u *unstructured.Unstructured) ([]managedClusterTaint, error) {
+	taintsU, _, err := unstructured.NestedSlice(u.Object, "spec", "taints")
+	if err != nil {
+		return nil, err
+	}
+	taints := []managedClusterTaint{}
+	for _, t := range taintsU {
+		tU, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		taint := managedClusterTaint{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(tU, &taint); err != nil {
+			return nil, err
+		}
+		taints = append(taints, taint)
+	}
+	return taints, nil
+}
+
+func createManagedClusterLeaseListWatchWithClient(client dynamic.Interface, ns string, timeout time.Duration) cache.ListWatch {
+	fieldSelector := fmt.Sprintf("metadata.name=%s", managedClusterLeaseName)
+	return cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			opts.FieldSelector = fieldSelector
+			ctx, cancel := requestContext(leaseGVR.Resource, timeout)
+			defer cancel()
+			return client.Resource(leaseGVR).Namespace(ns).List(ctx, opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			opts.FieldSelector = fieldSelector
+			return client.Resource(leaseGVR).Namespace(ns).Watch(context.TODO(), opts)
+		},
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"testing"
+	"time"
+
+	mcv1 "github.com/open-cluster-management/api/cluster/v1"
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+func newLeaseU(t *testing.T, ns string, renewTime *metav1.MicroTime) *unstructured.Unstructured {
+	l := &coordinationv1.Lease{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: coordinationv1.SchemeGroupVersion.String(),
+			Kind:       "Lease",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      managedClusterLeaseName,
+			Namespace: ns,
+		},
+		Spec: coordinationv1.LeaseSpec{
+			RenewTime: renewTime,
+		},
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(l)
+	if err != nil {
+		t.Error(err)
+	}
+	return &unstructured.Unstructured{Object: content}
+}
+
+func Test_getManagedClusterLeaseMetricFamilies(t *testing.T) {
+	scrapeTime := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return scrapeTime }
+	defer func() { now = time.Now }()
+
+	mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-1",
+			Namespace: "cluster-1",
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			KubeVendor: mciv1beta1.KubeVendorOpenShift,
+			ClusterID:  "managed_cluster_id",
+		},
+	})
+	renewTime := metav1.NewMicroTime(scrapeTime.Add(-90 * time.Second))
+	lease := newLeaseU(t, "cluster-1", &renewTime)
+	leaseNotRenewed := newLeaseU(t, "cluster-1", nil)
+	leaseNoMCI := newLeaseU(t, "cluster-2", &renewTime)
+
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         []interface{}{mci, lease, leaseNoMCI},
+			MetricNames: []string{"acm_managed_cluster_heartbeat_lag_seconds"},
+			Want:        `acm_managed_cluster_heartbeat_lag_seconds{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id"} 90`,
+		},
+		{
+			Obj:         []interface{}{mci, leaseNotRenewed},
+			MetricNames: []string{"acm_managed_cluster_heartbeat_lag_seconds"},
+			Want:        "",
+		},
+	}
+	for i, c := range tests {
+		c.Func = withFleet(metric.ComposeMetricGenFuncs(getManagedClusterLeaseMetricFamilies("mycluster_id", defaultClusterSettings)))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
+
+func Test_getManagedClusterLeaseMetricFamilies_unreachable(t *testing.T) {
+	scrapeTime := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return scrapeTime }
+	defer func() { now = time.Now }()
+
+	mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-1",
+			Namespace: "cluster-1",
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			ClusterID: "managed_cluster_id",
+		},
+	})
+	mcUnreachable := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1"},
+	})
+	err := unstructured.SetNestedSlice(mcUnreachable.Object, []interface{}{
+		map[string]interface{}{
+			"key":       "cluster.open-cluster-management.io/unavailable",
+			"effect":    "NoSelect",
+			"timeAdded": scrapeTime.Add(-time.Hour).Format(time.RFC3339),
+		},
+		map[string]interface{}{
+			"key":       unreachableTaintKey,
+			"effect":    "NoSelect",
+			"timeAdded": scrapeTime.Add(-5 * time.Minute).Format(time.RFC3339),
+		},
+	}, "spec", "taints")
+	if err != nil {
+		t.Error(err)
+	}
+	mcReachable := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1"},
+	})
+
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         []interface{}{mci, mcUnreachable},
+			MetricNames: []string{"acm_managed_cluster_unreachable_seconds"},
+			Want:        `acm_managed_cluster_unreachable_seconds{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id"} 300`,
+		},
+		{
+			Obj:         []interface{}{mci, mcReachable},
+			MetricNames: []string{"acm_managed_cluster_unreachable_seconds"},
+			Want:        "",
+		},
+		{
+			Obj:         []interface{}{mcUnreachable},
+			MetricNames: []string{"acm_managed_cluster_unreachable_seconds"},
+			Want:        "",
+		},
+	}
+	for i, c := range tests {
+		c.Func = withFleet(metric.ComposeMetricGenFuncs(getManagedClusterLeaseMetricFamilies("mycluster_id", defaultClusterSettings)))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
+
+func Test_createManagedClusterLeaseListWatchWithClient(t *testing.T) {
+	renewTime := metav1.NewMicroTime(time.Now())
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      managedClusterLeaseName,
+			Namespace: "cluster-1",
+		},
+		Spec: coordinationv1.LeaseSpec{
+			RenewTime: &renewTime,
+		},
+	}
+	client := fake.NewSimpleDynamicClient(scheme.Scheme, lease)
+	lw := createManagedClusterLeaseListWatchWithClient(client, "cluster-1", defaultRequestTimeout)
+	l, err := lw.ListFunc(metav1.ListOptions{})
+	if err != nil {
+		t.Error(err)
+	}
+	if n := len(l.(*unstructured.UnstructuredList).Items); n != 1 {
+		t.Errorf("expected a list of 1 element got %d", n)
+	}
+	w, err := lw.WatchFunc(metav1.ListOptions{})
+	if err != nil {
+		t.Error(err)
+	}
+	if w == nil {
+		t.Errorf("expected the watch to be not nil")
+	}
+}
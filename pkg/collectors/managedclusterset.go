@@ -0,0 +1,146 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kube-state-metrics/pkg/metric"
+
+	mcv1 "github.com/open-cluster-management/api/cluster/v1"
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+)
+
+// clusterSetLabel is the label of the ManagedCluster referencing the
+// ManagedClusterSet the cluster belongs to.
+const clusterSetLabel = "cluster.open-cluster-management.io/clusterset"
+
+var (
+	descClusterSetMisplacementName   = "acm_managed_cluster_set_misplacement"
+	descClusterSetMisplacementHelp   = "Managed cluster referencing a ManagedClusterSet which doesn't exist, 1 when the set doesn't exist"
+	descClusterSetMisplacementLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"clusterset"}
+
+	descClusterSetWorkerCoresName   = "acm_clusterset_worker_cores"
+	descClusterSetWorkerCoresHelp   = "Worker cores of the member clusters of the ManagedClusterSet"
+	descClusterSetWorkerCoresLabels = []string{"hub_cluster_id",
+		"clusterset"}
+
+	mcsGVR = schema.GroupVersionResource{
+		Group:    "cluster.open-cluster-management.io",
+		Version:  "v1alpha1",
+		Resource: "managedclustersets",
+	}
+)
+
+// getManagedClusterSetMetricFamilies returns the families joining the
+// ManagedClusters with the ManagedClusterSets, the ManagedClusterInfos
+// provide the managed_cluster_id.
+func getManagedClusterSetMetricFamilies(hubClusterID string, settings clusterSettings) []metric.FamilyGenerator {
+	return []metric.FamilyGenerator{
+		{
+			Name: descClusterSetMisplacementName,
+			Type: metric.Gauge,
+			Help: descClusterSetMisplacementHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				clusterIDs := map[string]string{}
+				for _, mci := range f.managedClusterInfos {
+					clusterIDs[settings.clusterNameFor(mci)] = settings.getClusterID(mci)
+				}
+				sets := map[string]bool{}
+				for _, mcs := range f.managedClusterSets {
+					sets[mcs.GetName()] = true
+				}
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, mc := range f.managedClusters {
+					clusterSet := getClusterSet(mc)
+					clusterID := clusterIDs[mc.GetName()]
+					if clusterSet == "" || clusterID == "" {
+						continue
+					}
+					value := 0.0
+					if !sets[clusterSet] {
+						value = 1
+					}
+					family.Metrics = append(family.Metrics, &metric.Metric{
+						LabelKeys:   descClusterSetMisplacementLabels,
+						LabelValues: []string{hubClusterID, clusterID, clusterSet},
+						Value:       value,
+					})
+				}
+				return family
+			}),
+		},
+		{
+			Name: descClusterSetWorkerCoresName,
+			Type: metric.Gauge,
+			Help: descClusterSetWorkerCoresHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				mcis := map[string]*mciv1beta1.ManagedClusterInfo{}
+				for _, mci := range f.managedClusterInfos {
+					mcis[settings.clusterNameFor(mci)] = mci
+				}
+				cores := map[string]int64{}
+				for _, mcs := range f.managedClusterSets {
+					cores[mcs.GetName()] = 0
+				}
+				for _, mc := range f.managedClusters {
+					clusterSet := getClusterSet(mc)
+					if _, ok := cores[clusterSet]; !ok {
+						continue
+					}
+					// The incomplete clusters are not accounted, as
+					// acm_managed_cluster_info doesn't expose them.
+					mci, ok := mcis[mc.GetName()]
+					if !ok || len(settings.getMissingInfo(mci)) > 0 {
+						continue
+					}
+					coreWorker, _ := getCapacity(mc, settings.workerResources)
+					cores[clusterSet] += coreWorker
+				}
+				clusterSets := make([]string, 0, len(cores))
+				for clusterSet := range cores {
+					clusterSets = append(clusterSets, clusterSet)
+				}
+				sort.Strings(clusterSets)
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, clusterSet := range clusterSets {
+					family.Metrics = append(family.Metrics, &metric.Metric{
+						LabelKeys:   descClusterSetWorkerCoresLabels,
+						LabelValues: []string{hubClusterID, clusterSet},
+						Value:       float64(cores[clusterSet]),
+					})
+				}
+				return family
+			}),
+		},
+	}
+}
+
+// getClusterSet returns the ManagedClusterSet of the ManagedCluster or an
+// empty string when the cluster doesn't belong to a set.
+func getClusterSet(mc *mcv1.ManagedCluster) string {
+	return mc.GetLabels()[clusterSetLabel]
+}
+
+func createManagedClusterSetListWatchWithClient(client dynamic.Interface, timeout time.Duration) cache.ListWatch {
+	return cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			ctx, cancel := requestContext(mcsGVR.Resource, timeout)
+			defer cancel()
+			return client.Resource(mcsGVR).List(ctx, opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return client.Resource(mcsGVR).Watch(context.TODO(), opts)
+		},
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"testing"
+
+	mcv1 "github.com/open-cluster-management/api/cluster/v1"
+	mcv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+func newManagedClusterSetU(t *testing.T, name string) *unstructured.Unstructured {
+	mcs := &mcv1alpha1.ManagedClusterSet{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: mcv1alpha1.GroupVersion.String(),
+			Kind:       "ManagedClusterSet",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(mcs)
+	if err != nil {
+		t.Error(err)
+	}
+	return &unstructured.Unstructured{Object: content}
+}
+
+func Test_getManagedClusterSetMetricFamilies(t *testing.T) {
+	objs := []interface{}{newManagedClusterSetU(t, "dev")}
+	for _, c := range []struct{ name, clusterSet string }{
+		{"cluster-dev", "dev"},
+		{"cluster-orphan", "removed"},
+		{"cluster-no-set", ""},
+	} {
+		labels := map[string]string{}
+		if c.clusterSet != "" {
+			labels[clusterSetLabel] = c.clusterSet
+		}
+		objs = append(objs,
+			newManagedClusterU(t, &mcv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   c.name,
+					Labels: labels,
+				},
+			}),
+			newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      c.name,
+					Namespace: c.name,
+				},
+				Status: mciv1beta1.ClusterInfoStatus{
+					KubeVendor: mciv1beta1.KubeVendorAKS,
+				},
+			}))
+	}
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         objs,
+			MetricNames: []string{"acm_managed_cluster_set_misplacement"},
+			Want: `acm_managed_cluster_set_misplacement{hub_cluster_id="mycluster_id",managed_cluster_id="cluster-dev",clusterset="dev"} 0
+acm_managed_cluster_set_misplacement{hub_cluster_id="mycluster_id",managed_cluster_id="cluster-orphan",clusterset="removed"} 1`,
+		},
+	}
+	for i, c := range tests {
+		c.Func = withFleet(metric.ComposeMetricGenFuncs(getManagedClusterSetMetricFamilies("mycluster_id", defaultClusterSettings)))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
+
+func Test_getManagedClusterSetMetricFamilies_workerCores(t *testing.T) {
+	objs := []interface{}{newManagedClusterSetU(t, "dev"), newManagedClusterSetU(t, "empty")}
+	for _, c := range []struct {
+		name, clusterSet, clusterID string
+		cores                       int64
+	}{
+		{"cluster-dev-1", "dev", "dev_1_id", 8},
+		{"cluster-dev-2", "dev", "dev_2_id", 4},
+		{"cluster-dev-incomplete", "dev", "", 16},
+		{"cluster-orphan", "removed", "orphan_id", 2},
+		{"cluster-no-set", "", "no_set_id", 2},
+	} {
+		labels := map[string]string{}
+		if c.clusterSet != "" {
+			labels[clusterSetLabel] = c.clusterSet
+		}
+		objs = append(objs,
+			newManagedClusterU(t, &mcv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   c.name,
+					Labels: labels,
+				},
+				Status: mcv1.ManagedClusterStatus{
+					Capacity: mcv1.ResourceList{
+						resourceCoreWorker: *resource.NewQuantity(c.cores, resource.DecimalSI),
+					},
+				},
+			}),
+			newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      c.name,
+					Namespace: c.name,
+				},
+				Status: mciv1beta1.ClusterInfoStatus{
+					ClusterID:  c.clusterID,
+					KubeVendor: mciv1beta1.KubeVendorOpenShift,
+				},
+			}))
+	}
+	c := generateMetricsTestCase{
+		Obj:         objs,
+		MetricNames: []string{"acm_clusterset_worker_cores"},
+		Want: `acm_clusterset_worker_cores{hub_cluster_id="mycluster_id",clusterset="dev"} 12
+acm_clusterset_worker_cores{hub_cluster_id="mycluster_id",clusterset="empty"} 0`,
+		Func: withFleet(metric.ComposeMetricGenFuncs(getManagedClusterSetMetricFamilies("mycluster_id", defaultClusterSettings))),
+	}
+	if err := c.run(); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func Test_createManagedClusterSetListWatchWithClient(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(mcv1alpha1.GroupVersion, &mcv1alpha1.ManagedClusterSet{}, &mcv1alpha1.ManagedClusterSetList{})
+
+	client := fake.NewSimpleDynamicClient(s, &mcv1alpha1.ManagedClusterSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dev",
+		},
+	})
+	lw := createManagedClusterSetListWatchWithClient(client, defaultRequestTimeout)
+	l, err := lw.ListFunc(metav1.ListOptions{})
+	if err != nil {
+		t.Error(err)
+	}
+	if n := len(l.(*unstructured.UnstructuredList).Items); n != 1 {
+		t.Errorf("expected a list of 1 element got %d", n)
+	}
+	w, err := lw.WatchFunc(metav1.ListOptions{})
+	if err != nil {
+		t.Error(err)
+	}
+	if w == nil {
+		t.Errorf("expected the watch to be not nil")
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"strings"
+
+	mcv1 "github.com/open-cluster-management/api/cluster/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+var (
+	descClusterStatusConditionName          = "acm_managed_cluster_status_condition"
+	descClusterStatusConditionHelp          = "Status of the registration conditions of the managed cluster"
+	descClusterStatusConditionDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"condition",
+		"status"}
+
+	descClusterClaimName          = "acm_managed_cluster_claim"
+	descClusterClaimHelp          = "Cluster claims reported by the managed cluster, one series per claim"
+	descClusterClaimDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"claim_name",
+		"claim_value"}
+
+	// managedClusterStatusConditions are the conditions of the
+	// ManagedCluster reported by acm_managed_cluster_status_condition.
+	managedClusterStatusConditions = map[string]bool{
+		mcv1.ManagedClusterConditionAvailable:   true,
+		mcv1.ManagedClusterConditionHubAccepted: true,
+		mcv1.ManagedClusterConditionJoined:      true,
+	}
+)
+
+// getManagedClusterStatusMetricFamilies returns the families exposing the
+// conditions and the cluster claims of the ManagedCluster, they are generated
+// along the ManagedClusterInfo families as they share the ManagedCluster
+// list/watch.
+func getManagedClusterStatusMetricFamilies(hubClusterID string, clusters *clusterCache) []metric.FamilyGenerator {
+	return []metric.FamilyGenerator{
+		{
+			Name: descClusterStatusConditionName,
+			Type: metric.Gauge,
+			Help: descClusterStatusConditionHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				mc, err := clusters.getManagedCluster(clusters.clusterNameFor(mci))
+				if err != nil {
+					countCollectorError(mcGVR.Resource, clusters.clusterNameFor(mci), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, c := range mc.Status.Conditions {
+					if !managedClusterStatusConditions[c.Type] {
+						continue
+					}
+					family.Metrics = append(family.Metrics, &metric.Metric{
+						LabelKeys:   descClusterStatusConditionDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID, c.Type, strings.ToLower(string(c.Status))},
+						Value:       1,
+					})
+				}
+				return family
+			}),
+		},
+		{
+			Name: descClusterClaimName,
+			Type: metric.Gauge,
+			Help: descClusterClaimHelp,
+			GenerateFunc: wrapManagedClusterInfoFunc(func(obj *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(clusters.clusterNameFor(obj))
+				if err != nil {
+					countCollectorError(mciGVR.Resource, clusters.clusterNameFor(obj), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				mc, err := clusters.getManagedCluster(clusters.clusterNameFor(mci))
+				if err != nil {
+					countCollectorError(mcGVR.Resource, clusters.clusterNameFor(mci), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, c := range mc.Status.ClusterClaims {
+					if c.Value == "" {
+						continue
+					}
+					family.Metrics = append(family.Metrics, &metric.Metric{
+						LabelKeys:   descClusterClaimDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID, c.Name, c.Value},
+						Value:       1,
+					})
+				}
+				return family
+			}),
+		},
+	}
+}
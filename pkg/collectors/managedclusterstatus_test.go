@@ -0,0 +1,76 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"testing"
+
+	mcv1 "github.com/open-cluster-management/api/cluster/v1"
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+func Test_getManagedClusterStatusMetricFamilies(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(mciv1beta1.GroupVersion, &mciv1beta1.ManagedClusterInfo{})
+	s.AddKnownTypes(mcv1.GroupVersion, &mcv1.ManagedCluster{})
+
+	newObjects := func(name string, conditions []metav1.Condition, claims ...mcv1.ManagedClusterClaim) (*unstructured.Unstructured, *unstructured.Unstructured) {
+		mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: name},
+			Status:     mciv1beta1.ClusterInfoStatus{ClusterID: name + "_id"},
+		})
+		mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     mcv1.ManagedClusterStatus{Conditions: conditions, ClusterClaims: claims},
+		})
+		return mci, mc
+	}
+	mciJoined, mcJoined := newObjects("joined-cluster", []metav1.Condition{
+		{Type: mcv1.ManagedClusterConditionHubAccepted, Status: metav1.ConditionTrue},
+		{Type: mcv1.ManagedClusterConditionJoined, Status: metav1.ConditionTrue},
+		{Type: mcv1.ManagedClusterConditionAvailable, Status: metav1.ConditionUnknown},
+		{Type: managedClusterConditionClockSynced, Status: metav1.ConditionTrue},
+	},
+		mcv1.ManagedClusterClaim{Name: "region.open-cluster-management.io", Value: "us-east-1"},
+		mcv1.ManagedClusterClaim{Name: "platform.open-cluster-management.io", Value: "AWS"},
+		mcv1.ManagedClusterClaim{Name: "consoleurl.cluster.open-cluster-management.io", Value: ""},
+	)
+	mciPending, mcPending := newObjects("pending-cluster", nil)
+
+	clusters := newTestClusterCache(t, mciJoined, mcJoined, mciPending, mcPending)
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         mciJoined,
+			MetricNames: []string{"acm_managed_cluster_status_condition"},
+			Want: `acm_managed_cluster_status_condition{hub_cluster_id="mycluster_id",managed_cluster_id="joined-cluster_id",condition="HubAcceptedManagedCluster",status="true"} 1
+acm_managed_cluster_status_condition{hub_cluster_id="mycluster_id",managed_cluster_id="joined-cluster_id",condition="ManagedClusterJoined",status="true"} 1
+acm_managed_cluster_status_condition{hub_cluster_id="mycluster_id",managed_cluster_id="joined-cluster_id",condition="ManagedClusterConditionAvailable",status="unknown"} 1`,
+		},
+		{
+			Obj:         mcPending,
+			MetricNames: []string{"acm_managed_cluster_status_condition"},
+			Want:        "",
+		},
+		{
+			Obj:         mciJoined,
+			MetricNames: []string{"acm_managed_cluster_claim"},
+			Want: `acm_managed_cluster_claim{hub_cluster_id="mycluster_id",managed_cluster_id="joined-cluster_id",claim_name="region.open-cluster-management.io",claim_value="us-east-1"} 1
+acm_managed_cluster_claim{hub_cluster_id="mycluster_id",managed_cluster_id="joined-cluster_id",claim_name="platform.open-cluster-management.io",claim_value="AWS"} 1`,
+		},
+		{
+			Obj:         mciPending,
+			MetricNames: []string{"acm_managed_cluster_claim"},
+			Want:        "",
+		},
+	}
+	for i, c := range tests {
+		c.Func = metric.ComposeMetricGenFuncs(getManagedClusterStatusMetricFamilies("mycluster_id", clusters))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
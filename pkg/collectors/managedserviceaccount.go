@@ -0,0 +1,117 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+// managedServiceAccountConditionTokenReported is set by the
+// managed-serviceaccount agent once the token of the service account is
+// reported to the hub.
+const managedServiceAccountConditionTokenReported = "TokenReported"
+
+var (
+	descManagedServiceAccountTokenValidName          = "acm_managed_service_account_token_valid"
+	descManagedServiceAccountTokenValidHelp          = "Managed service account token provisioning, 1 when the agent reported the token to the hub"
+	descManagedServiceAccountTokenValidDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"name"}
+
+	msaGVR = schema.GroupVersionResource{
+		Group:    "authentication.open-cluster-management.io",
+		Version:  "v1beta1",
+		Resource: "managedserviceaccounts",
+	}
+)
+
+// getManagedServiceAccountMetricFamilies returns the families of the
+// ManagedServiceAccounts, located in the namespace of their cluster.
+func getManagedServiceAccountMetricFamilies(hubClusterID string, clusters *clusterCache) []metric.FamilyGenerator {
+	return []metric.FamilyGenerator{
+		{
+			Name: descManagedServiceAccountTokenValidName,
+			Type: metric.Gauge,
+			Help: descManagedServiceAccountTokenValidHelp,
+			GenerateFunc: wrapManagedServiceAccountFunc(func(msa *unstructured.Unstructured) metric.Family {
+				mci, err := clusters.getManagedClusterInfo(msa.GetNamespace())
+				if err != nil {
+					countCollectorError(mciGVR.Resource, msa.GetNamespace(), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				valid := 0.0
+				if isManagedServiceAccountTokenReported(msa) {
+					valid = 1
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descManagedServiceAccountTokenValidDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID, msa.GetName()},
+						Value:       valid,
+					},
+				}}
+			}),
+		},
+	}
+}
+
+// isManagedServiceAccountTokenReported returns true when the TokenReported
+// condition of the ManagedServiceAccount is true.
+func isManagedServiceAccountTokenReported(msa *unstructured.Unstructured) bool {
+	conditions, _, err := unstructured.NestedSlice(msa.Object, "status", "conditions")
+	if err != nil {
+		klog.Errorf("Error: %v", err)
+		return false
+	}
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == managedServiceAccountConditionTokenReported {
+			return condition["status"] == string(metav1.ConditionTrue)
+		}
+	}
+	return false
+}
+
+func wrapManagedServiceAccountFunc(f func(*unstructured.Unstructured) metric.Family) func(interface{}) *metric.Family {
+	return func(obj interface{}) *metric.Family {
+		metricFamily := f(obj.(*unstructured.Unstructured))
+
+		for _, m := range metricFamily.Metrics {
+			m.LabelKeys = append([]string{}, m.LabelKeys...)
+			m.LabelValues = append([]string{}, m.LabelValues...)
+		}
+
+		return &metricFamily
+	}
+}
+
+func createManagedServiceAccountListWatchWithClient(client dynamic.Interface, ns string, timeout time.Duration) cache.ListWatch {
+	return cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			ctx, cancel := requestContext(msaGVR.Resource, timeout)
+			defer cancel()
+			return client.Resource(msaGVR).Namespace(ns).List(ctx, opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return client.Resource(msaGVR).Namespace(ns).Watch(context.TODO(), opts)
+		},
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"testing"
+
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+func newManagedServiceAccountU(ns, name string, conditions ...map[string]interface{}) *unstructured.Unstructured {
+	conditionsU := []interface{}{}
+	for _, c := range conditions {
+		conditionsU = append(conditionsU, c)
+	}
+	msa := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "authentication.open-cluster-management.io/v1beta1",
+		"kind":       "ManagedServiceAccount",
+		"status": map[string]interface{}{
+			"conditions": conditionsU,
+		},
+	}}
+	msa.SetNamespace(ns)
+	msa.SetName(name)
+	return msa
+}
+
+func Test_getManagedServiceAccountMetricFamilies(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(mciv1beta1.GroupVersion, &mciv1beta1.ManagedClusterInfo{})
+
+	mci := &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-1",
+			Namespace: "cluster-1",
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			ClusterID: "managed_cluster_id",
+		},
+	}
+
+	msaReported := newManagedServiceAccountU("cluster-1", "backup-sa",
+		map[string]interface{}{"type": "SecretCreated", "status": "True"},
+		map[string]interface{}{"type": "TokenReported", "status": "True"})
+	msaNotReported := newManagedServiceAccountU("cluster-1", "backup-sa",
+		map[string]interface{}{"type": "TokenReported", "status": "False"})
+	msaNoStatus := newManagedServiceAccountU("cluster-1", "backup-sa")
+	msaNoCluster := newManagedServiceAccountU("cluster-2", "backup-sa",
+		map[string]interface{}{"type": "TokenReported", "status": "True"})
+
+	clusters := newTestClusterCache(t, mci)
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         msaReported,
+			MetricNames: []string{"acm_managed_service_account_token_valid"},
+			Want:        `acm_managed_service_account_token_valid{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id",name="backup-sa"} 1`,
+		},
+		{
+			Obj:         msaNotReported,
+			MetricNames: []string{"acm_managed_service_account_token_valid"},
+			Want:        `acm_managed_service_account_token_valid{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id",name="backup-sa"} 0`,
+		},
+		{
+			Obj:         msaNoStatus,
+			MetricNames: []string{"acm_managed_service_account_token_valid"},
+			Want:        `acm_managed_service_account_token_valid{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id",name="backup-sa"} 0`,
+		},
+		{
+			Obj:         msaNoCluster,
+			MetricNames: []string{"acm_managed_service_account_token_valid"},
+			Want:        "",
+		},
+	}
+	for i, c := range tests {
+		c.Func = metric.ComposeMetricGenFuncs(getManagedServiceAccountMetricFamilies("mycluster_id", clusters))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+var (
+	descClusterManifestWorkDeletingName          = "acm_managed_cluster_manifestwork_deleting_count"
+	descClusterManifestWorkDeletingHelp          = "Number of ManifestWorks of the managed cluster being deleted"
+	descClusterManifestWorkDeletingDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id"}
+
+	workGVR = schema.GroupVersionResource{
+		Group:    "work.open-cluster-management.io",
+		Version:  "v1",
+		Resource: "manifestworks",
+	}
+)
+
+// getManifestWorkMetricFamilies returns the families counting the
+// ManifestWorks in the namespace of each cluster, the ManagedClusterInfos
+// provide the managed_cluster_id.
+func getManifestWorkMetricFamilies(hubClusterID string, settings clusterSettings) []metric.FamilyGenerator {
+	return []metric.FamilyGenerator{
+		{
+			Name: descClusterManifestWorkDeletingName,
+			Type: metric.Gauge,
+			Help: descClusterManifestWorkDeletingHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				deleting := map[string]int{}
+				for _, mw := range f.manifestWorks {
+					if mw.GetDeletionTimestamp() != nil {
+						deleting[mw.GetNamespace()]++
+					}
+				}
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, mci := range f.managedClusterInfos {
+					clusterID := settings.getClusterID(mci)
+					if clusterID == "" {
+						continue
+					}
+					family.Metrics = append(family.Metrics, &metric.Metric{
+						LabelKeys:   descClusterManifestWorkDeletingDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID},
+						Value:       float64(deleting[settings.clusterNameFor(mci)]),
+					})
+				}
+				return family
+			}),
+		},
+	}
+}
+
+// stripManifestWork drops the spec and the status of the ManifestWork, only
+// its metadata is used and the manifests can be large.
+func stripManifestWork(obj runtime.Object) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		unstructured.RemoveNestedField(u.Object, "spec")
+		unstructured.RemoveNestedField(u.Object, "status")
+	}
+}
+
+func createManifestWorkListWatchWithClient(client dynamic.Interface, ns string, timeout time.Duration) cache.ListWatch {
+	return cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			ctx, cancel := requestContext(workGVR.Resource, timeout)
+			defer cancel()
+			l, err := client.Resource(workGVR).Namespace(ns).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			for i := range l.Items {
+				stripManifestWork(&l.Items[i])
+			}
+			return l, nil
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			w, err := client.Resource(workGVR).Namespace(ns).Watch(context.TODO(), opts)
+			if err != nil {
+				return nil, err
+			}
+			return watch.Filter(w, func(e watch.Event) (watch.Event, bool) {
+				stripManifestWork(e.Object)
+				return e, true
+			}), nil
+		},
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"testing"
+	"time"
+
+	workv1 "github.com/open-cluster-management/api/work/v1"
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+func newManifestWorkU(t *testing.T, ns, name string, deletionTimestamp *metav1.Time) *unstructured.Unstructured {
+	mw := &workv1.ManifestWork{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: workv1.GroupVersion.String(),
+			Kind:       "ManifestWork",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         ns,
+			DeletionTimestamp: deletionTimestamp,
+		},
+		Spec: workv1.ManifestWorkSpec{
+			Workload: workv1.ManifestsTemplate{
+				Manifests: []workv1.Manifest{
+					{RawExtension: runtime.RawExtension{Raw: []byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"app"}}`)}},
+				},
+			},
+		},
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(mw)
+	if err != nil {
+		t.Error(err)
+	}
+	return &unstructured.Unstructured{Object: content}
+}
+
+func Test_getManifestWorkMetricFamilies(t *testing.T) {
+	mci1 := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Namespace: "cluster-1"},
+		Status:     mciv1beta1.ClusterInfoStatus{ClusterID: "cluster_id_1"},
+	})
+	mci2 := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-2", Namespace: "cluster-2"},
+		Status:     mciv1beta1.ClusterInfoStatus{ClusterID: "cluster_id_2"},
+	})
+	deletionTimestamp := metav1.NewTime(time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC))
+
+	tests := []generateMetricsTestCase{
+		{
+			Obj: []interface{}{mci1, mci2,
+				newManifestWorkU(t, "cluster-1", "work-1", &deletionTimestamp),
+				newManifestWorkU(t, "cluster-1", "work-2", &deletionTimestamp),
+				newManifestWorkU(t, "cluster-1", "work-3", nil),
+				newManifestWorkU(t, "cluster-2", "work-1", nil),
+				newManifestWorkU(t, "cluster-3", "work-1", &deletionTimestamp),
+			},
+			MetricNames: []string{"acm_managed_cluster_manifestwork_deleting_count"},
+			Want: `acm_managed_cluster_manifestwork_deleting_count{hub_cluster_id="mycluster_id",managed_cluster_id="cluster_id_1"} 2
+acm_managed_cluster_manifestwork_deleting_count{hub_cluster_id="mycluster_id",managed_cluster_id="cluster_id_2"} 0`,
+		},
+		{
+			Obj:         []interface{}{newManifestWorkU(t, "cluster-1", "work-1", &deletionTimestamp)},
+			MetricNames: []string{"acm_managed_cluster_manifestwork_deleting_count"},
+			Want:        "",
+		},
+	}
+	for i, c := range tests {
+		c.Func = withFleet(metric.ComposeMetricGenFuncs(getManifestWorkMetricFamilies("mycluster_id", defaultClusterSettings)))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
+
+func Test_createManifestWorkListWatchWithClient(t *testing.T) {
+	mw := newManifestWorkU(t, "cluster-1", "work-1", nil)
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{workGVR: "ManifestWorkList"}, mw)
+	lw := createManifestWorkListWatchWithClient(client, "cluster-1", defaultRequestTimeout)
+	l, err := lw.ListFunc(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := l.(*unstructured.UnstructuredList).Items
+	if len(items) != 1 {
+		t.Fatalf("expected a list of 1 element got %d", len(items))
+	}
+	if _, ok := items[0].Object["spec"]; ok {
+		t.Errorf("expected the spec of the ManifestWork to be stripped")
+	}
+	w, err := lw.WatchFunc(metav1.ListOptions{})
+	if err != nil {
+		t.Error(err)
+	}
+	if w == nil {
+		t.Errorf("expected the watch to be not nil")
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// mergedWriter writes the metrics of the collectors of the same kind of
+// several hubs. Each collector writes the headers of its families, and the
+// exposition format doesn't allow a family to be repeated, so the series of
+// the collectors are written under a single header per family.
+type mergedWriter struct {
+	writers []MetricsWriter
+}
+
+// newMergedWriter returns the writer merging the families of the writers, the
+// only writer of a single hub is returned as is.
+func newMergedWriter(writers []MetricsWriter) MetricsWriter {
+	if len(writers) == 1 {
+		return writers[0]
+	}
+	return &mergedWriter{writers: writers}
+}
+
+// WriteAll writes the families in the order they are first written by the
+// writers, each with the header of the first writer and the series of all
+// the writers.
+func (m *mergedWriter) WriteAll(w io.Writer) {
+	names := []string{}
+	headers := map[string]*bytes.Buffer{}
+	series := map[string]*bytes.Buffer{}
+	for _, writer := range m.writers {
+		buf := new(bytes.Buffer)
+		writer.WriteAll(buf)
+		name := ""
+		headerWritten := map[string]bool{}
+		for _, line := range strings.Split(buf.String(), "\n") {
+			if line == "" {
+				continue
+			}
+			if strings.HasPrefix(line, "# HELP ") || strings.HasPrefix(line, "# TYPE ") {
+				if fields := strings.Fields(line); len(fields) > 2 {
+					name = fields[2]
+				}
+				if _, ok := headers[name]; !ok {
+					names = append(names, name)
+					headers[name] = new(bytes.Buffer)
+					series[name] = new(bytes.Buffer)
+					headerWritten[name] = true
+				}
+				if headerWritten[name] {
+					headers[name].WriteString(line + "\n")
+				}
+				continue
+			}
+			if _, ok := series[name]; !ok {
+				names = append(names, name)
+				headers[name] = new(bytes.Buffer)
+				series[name] = new(bytes.Buffer)
+			}
+			series[name].WriteString(line + "\n")
+		}
+	}
+	for _, name := range names {
+		w.Write(headers[name].Bytes())
+		w.Write(series[name].Bytes())
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"bytes"
+	"testing"
+
+	mcv1 "github.com/open-cluster-management/api/cluster/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+func Test_mergedWriter_WriteAll(t *testing.T) {
+	newStore := func(hubClusterID string, clusters ...string) MetricsWriter {
+		families := getFleetMetricFamilies(hubClusterID, defaultClusterSettings)[:2]
+		store := newRollupStore(metric.ExtractMetricFamilyHeaders(families),
+			withFleet(metric.ComposeMetricGenFuncs(families)))
+		objs := []interface{}{}
+		for _, name := range clusters {
+			objs = append(objs, newManagedClusterU(t, &mcv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+			}))
+		}
+		if err := store.source().Replace(objs, ""); err != nil {
+			t.Fatal(err)
+		}
+		return store
+	}
+
+	writer := newMergedWriter([]MetricsWriter{newStore("hub_1", "cluster-1"), newStore("hub_2", "cluster-1", "cluster-2")})
+	buf := new(bytes.Buffer)
+	writer.WriteAll(buf)
+	want := `# HELP acm_fleet_total_clusters Number of managed clusters
+# TYPE acm_fleet_total_clusters gauge
+acm_fleet_total_clusters{hub_cluster_id="hub_1"} 1
+acm_fleet_total_clusters{hub_cluster_id="hub_2"} 2
+# HELP acm_fleet_available_clusters Number of available managed clusters
+# TYPE acm_fleet_available_clusters gauge
+acm_fleet_available_clusters{hub_cluster_id="hub_1"} 0
+acm_fleet_available_clusters{hub_cluster_id="hub_2"} 0
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteAll() =\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metricsstore "k8s.io/kube-state-metrics/pkg/metrics_store"
+
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+)
+
+// nodeSummary aggregates the nodes reported in the ManagedClusterInfo status.
+type nodeSummary struct {
+	// capacity is the summed capacity of all nodes
+	capacity mciv1beta1.ResourceList
+	// workerCapacity is the summed capacity of the worker nodes
+	workerCapacity mciv1beta1.ResourceList
+	// controlPlaneCapacity is the summed capacity of the control plane nodes
+	controlPlaneCapacity mciv1beta1.ResourceList
+	// spotWorkers is the number of spot or preemptible nodes
+	spotWorkers int
+	// pressures is the number of nodes under each pressure, by pressure label
+	pressures map[string]int
+	// readyNodes is the number of nodes with the Ready condition true
+	readyNodes int
+	// totalNodes is the number of nodes
+	totalNodes int
+	// workerNodes is the number of nodes with the workerLabel
+	workerNodes int
+	// controlPlaneNodes is the number of nodes with one of the
+	// controlPlaneLabels
+	controlPlaneNodes int
+	// workerCPUByInstanceType is the summed cpu capacity of the worker nodes
+	// by instance type
+	workerCPUByInstanceType map[string]resource.Quantity
+	// instanceTypes is the set of the instance types of all the nodes, the
+	// nodes without the instanceTypeLabel are not accounted
+	instanceTypes map[string]bool
+}
+
+// instanceTypeLabel is the well-known label set by the cloud providers on the
+// nodes with their instance type.
+const instanceTypeLabel = "node.kubernetes.io/instance-type"
+
+// unknownInstanceType is the instance type of the nodes without the
+// instanceTypeLabel, ie: bare metal nodes.
+const unknownInstanceType = "unknown"
+
+// controlPlaneLabels are the role labels of the control plane nodes, the
+// master one is deprecated in favor of the control-plane one but still set
+// by the older clusters.
+var controlPlaneLabels = []string{
+	"node-role.kubernetes.io/master",
+	"node-role.kubernetes.io/control-plane",
+}
+
+// nodePressureConditions maps the node pressure conditions to the value of
+// the pressure label.
+var nodePressureConditions = map[corev1.NodeConditionType]string{
+	corev1.NodeMemoryPressure: "Memory",
+	corev1.NodeDiskPressure:   "Disk",
+	corev1.NodePIDPressure:    "PID",
+}
+
+// spotNodeLabels are the well-known labels set by the cloud providers on the
+// spot or preemptible nodes with their value.
+var spotNodeLabels = map[string]string{
+	"cloud.google.com/gke-preemptible":      "true",
+	"eks.amazonaws.com/capacityType":        "SPOT",
+	"kubernetes.azure.com/scalesetpriority": "spot",
+}
+
+// summarizeNodes walks the nodeList once and aggregates the node capacities.
+func summarizeNodes(nodes []mciv1beta1.NodeStatus) nodeSummary {
+	s := nodeSummary{
+		capacity:             mciv1beta1.ResourceList{},
+		workerCapacity:       mciv1beta1.ResourceList{},
+		controlPlaneCapacity: mciv1beta1.ResourceList{},
+		pressures:            map[string]int{},
+
+		workerCPUByInstanceType: map[string]resource.Quantity{},
+		instanceTypes:           map[string]bool{},
+	}
+	for _, pressure := range nodePressureConditions {
+		s.pressures[pressure] = 0
+	}
+	s.totalNodes = len(nodes)
+	for _, n := range nodes {
+		addResourceList(s.capacity, n.Capacity)
+		if instanceType := n.Labels[instanceTypeLabel]; instanceType != "" {
+			s.instanceTypes[instanceType] = true
+		}
+		if isControlPlaneNode(n) {
+			s.controlPlaneNodes++
+			addResourceList(s.controlPlaneCapacity, n.Capacity)
+		}
+		if _, ok := n.Labels[workerLabel]; ok {
+			s.workerNodes++
+			addResourceList(s.workerCapacity, n.Capacity)
+			instanceType := n.Labels[instanceTypeLabel]
+			if instanceType == "" {
+				instanceType = unknownInstanceType
+			}
+			cpu := s.workerCPUByInstanceType[instanceType]
+			cpu.Add(n.Capacity[mciv1beta1.ResourceCPU])
+			s.workerCPUByInstanceType[instanceType] = cpu
+		}
+		if isSpotNode(n) {
+			s.spotWorkers++
+		}
+		for _, c := range n.Conditions {
+			if c.Type == corev1.NodeReady && c.Status == corev1.ConditionTrue {
+				s.readyNodes++
+			}
+			if pressure, ok := nodePressureConditions[c.Type]; ok && c.Status == corev1.ConditionTrue {
+				s.pressures[pressure]++
+			}
+		}
+	}
+	return s
+}
+
+// nodeSummaryPass shares the nodeSummary of a ManagedClusterInfo between the
+// node based families of a single generation. It is reset by
+// withNodeSummaryPass before each object, a nil pass summarizes the nodeList
+// on each call.
+type nodeSummaryPass struct {
+	mutex   sync.Mutex
+	key     string
+	summary nodeSummary
+	// views refreshes the capacity of the stale ManagedClusterInfos, nil
+	// when the capacity views are disabled
+	views *clusterViews
+}
+
+// summaryOf returns the nodeSummary of the mci, the nodeList is walked only
+// once per resourceVersion of the mci until the next reset.
+func (p *nodeSummaryPass) summaryOf(mci *mciv1beta1.ManagedClusterInfo) nodeSummary {
+	if p == nil {
+		return summarizeNodes(mci.Status.NodeList)
+	}
+	key := mci.Namespace + "/" + mci.Name + "/" + mci.ResourceVersion
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.key != key {
+		p.key = key
+		p.summary = summarizeNodes(p.views.nodeList(mci))
+	}
+	return p.summary
+}
+
+// reset drops the summary of the previous object.
+func (p *nodeSummaryPass) reset() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.key = ""
+	p.summary = nodeSummary{}
+}
+
+// withNodeSummaryPass resets the pass before generating the families of each
+// object, so a summary never outlives the generation of its object.
+func withNodeSummaryPass(p *nodeSummaryPass,
+	generateFunc func(obj interface{}) []metricsstore.FamilyByteSlicer) func(obj interface{}) []metricsstore.FamilyByteSlicer {
+	return func(obj interface{}) []metricsstore.FamilyByteSlicer {
+		p.reset()
+		return generateFunc(obj)
+	}
+}
+
+// isControlPlaneNode returns true when the node carries one of the
+// controlPlaneLabels.
+func isControlPlaneNode(n mciv1beta1.NodeStatus) bool {
+	for _, label := range controlPlaneLabels {
+		if _, ok := n.Labels[label]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isSpotNode returns true when the node carries one of the spotNodeLabels.
+// The managed services don't run their control plane on spot nodes, so a
+// spot node is counted as a worker even without the worker role label.
+func isSpotNode(n mciv1beta1.NodeStatus) bool {
+	for label, value := range spotNodeLabels {
+		if v, ok := n.Labels[label]; ok && v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// addResourceList adds the src quantities to dst. The resource.Quantity
+// arithmetic is used as nodes may report their capacity in different units
+// (ie: Gi and Mi) and summing the int64 values would lose precision.
+func addResourceList(dst, src mciv1beta1.ResourceList) {
+	for name, q := range src {
+		sum := dst[name]
+		sum.Add(q)
+		dst[name] = sum
+	}
+}
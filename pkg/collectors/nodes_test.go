@@ -0,0 +1,244 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"reflect"
+	"testing"
+
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metricsstore "k8s.io/kube-state-metrics/pkg/metrics_store"
+)
+
+func Test_summarizeNodes(t *testing.T) {
+	nodes := []mciv1beta1.NodeStatus{
+		{
+			Name: "master",
+			Labels: map[string]string{
+				"node-role.kubernetes.io/master": "",
+			},
+			Capacity: mciv1beta1.ResourceList{
+				mciv1beta1.ResourceCPU:    resource.MustParse("4"),
+				mciv1beta1.ResourceMemory: resource.MustParse("16Gi"),
+			},
+		},
+		{
+			Name: "worker-1",
+			Labels: map[string]string{
+				workerLabel:       "",
+				instanceTypeLabel: "m5.xlarge",
+			},
+			Capacity: mciv1beta1.ResourceList{
+				mciv1beta1.ResourceCPU:    resource.MustParse("500m"),
+				mciv1beta1.ResourceMemory: resource.MustParse("1Gi"),
+			},
+		},
+		{
+			Name: "worker-2",
+			Labels: map[string]string{
+				workerLabel: "",
+			},
+			Capacity: mciv1beta1.ResourceList{
+				mciv1beta1.ResourceCPU:    resource.MustParse("2"),
+				mciv1beta1.ResourceMemory: resource.MustParse("512Mi"),
+			},
+		},
+	}
+	tests := []struct {
+		name string
+		got  func(nodeSummary) resource.Quantity
+		want resource.Quantity
+	}{
+		{
+			name: "total memory in mixed units",
+			got:  func(s nodeSummary) resource.Quantity { return s.capacity[mciv1beta1.ResourceMemory] },
+			want: resource.MustParse("17920Mi"),
+		},
+		{
+			name: "worker memory in mixed units",
+			got:  func(s nodeSummary) resource.Quantity { return s.workerCapacity[mciv1beta1.ResourceMemory] },
+			want: resource.MustParse("1536Mi"),
+		},
+		{
+			name: "worker cpu with milli cores",
+			got:  func(s nodeSummary) resource.Quantity { return s.workerCapacity[mciv1beta1.ResourceCPU] },
+			want: resource.MustParse("2500m"),
+		},
+		{
+			name: "worker cpu of an instance type",
+			got:  func(s nodeSummary) resource.Quantity { return s.workerCPUByInstanceType["m5.xlarge"] },
+			want: resource.MustParse("500m"),
+		},
+		{
+			name: "worker cpu of an unknown instance type",
+			got:  func(s nodeSummary) resource.Quantity { return s.workerCPUByInstanceType[unknownInstanceType] },
+			want: resource.MustParse("2"),
+		},
+	}
+	s := summarizeNodes(nodes)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.got(s)
+			if got.Cmp(tt.want) != 0 {
+				t.Errorf("summarizeNodes() = %s, want %s", got.String(), tt.want.String())
+			}
+		})
+	}
+}
+
+func Test_summarizeNodes_pressures(t *testing.T) {
+	nodes := []mciv1beta1.NodeStatus{
+		{
+			Name: "node-1",
+			Conditions: []mciv1beta1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+				{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionTrue},
+				{Type: corev1.NodeDiskPressure, Status: corev1.ConditionTrue},
+			},
+		},
+		{
+			Name: "node-2",
+			Conditions: []mciv1beta1.NodeCondition{
+				{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionTrue},
+				{Type: corev1.NodeDiskPressure, Status: corev1.ConditionFalse},
+				{Type: corev1.NodePIDPressure, Status: corev1.ConditionUnknown},
+			},
+		},
+	}
+	want := map[string]int{"Memory": 2, "Disk": 1, "PID": 0}
+	if got := summarizeNodes(nodes).pressures; !reflect.DeepEqual(got, want) {
+		t.Errorf("summarizeNodes().pressures = %v, want %v", got, want)
+	}
+}
+
+func Test_summarizeNodes_readiness(t *testing.T) {
+	nodes := []mciv1beta1.NodeStatus{
+		{
+			Name: "node-1",
+			Conditions: []mciv1beta1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			},
+		},
+		{
+			Name: "node-2",
+			Conditions: []mciv1beta1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionFalse},
+			},
+		},
+		{
+			Name: "node-3",
+			Conditions: []mciv1beta1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionUnknown},
+			},
+		},
+		{
+			Name: "node-4",
+		},
+	}
+	s := summarizeNodes(nodes)
+	if s.readyNodes != 1 {
+		t.Errorf("summarizeNodes().readyNodes = %d, want 1", s.readyNodes)
+	}
+	if s.totalNodes != 4 {
+		t.Errorf("summarizeNodes().totalNodes = %d, want 4", s.totalNodes)
+	}
+}
+
+func Test_summarizeNodes_roles(t *testing.T) {
+	nodes := []mciv1beta1.NodeStatus{
+		{
+			Name:   "master",
+			Labels: map[string]string{"node-role.kubernetes.io/master": ""},
+		},
+		{
+			Name: "control-plane",
+			Labels: map[string]string{
+				"node-role.kubernetes.io/master":        "",
+				"node-role.kubernetes.io/control-plane": "",
+			},
+		},
+		{
+			Name: "control-plane-worker",
+			Labels: map[string]string{
+				"node-role.kubernetes.io/control-plane": "",
+				workerLabel:                             "",
+			},
+		},
+		{
+			Name:   "worker",
+			Labels: map[string]string{workerLabel: ""},
+		},
+		{
+			Name: "no-role",
+		},
+	}
+	s := summarizeNodes(nodes)
+	if s.controlPlaneNodes != 3 {
+		t.Errorf("summarizeNodes().controlPlaneNodes = %d, want 3", s.controlPlaneNodes)
+	}
+	if s.workerNodes != 2 {
+		t.Errorf("summarizeNodes().workerNodes = %d, want 2", s.workerNodes)
+	}
+}
+
+func Test_summarizeNodes_instanceTypes(t *testing.T) {
+	nodes := []mciv1beta1.NodeStatus{
+		{
+			Name:   "master",
+			Labels: map[string]string{instanceTypeLabel: "m5.2xlarge"},
+		},
+		{
+			Name:   "worker-1",
+			Labels: map[string]string{workerLabel: "", instanceTypeLabel: "m5.xlarge"},
+		},
+		{
+			Name:   "worker-2",
+			Labels: map[string]string{workerLabel: "", instanceTypeLabel: "m5.xlarge"},
+		},
+		{
+			Name:   "bare-metal",
+			Labels: map[string]string{workerLabel: ""},
+		},
+	}
+	want := map[string]bool{"m5.2xlarge": true, "m5.xlarge": true}
+	if got := summarizeNodes(nodes).instanceTypes; !reflect.DeepEqual(got, want) {
+		t.Errorf("summarizeNodes().instanceTypes = %v, want %v", got, want)
+	}
+}
+
+func Test_nodeSummaryPass(t *testing.T) {
+	mci := &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "hive", Namespace: "hive", ResourceVersion: "1"},
+		Status: mciv1beta1.ClusterInfoStatus{
+			NodeList: []mciv1beta1.NodeStatus{{Name: "worker-1", Labels: map[string]string{workerLabel: ""}}},
+		},
+	}
+	p := &nodeSummaryPass{}
+	if got := p.summaryOf(mci).workerNodes; got != 1 {
+		t.Errorf("summaryOf().workerNodes = %d, want 1", got)
+	}
+	// The nodeList is not walked again for the same resourceVersion.
+	mci.Status.NodeList = append(mci.Status.NodeList, mciv1beta1.NodeStatus{Name: "worker-2", Labels: map[string]string{workerLabel: ""}})
+	if got := p.summaryOf(mci).workerNodes; got != 1 {
+		t.Errorf("summaryOf().workerNodes = %d, want the memoized 1", got)
+	}
+	generate := withNodeSummaryPass(p, func(obj interface{}) []metricsstore.FamilyByteSlicer {
+		if got := p.summaryOf(obj.(*mciv1beta1.ManagedClusterInfo)).workerNodes; got != 2 {
+			t.Errorf("summaryOf().workerNodes = %d after the reset, want 2", got)
+		}
+		return nil
+	})
+	generate(mci)
+	mci.ResourceVersion = "2"
+	mci.Status.NodeList = mci.Status.NodeList[:1]
+	if got := p.summaryOf(mci).workerNodes; got != 1 {
+		t.Errorf("summaryOf().workerNodes = %d for a new resourceVersion, want 1", got)
+	}
+	var nilPass *nodeSummaryPass
+	if got := nilPass.summaryOf(mci).workerNodes; got != 1 {
+		t.Errorf("nil summaryOf().workerNodes = %d, want 1", got)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+const (
+	// rootPolicyLabel is set by the policy propagator on the policies it
+	// replicates in the cluster namespaces, its value is the namespaced name
+	// of the root policy.
+	rootPolicyLabel = "policy.open-cluster-management.io/root-policy"
+
+	policyNonCompliant = "NonCompliant"
+)
+
+var (
+	descPolicyViolationsName          = "acm_managed_cluster_policy_violations"
+	descPolicyViolationsHelp          = "Number of non compliant templates of the policy on the managed cluster"
+	descPolicyViolationsDefaultLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"policy"}
+
+	policyGVR = schema.GroupVersionResource{
+		Group:    "policy.open-cluster-management.io",
+		Version:  "v1",
+		Resource: "policies",
+	}
+)
+
+// getPolicyMetricFamilies returns the families of the policies replicated
+// in the cluster namespaces, the root policies are not listed.
+func getPolicyMetricFamilies(hubClusterID string, clusters *clusterCache) []metric.FamilyGenerator {
+	return []metric.FamilyGenerator{
+		{
+			Name: descPolicyViolationsName,
+			Type: metric.Gauge,
+			Help: descPolicyViolationsHelp,
+			GenerateFunc: wrapPolicyFunc(func(policy *unstructured.Unstructured) metric.Family {
+				rootPolicy := policy.GetLabels()[rootPolicyLabel]
+				if rootPolicy == "" {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				mci, err := clusters.getManagedClusterInfo(policy.GetNamespace())
+				if err != nil {
+					countCollectorError(mciGVR.Resource, policy.GetNamespace(), err)
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				clusterID := clusters.getClusterID(mci)
+				if clusterID == "" {
+					return metric.Family{Metrics: []*metric.Metric{}}
+				}
+				return metric.Family{Metrics: []*metric.Metric{
+					{
+						LabelKeys:   descPolicyViolationsDefaultLabels,
+						LabelValues: []string{hubClusterID, clusterID, rootPolicy},
+						Value:       float64(getPolicyViolations(policy)),
+					},
+				}}
+			}),
+		},
+	}
+}
+
+// getPolicyViolations returns the number of templates of the policy reported
+// as non compliant in its status details.
+func getPolicyViolations(policy *unstructured.Unstructured) int {
+	details, _, err := unstructured.NestedSlice(policy.Object, "status", "details")
+	if err != nil {
+		klog.Errorf("Error: %v", err)
+		return 0
+	}
+	violations := 0
+	for _, d := range details {
+		detail, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if compliant, _, _ := unstructured.NestedString(detail, "compliant"); compliant == policyNonCompliant {
+			violations++
+		}
+	}
+	return violations
+}
+
+func wrapPolicyFunc(f func(*unstructured.Unstructured) metric.Family) func(interface{}) *metric.Family {
+	return func(obj interface{}) *metric.Family {
+		metricFamily := f(obj.(*unstructured.Unstructured))
+
+		for _, m := range metricFamily.Metrics {
+			m.LabelKeys = append([]string{}, m.LabelKeys...)
+			m.LabelValues = append([]string{}, m.LabelValues...)
+		}
+
+		return &metricFamily
+	}
+}
+
+func createPolicyListWatchWithClient(client dynamic.Interface, ns string, timeout time.Duration) cache.ListWatch {
+	return cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			opts.LabelSelector = rootPolicyLabel
+			ctx, cancel := requestContext(policyGVR.Resource, timeout)
+			defer cancel()
+			return client.Resource(policyGVR).Namespace(ns).List(ctx, opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			opts.LabelSelector = rootPolicyLabel
+			return client.Resource(policyGVR).Namespace(ns).Watch(context.TODO(), opts)
+		},
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"fmt"
+	"testing"
+
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+func newPolicyU(ns, name string, labels map[string]string, compliances ...string) *unstructured.Unstructured {
+	details := []interface{}{}
+	for i, c := range compliances {
+		details = append(details, map[string]interface{}{
+			"compliant": c,
+			"templateMeta": map[string]interface{}{
+				"name": fmt.Sprintf("%s-template-%d", name, i),
+			},
+		})
+	}
+	policy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "policy.open-cluster-management.io/v1",
+		"kind":       "Policy",
+		"status": map[string]interface{}{
+			"details": details,
+		},
+	}}
+	policy.SetNamespace(ns)
+	policy.SetName(name)
+	policy.SetLabels(labels)
+	return policy
+}
+
+func Test_getPolicyMetricFamilies(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(mciv1beta1.GroupVersion, &mciv1beta1.ManagedClusterInfo{})
+
+	mci := &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-1",
+			Namespace: "cluster-1",
+		},
+		Status: mciv1beta1.ClusterInfoStatus{
+			ClusterID: "managed_cluster_id",
+		},
+	}
+	replicated := map[string]string{rootPolicyLabel: "policies.policy-pod"}
+
+	policyViolated := newPolicyU("cluster-1", "policies.policy-pod", replicated, "NonCompliant", "Compliant", "NonCompliant")
+	policyCompliant := newPolicyU("cluster-1", "policies.policy-pod", replicated, "Compliant")
+	policyNoStatus := newPolicyU("cluster-1", "policies.policy-pod", replicated)
+	policyRoot := newPolicyU("policies", "policy-pod", nil, "NonCompliant")
+	policyNoCluster := newPolicyU("cluster-2", "policies.policy-pod", replicated, "NonCompliant")
+
+	clusters := newTestClusterCache(t, mci)
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         policyViolated,
+			MetricNames: []string{"acm_managed_cluster_policy_violations"},
+			Want:        `acm_managed_cluster_policy_violations{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id",policy="policies.policy-pod"} 2`,
+		},
+		{
+			Obj:         policyCompliant,
+			MetricNames: []string{"acm_managed_cluster_policy_violations"},
+			Want:        `acm_managed_cluster_policy_violations{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id",policy="policies.policy-pod"} 0`,
+		},
+		{
+			Obj:         policyNoStatus,
+			MetricNames: []string{"acm_managed_cluster_policy_violations"},
+			Want:        `acm_managed_cluster_policy_violations{hub_cluster_id="mycluster_id",managed_cluster_id="managed_cluster_id",policy="policies.policy-pod"} 0`,
+		},
+		{
+			Obj:         policyRoot,
+			MetricNames: []string{"acm_managed_cluster_policy_violations"},
+			Want:        "",
+		},
+		{
+			Obj:         policyNoCluster,
+			MetricNames: []string{"acm_managed_cluster_policy_violations"},
+			Want:        "",
+		},
+	}
+	for i, c := range tests {
+		c.Func = metric.ComposeMetricGenFuncs(getPolicyMetricFamilies("mycluster_id", clusters))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
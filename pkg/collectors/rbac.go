@@ -0,0 +1,69 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// deniedResources are the resources the exporter is not allowed to list,
+// each one is logged once.
+var deniedResources sync.Map
+
+// emptySyncer is implemented by the stores which are synced empty when their
+// reflector is not allowed to list its resource, so the collectors waiting for
+// them still expose the other resources.
+type emptySyncer interface {
+	syncEmpty()
+}
+
+// withForbidden calls onForbidden when the list of lw is forbidden, after
+// reporting the resource by acm_state_metrics_rbac_denied.
+func withForbidden(lw cache.ListWatch, resource string, onForbidden func()) cache.ListWatch {
+	listFunc := lw.ListFunc
+	lw.ListFunc = func(opts metav1.ListOptions) (runtime.Object, error) {
+		obj, err := listFunc(opts)
+		if errors.IsForbidden(err) {
+			if _, logged := deniedResources.LoadOrStore(resource, true); !logged {
+				klog.Errorf("Not allowed to list %s, its metrics are not collected: %v", resource, err)
+			}
+			RBACDeniedMetric.WithLabelValues(resource).Set(1)
+			if onForbidden != nil {
+				onForbidden()
+			}
+		}
+		return obj, err
+	}
+	return lw
+}
+
+// runReflector runs a reflector of lw feeding the store until the context is
+// done. The reflector is stopped when the list of its resource is forbidden
+// instead of retrying it, the store is synced empty when it is an
+// emptySyncer.
+func runReflector(
+	ctx context.Context,
+	lw cache.ListWatch,
+	expectedType interface{},
+	store cache.Store,
+	resource string,
+	pageSize int64,
+) {
+	ctx, cancel := context.WithCancel(ctx)
+	lw = withForbidden(lw, resource, func() {
+		if s, ok := store.(emptySyncer); ok {
+			s.syncEmpty()
+		}
+		cancel()
+	})
+	reflector := cache.NewReflector(&lw, expectedType, newCountingStore(store, resource), 0)
+	reflector.WatchListPageSize = pageSize
+	go reflector.Run(ctx.Done())
+}
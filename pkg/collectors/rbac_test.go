@@ -0,0 +1,46 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func Test_runReflector_forbidden(t *testing.T) {
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{mcaGVR: "ManagedClusterAddOnList"})
+	client.PrependReactor("list", mcaGVR.Resource, func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewForbidden(mcaGVR.GroupResource(), "", nil)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := newRollupStore(nil, nil)
+	runReflector(ctx, createManagedClusterAddOnListWatchWithClient(client, metav1.NamespaceAll, defaultRequestTimeout),
+		&unstructured.Unstructured{}, s.source(), "forbidden-managedclusteraddons", 0)
+
+	err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return s.hasSynced(), nil
+	})
+	if err != nil {
+		t.Fatal("the source of the forbidden reflector was not synced")
+	}
+	if got := testutil.ToFloat64(RBACDeniedMetric.WithLabelValues("forbidden-managedclusteraddons")); got != 1 {
+		t.Errorf("rbac denied = %v, want 1", got)
+	}
+	if got := len(s.List()); got != 0 {
+		t.Errorf("cached objects = %d, want 0", got)
+	}
+}
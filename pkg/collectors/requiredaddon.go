@@ -0,0 +1,55 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+var (
+	descClusterMissingRequiredAddOnName   = "acm_managed_cluster_missing_required_addon"
+	descClusterMissingRequiredAddOnHelp   = "Required addon not installed on the managed cluster, 1 per missing addon"
+	descClusterMissingRequiredAddOnLabels = []string{"hub_cluster_id",
+		"managed_cluster_id",
+		"addon"}
+)
+
+// getRequiredAddOnMetricFamilies returns the families checking the
+// requiredAddOns have a ManagedClusterAddOn in the namespace of each cluster.
+// They are computed by the fleet collector which reflects the addons.
+func getRequiredAddOnMetricFamilies(hubClusterID string, settings clusterSettings, requiredAddOns []string) []metric.FamilyGenerator {
+	return []metric.FamilyGenerator{
+		{
+			Name: descClusterMissingRequiredAddOnName,
+			Type: metric.Gauge,
+			Help: descClusterMissingRequiredAddOnHelp,
+			GenerateFunc: wrapFleetFunc(func(f *fleet) metric.Family {
+				installed := map[string]map[string]bool{}
+				for _, mca := range f.managedClusterAddOns {
+					if installed[mca.GetNamespace()] == nil {
+						installed[mca.GetNamespace()] = map[string]bool{}
+					}
+					installed[mca.GetNamespace()][mca.GetName()] = true
+				}
+				family := metric.Family{Metrics: []*metric.Metric{}}
+				for _, mci := range f.managedClusterInfos {
+					clusterID := settings.getClusterID(mci)
+					if clusterID == "" {
+						continue
+					}
+					for _, addon := range requiredAddOns {
+						if installed[settings.clusterNameFor(mci)][addon] {
+							continue
+						}
+						family.Metrics = append(family.Metrics, &metric.Metric{
+							LabelKeys:   descClusterMissingRequiredAddOnLabels,
+							LabelValues: []string{hubClusterID, clusterID, addon},
+							Value:       1,
+						})
+					}
+				}
+				return family
+			}),
+		},
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"testing"
+
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+func Test_getRequiredAddOnMetricFamilies(t *testing.T) {
+	mci1 := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Namespace: "cluster-1"},
+		Status:     mciv1beta1.ClusterInfoStatus{ClusterID: "cluster_id_1"},
+	})
+	mci2 := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-2", Namespace: "cluster-2"},
+		Status:     mciv1beta1.ClusterInfoStatus{ClusterID: "cluster_id_2"},
+	})
+	workManager1 := newAddOnWithConditionU(t, "cluster-1", "work-manager", nil)
+	appManager1 := newAddOnWithConditionU(t, "cluster-1", "application-manager", nil)
+	workManager2 := newAddOnWithConditionU(t, "cluster-2", "work-manager", nil)
+
+	tests := []generateMetricsTestCase{
+		{
+			Obj:         []interface{}{mci1, mci2, workManager1, appManager1, workManager2},
+			MetricNames: []string{"acm_managed_cluster_missing_required_addon"},
+			Want:        `acm_managed_cluster_missing_required_addon{hub_cluster_id="mycluster_id",managed_cluster_id="cluster_id_2",addon="application-manager"} 1`,
+		},
+		{
+			Obj:         []interface{}{mci1, workManager1, appManager1},
+			MetricNames: []string{"acm_managed_cluster_missing_required_addon"},
+			Want:        "",
+		},
+		{
+			Obj:         []interface{}{mci2},
+			MetricNames: []string{"acm_managed_cluster_missing_required_addon"},
+			Want: `acm_managed_cluster_missing_required_addon{hub_cluster_id="mycluster_id",managed_cluster_id="cluster_id_2",addon="application-manager"} 1
+acm_managed_cluster_missing_required_addon{hub_cluster_id="mycluster_id",managed_cluster_id="cluster_id_2",addon="work-manager"} 1`,
+		},
+	}
+	for i, c := range tests {
+		c.Func = withFleet(metric.ComposeMetricGenFuncs(getRequiredAddOnMetricFamilies("mycluster_id", defaultClusterSettings, []string{"application-manager", "work-manager"})))
+		if err := c.run(); err != nil {
+			t.Errorf("unexpected collecting result in %v run:\n%s", i, err)
+		}
+	}
+}
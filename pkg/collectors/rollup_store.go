@@ -0,0 +1,178 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	metricsstore "k8s.io/kube-state-metrics/pkg/metrics_store"
+)
+
+// rollupStore keeps the reflected objects in a cache.Store per reflector.
+// Unlike the MetricsStore which generates the metrics of an object when it
+// is added, the metrics are generated from all the stored objects on
+// WriteAll. This allows to expose fleet wide rollups.
+type rollupStore struct {
+	mutex   sync.RWMutex
+	sources []rollupSource
+	headers []string
+
+	// generateMetricsFunc generates the metric families from the list of
+	// all the stored objects.
+	generateMetricsFunc func(interface{}) []metricsstore.FamilyByteSlicer
+}
+
+// rollupSource is a source of the objects of the rollups.
+type rollupSource interface {
+	List() []interface{}
+	hasSynced() bool
+}
+
+// informerSource is the source of the objects of a shared informer, ie: of
+// the cluster cache, so the objects already cached are not reflected again.
+type informerSource struct {
+	informer cache.SharedIndexInformer
+}
+
+func (s informerSource) List() []interface{} {
+	return s.informer.GetStore().List()
+}
+
+func (s informerSource) hasSynced() bool {
+	return s.informer.HasSynced()
+}
+
+// sourceStore is the store of a single reflector. A reflector replaces the
+// whole content of its store on each list, so the reflectors can't share a
+// store. The store is synced once the reflector completed its first list.
+type sourceStore struct {
+	cache.Store
+
+	mutex  sync.RWMutex
+	synced bool
+}
+
+func newRollupStore(headers []string, generateFunc func(interface{}) []metricsstore.FamilyByteSlicer) *rollupStore {
+	return &rollupStore{
+		headers:             headers,
+		generateMetricsFunc: generateFunc,
+	}
+}
+
+// source returns a new store to be registered with a reflector.
+func (s *rollupStore) source() *sourceStore {
+	src := &sourceStore{Store: cache.NewStore(cache.MetaNamespaceKeyFunc)}
+	s.mutex.Lock()
+	s.sources = append(s.sources, src)
+	s.mutex.Unlock()
+	return src
+}
+
+// addInformers adds the objects of the informers to the sources.
+func (s *rollupStore) addInformers(informers ...cache.SharedIndexInformer) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, informer := range informers {
+		s.sources = append(s.sources, informerSource{informer: informer})
+	}
+}
+
+// Replace implements cache.Store, the reflector calls it on each list.
+func (s *sourceStore) Replace(list []interface{}, resourceVersion string) error {
+	if err := s.Store.Replace(list, resourceVersion); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	s.synced = true
+	s.mutex.Unlock()
+	return nil
+}
+
+func (s *sourceStore) hasSynced() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.synced
+}
+
+// syncEmpty implements emptySyncer, the source of a reflector not allowed to
+// list its resource stays empty.
+func (s *sourceStore) syncEmpty() {
+	s.mutex.Lock()
+	s.synced = true
+	s.mutex.Unlock()
+}
+
+// hasSynced returns true when all the reflectors completed their first list.
+func (s *rollupStore) hasSynced() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for _, src := range s.sources {
+		if !src.hasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// List returns the objects of all the sources.
+func (s *rollupStore) List() []interface{} {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	objs := []interface{}{}
+	for _, src := range s.sources {
+		objs = append(objs, src.List()...)
+	}
+	return objs
+}
+
+// WriteAll writes all metrics of the store into the given writer, together with
+// their help text. Nothing is written until all the reflectors synced, so the
+// rollups are not computed from a partial list of objects on startup.
+func (s *rollupStore) WriteAll(w io.Writer) {
+	if !s.hasSynced() {
+		klog.Infof("Waiting for the caches to sync before generating %d families", len(s.headers))
+		return
+	}
+	families := s.generateMetricsFunc(s.List())
+	for i, help := range s.headers {
+		w.Write([]byte(help))
+		w.Write([]byte{'\n'})
+		w.Write(families[i].ByteSlice())
+	}
+}
+
+// reflectPerNamespace registers a reflector per namespace, each with its own
+// source.
+func (s *rollupStore) reflectPerNamespace(
+	ctx context.Context,
+	expectedType interface{},
+	config *rest.Config,
+	namespaces []string,
+	listWatchFunc func(config *rest.Config, ns string, timeout time.Duration) cache.ListWatch,
+	resource string,
+	pageSize int64,
+	timeout time.Duration,
+) {
+	for _, ns := range namespaces {
+		reflectorPerNamespace(ctx, expectedType, s.source(), config, []string{ns}, listWatchFunc, resource, pageSize, timeout)
+	}
+}
+
+// reflectClusterScoped registers a cluster scoped reflector with its own source.
+func (s *rollupStore) reflectClusterScoped(
+	ctx context.Context,
+	expectedType interface{},
+	config *rest.Config,
+	listWatchFunc func(config *rest.Config, timeout time.Duration) cache.ListWatch,
+	resource string,
+	pageSize int64,
+	timeout time.Duration,
+) {
+	reflectorClusterScoped(ctx, expectedType, s.source(), config, listWatchFunc, resource, pageSize, timeout)
+}
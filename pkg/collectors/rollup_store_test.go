@@ -0,0 +1,119 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package collectors
+
+import (
+	"bytes"
+	"testing"
+
+	mcv1 "github.com/open-cluster-management/api/cluster/v1"
+	mciv1beta1 "github.com/open-cluster-management/multicloud-operators-foundation/pkg/apis/internal.open-cluster-management.io/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kube-state-metrics/pkg/metric"
+)
+
+func Test_rollupStore_WriteAll(t *testing.T) {
+	families := getFleetMetricFamilies("mycluster_id", defaultClusterSettings)[:1]
+	store := newRollupStore(metric.ExtractMetricFamilyHeaders(families),
+		withFleet(metric.ComposeMetricGenFuncs(families)))
+
+	src := store.source()
+
+	buf := new(bytes.Buffer)
+	store.WriteAll(buf)
+	if buf.String() != "" {
+		t.Errorf("Expected nothing before the sync got\n%s", buf.String())
+	}
+
+	mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster",
+		},
+	})
+	if err := src.Replace([]interface{}{mc}, ""); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP acm_fleet_total_clusters Number of managed clusters
+# TYPE acm_fleet_total_clusters gauge
+acm_fleet_total_clusters{hub_cluster_id="mycluster_id"} 1
+`
+	buf.Reset()
+	store.WriteAll(buf)
+	if buf.String() != want {
+		t.Errorf("Expected \n%s\ngot\n%s", want, buf.String())
+	}
+
+	if err := src.Delete(mc); err != nil {
+		t.Fatal(err)
+	}
+	want = `# HELP acm_fleet_total_clusters Number of managed clusters
+# TYPE acm_fleet_total_clusters gauge
+acm_fleet_total_clusters{hub_cluster_id="mycluster_id"} 0
+`
+	buf.Reset()
+	store.WriteAll(buf)
+	if buf.String() != want {
+		t.Errorf("Expected \n%s\ngot\n%s", want, buf.String())
+	}
+}
+
+func Test_rollupStore_sources(t *testing.T) {
+	store := newRollupStore([]string{}, nil)
+	mciSrc := store.source()
+	mcaSrc := store.source()
+
+	mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster",
+			Namespace: "cluster",
+		},
+	})
+	mca := newAddOnWithConditionU(t, "cluster", "cluster", nil)
+	if err := mciSrc.Replace([]interface{}{mci}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if store.hasSynced() {
+		t.Errorf("expected the store not to be synced before all the sources")
+	}
+	if err := mcaSrc.Replace([]interface{}{mca}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !store.hasSynced() {
+		t.Errorf("expected the store to be synced")
+	}
+	// A relist of a source doesn't remove the objects of the other sources
+	if err := mcaSrc.Replace([]interface{}{}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(store.List()); n != 1 {
+		t.Errorf("expected 1 object, got %d", n)
+	}
+}
+
+func Test_rollupStore_addInformers(t *testing.T) {
+	mci := newManagedClusterInfoU(t, &mciv1beta1.ManagedClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "cluster"},
+	})
+	mc := newManagedClusterU(t, &mcv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+	})
+	clusters := newTestClusterCache(t, mci, mc)
+	store := newRollupStore([]string{}, nil)
+	store.addInformers(clusters.clusterInformers()...)
+	mcaSrc := store.source()
+
+	if store.hasSynced() {
+		t.Errorf("expected the store not to be synced before all the sources")
+	}
+	mca := newAddOnWithConditionU(t, "cluster", "cluster", nil)
+	if err := mcaSrc.Replace([]interface{}{mca}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !store.hasSynced() {
+		t.Errorf("expected the store to be synced")
+	}
+	// The objects of the cluster cache are listed with the reflected ones
+	if n := len(store.List()); n != 3 {
+		t.Errorf("expected 3 objects, got %d", n)
+	}
+}
@@ -4,11 +4,20 @@
 package collectors
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"sort"
 	"strings"
-
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
 	metricsstore "k8s.io/kube-state-metrics/pkg/metrics_store"
 )
 
@@ -100,7 +109,7 @@ func filterMetrics(ms []string, names []string) []string {
 
 	regexps := []*regexp.Regexp{}
 	for _, n := range names {
-		regexps = append(regexps, regexp.MustCompile(fmt.Sprintf("^%v", n)))
+		regexps = append(regexps, regexp.MustCompile(fmt.Sprintf("^%v[{ ]", regexp.QuoteMeta(n))))
 	}
 
 	for _, m := range ms {
@@ -135,3 +144,36 @@ func removeUnusedWhitespace(s string) string {
 
 	return strings.Join(trimmedLines, "\n")
 }
+
+// newTestClusterCache returns a synced cache of the ManagedClusterInfos and
+// the ManagedClusters of objs, the typed objects are converted with the
+// client-go scheme.
+func newTestClusterCache(t *testing.T, objs ...runtime.Object) *clusterCache {
+	return newTestClusterCacheWithSettings(t, defaultClusterSettings, objs...)
+}
+
+// newTestClusterCacheWithSettings returns a synced cluster cache of the
+// objects with the settings.
+func newTestClusterCacheWithSettings(t *testing.T, settings clusterSettings, objs ...runtime.Object) *clusterCache {
+	unstructuredObjs := []runtime.Object{}
+	for _, obj := range objs {
+		u := &unstructured.Unstructured{}
+		if err := scheme.Scheme.Convert(obj, u, nil); err != nil {
+			t.Fatal(err)
+		}
+		unstructuredObjs = append(unstructuredObjs, u)
+	}
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			mciGVR: "ManagedClusterInfoList",
+			mcGVR:  "ManagedClusterList",
+		}, unstructuredObjs...)
+	clusters := newClusterCache(client, []string{metav1.NamespaceAll}, nil, 0, defaultRequestTimeout, settings)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	clusters.run(ctx)
+	if !cache.WaitForCacheSync(ctx.Done(), clusters.hasSynced) {
+		t.Fatal("the cluster cache didn't sync")
+	}
+	return clusters
+}
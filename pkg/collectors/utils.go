@@ -1,15 +1,19 @@
 // Copyright (c) 2020 Red Hat, Inc.
 // Copyright Contributors to the Open Cluster Management project
 
-
 package collectors
 
 import (
+	"fmt"
+	"time"
+
 	ocinfrav1 "github.com/openshift/api/config/v1"
 	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/net/context"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/klog/v2"
 )
@@ -30,18 +34,152 @@ var (
 		},
 		[]string{"resource"},
 	)
+
+	LastCollectionTimestampMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "acm_state_metrics_last_collection_timestamp_seconds",
+			Help: "Timestamp of the last generation pass completed by a collector",
+		},
+		[]string{"collector"},
+	)
+
+	CacheSizeMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "acm_state_metrics_cache_size",
+			Help: "Number of objects cached by the reflectors of the collectors by resource",
+		},
+		[]string{"resource"},
+	)
+
+	CollectorGenerateDurationMetric = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "acm_state_metrics_collector_generate_duration_seconds",
+			Help:    "Duration of the generation of the metrics of an object, or of a rollup, by a collector",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 4, 8),
+		},
+		[]string{"collector"},
+	)
+
+	APIVersionMismatchMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "acm_state_metrics_api_version_mismatch",
+			Help: "Number of resources served by the hub in a version other than the compiled-in one",
+		},
+		[]string{"resource"},
+	)
+
+	RBACDeniedMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "acm_state_metrics_rbac_denied",
+			Help: "Set to 1 for the resources the exporter is not allowed to list",
+		},
+		[]string{"resource"},
+	)
+
+	CollectorErrorsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "acm_state_metrics_collector_errors_total",
+			Help: "Number of failed lookups of the resources of a cluster while generating its metrics",
+		},
+		[]string{"resource", "cluster"},
+	)
+
+	DuplicateManagedClusterInfoMetric = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "acm_duplicate_managed_cluster_info_total",
+			Help: "Number of ManagedClusterInfo ignored as located outside the namespace of their cluster",
+		},
+	)
+
+	DistributionMismatchMetric = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "acm_state_metrics_distribution_mismatch_total",
+			Help: "Number of ManagedClusterInfo whose distribution info doesn't match their kube vendor",
+		},
+	)
+
+	APIRequestsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "acm_state_metrics_api_requests_total",
+			Help: "Number of requests sent to the hub apiserver by resource and verb",
+		},
+		[]string{"resource", "verb"},
+	)
+
+	BuildInfoMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "acm_state_metrics_build_info",
+			Help: "Build information of the exporter, set to 1",
+		},
+		[]string{"version", "git_commit", "go_version"},
+	)
 )
 
-func getHubClusterID(c dynamic.Interface) string {
+// namespaceGVR is the resource of the namespaces, the hubs without
+// ClusterVersion are identified by their kube-system namespace.
+var namespaceGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
 
-	cvObj, errCv := c.Resource(cvGVR).Get(context.TODO(), "version", metav1.GetOptions{})
+// now is the clock of the collectors, it is replaced in the tests.
+var now = time.Now
+
+// defaultRequestTimeout bounds the lists and the gets sent to the apiserver
+// unless overridden by the builder.
+const defaultRequestTimeout = 10 * time.Second
+
+// requestContext returns the context of a list or a get of the resource,
+// cancelled after the timeout. The requests timing out are logged by the
+// returned cancel function. The watches are long running requests ended by
+// the reflectors, they are not bounded by the timeout.
+func requestContext(resource string, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	return ctx, func() {
+		if ctx.Err() == context.DeadlineExceeded {
+			klog.Warningf("The request of the %s timed out after %v", resource, timeout)
+		}
+		cancel()
+	}
+}
+
+// countCollectorError logs the failed lookup of the resource of the cluster
+// and counts it by acm_state_metrics_collector_errors_total.
+func countCollectorError(resource string, cluster string, err error) {
+	klog.Errorf("Error getting the %s of %s: %v", resource, cluster, err)
+	CollectorErrorsMetric.WithLabelValues(resource, cluster).Inc()
+}
+
+func getHubClusterID(c dynamic.Interface, timeout time.Duration) string {
+	clusterID, err := getHubClusterIDE(c, timeout)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	return clusterID
+}
+
+// getHubClusterIDE returns the cluster id of the hub, the spec.clusterID of
+// the version ClusterVersion of an OpenShift hub. A hub without
+// ClusterVersion is identified by the uid of its kube-system namespace. The
+// requests are bounded by the timeout.
+func getHubClusterIDE(c dynamic.Interface, timeout time.Duration) (string, error) {
+
+	ctx, cancel := requestContext(cvGVR.Resource, timeout)
+	defer cancel()
+	cvObj, errCv := c.Resource(cvGVR).Get(ctx, "version", metav1.GetOptions{})
+	if errors.IsNotFound(errCv) {
+		ctx, cancel := requestContext(namespaceGVR.Resource, timeout)
+		defer cancel()
+		ns, err := c.Resource(namespaceGVR).Get(ctx, metav1.NamespaceSystem, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("error getting the %s namespace of a hub without cluster version: %v", metav1.NamespaceSystem, err)
+		}
+		return string(ns.GetUID()), nil
+	}
 	if errCv != nil {
-		klog.Fatalf("Error getting cluster version %v \n", errCv)
+		return "", fmt.Errorf("error getting cluster version: %v", errCv)
 	}
 	cv := &ocinfrav1.ClusterVersion{}
 	err := runtime.DefaultUnstructuredConverter.FromUnstructured(cvObj.UnstructuredContent(), &cv)
 	if err != nil {
-		klog.Fatalf("Error unmarshal cluster version object%v \n", err)
+		return "", fmt.Errorf("error unmarshal cluster version object: %v", err)
 	}
-	return string(cv.Spec.ClusterID)
+	return string(cv.Spec.ClusterID), nil
 }
@@ -5,13 +5,18 @@
 package collectors
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	ocinfrav1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 )
 
 func Test_getHubClusterID(t *testing.T) {
@@ -28,6 +33,13 @@ func Test_getHubClusterID(t *testing.T) {
 	}
 
 	client := fake.NewSimpleDynamicClient(s, version)
+	kubeSystem := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: metav1.NamespaceSystem,
+			UID:  "kube_system_uid",
+		},
+	}
+	clientWithoutClusterVersion := fake.NewSimpleDynamicClient(s, kubeSystem)
 	type args struct {
 		c dynamic.Interface
 	}
@@ -43,12 +55,36 @@ func Test_getHubClusterID(t *testing.T) {
 			},
 			want: "mycluster_id",
 		},
+		{
+			name: "Get kube-system uid without cluster version",
+			args: args{
+				c: clientWithoutClusterVersion,
+			},
+			want: "kube_system_uid",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getHubClusterID(tt.args.c); got != tt.want {
+			if got := getHubClusterID(tt.args.c, defaultRequestTimeout); got != tt.want {
 				t.Errorf("getHubClusterID() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_requestContext_expired(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Second)
+	}))
+	defer server.Close()
+	client := dynamic.NewForConfigOrDie(&rest.Config{Host: server.URL})
+
+	// The context of the requests is expired before they are sent.
+	if _, err := getHubClusterIDE(client, -1); err == nil {
+		t.Error("expected the get of the hub cluster id to fail")
+	}
+	lw := createManagedClusterInfoListWatchWithClient(client, metav1.NamespaceAll, nil, -1)
+	if _, err := lw.List(metav1.ListOptions{}); err == nil {
+		t.Error("expected the list of the managedclusterinfos to fail")
+	}
+}
@@ -13,6 +13,13 @@ func init() {
 	//TODO this is because the CollectorSet struct is validate the collectors from the commandline using
 	//"DefaultCollectors". https://github.com/kubernetes/kube-state-metrics/blob/master/pkg/options/types.go#L80
 	koptions.DefaultCollectors["managedclusterinfos"] = struct{}{}
+	koptions.DefaultCollectors["managedclusteraddons"] = struct{}{}
+	koptions.DefaultCollectors["fleet"] = struct{}{}
+	koptions.DefaultCollectors["managedclusterleases"] = struct{}{}
+	koptions.DefaultCollectors["managedclustersets"] = struct{}{}
+	koptions.DefaultCollectors["policies"] = struct{}{}
+	koptions.DefaultCollectors["managedserviceaccounts"] = struct{}{}
+	koptions.DefaultCollectors["manifestworks"] = struct{}{}
 }
 
 var (
@@ -0,0 +1,54 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package options
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var labelNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// LabelSet is a set of constant labels provided as a comma-separated list
+// of name=value pairs.
+type LabelSet map[string]string
+
+func (l *LabelSet) String() string {
+	s := []string{}
+	for name, value := range *l {
+		s = append(s, name+"="+value)
+	}
+	sort.Strings(s)
+	return strings.Join(s, ",")
+}
+
+// Set parses and validates the labels, the label names must be valid
+// prometheus label names and must not use the reserved "__" prefix.
+func (l *LabelSet) Set(value string) error {
+	s := *l
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("label %q must be of the form name=value", pair)
+		}
+		name := strings.TrimSpace(kv[0])
+		if !labelNameRegexp.MatchString(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+		if _, ok := s[name]; ok {
+			return fmt.Errorf("duplicate label name %q", name)
+		}
+		s[name] = kv[1]
+	}
+	return nil
+}
+
+func (l LabelSet) Type() string {
+	return "string"
+}
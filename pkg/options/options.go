@@ -7,6 +7,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	"k8s.io/klog/v2"
 	koptions "k8s.io/kube-state-metrics/pkg/options"
@@ -15,6 +16,7 @@ import (
 type Options struct {
 	Apiserver          string
 	Kubeconfig         string
+	KubeContexts       string
 	Help               bool
 	HTTPPort           int
 	HTTPSPort          int
@@ -30,6 +32,43 @@ type Options struct {
 	MetricWhitelist    koptions.MetricSet
 	Version            bool
 
+	MaxLabelValueLength int
+	ConstLabels         LabelSet
+	MetricsCacheTTL     time.Duration
+
+	ProviderClusterIDClaim string
+	AutoscalerClaim        string
+	FIPSClaim              string
+	EtcdEncryptionClaim    string
+	HubClusterID           string
+	ClusterSet             string
+	ClusterSelector        string
+	ClusterCreatedWithin   time.Duration
+	ExcludeVendors         string
+	ExcludeClouds          string
+	ClusterNameLabel       string
+	ListPageSize           int64
+	RequestTimeout         time.Duration
+	InstanceTypeMetrics    bool
+	SplitInfoMetrics       bool
+	RequiredAddOns         string
+	CapacityResources      string
+	CapacityViewMaxAge     time.Duration
+	MachineSetMetrics      bool
+	ClusterVersionViews    bool
+	CoreWorkerResource     string
+	SocketWorkerResource   string
+	MemoryWorkerResource   string
+	InfoLabels             string
+	APIURLLabel            bool
+	ClusterUIDLabel        bool
+	CollapsePrerelease     bool
+	CAPIClusterResource    string
+
+	PushgatewayURL      string
+	PushgatewayJob      string
+	PushgatewayInterval time.Duration
+
 	EnableGZIPEncoding bool
 }
 
@@ -38,6 +77,7 @@ func NewOptions() *Options {
 		Collectors:      koptions.CollectorSet{},
 		MetricWhitelist: koptions.MetricSet{},
 		MetricBlacklist: koptions.MetricSet{},
+		ConstLabels:     LabelSet{},
 	}
 }
 
@@ -56,6 +96,7 @@ func (o *Options) AddFlags() {
 
 	flag.StringVar(&o.Apiserver, "apiserver", "", `The URL of the apiserver to use as a master`)
 	flag.StringVar(&o.Kubeconfig, "csm-kubeconfig", "", "Absolute path to the kubeconfig file")
+	flag.StringVar(&o.KubeContexts, "kube-contexts", "", "Comma-separated list of the kubeconfig contexts of the hubs to collect. Defaults to the current context")
 	flag.BoolVar(&o.Help, "help", false, "Print Help text")
 	flag.IntVar(&o.HTTPPort, "http-port", 8080, `http Port to expose metrics on.`)
 	flag.IntVar(&o.HTTPSPort, "https-port", 8443, `https Port to expose metrics on.`)
@@ -70,6 +111,40 @@ func (o *Options) AddFlags() {
 	flag.Var(&o.MetricWhitelist, "metric-whitelist", "Comma-separated list of metrics to be exposed. The whitelist and blacklist are mutually exclusive.")
 	flag.Var(&o.MetricBlacklist, "metric-blacklist", "Comma-separated list of metrics not to be enabled. The whitelist and blacklist are mutually exclusive.")
 	flag.BoolVar(&o.Version, "version", false, "openshift-state-metrics build version information")
+	flag.IntVar(&o.MaxLabelValueLength, "max-label-value-length", 0, "Maximum length of the label values, longer values are truncated and suffixed by '...'. Defaults to 0, no limit")
+	flag.DurationVar(&o.MetricsCacheTTL, "metrics-cache-ttl", 0, "Duration the serialized metrics are cached between scrapes, for example 30s. Defaults to 0, no cache")
+	flag.StringVar(&o.ProviderClusterIDClaim, "provider-cluster-id-claim", "", "Name of the cluster claim holding the cloud provider cluster id, exposed in the provider_cluster_id label of acm_managed_cluster_info. Defaults to no label")
+	flag.StringVar(&o.AutoscalerClaim, "autoscaler-claim", "", "Name of the cluster claim reporting with true or false if the cluster autoscaler is enabled, exposed by acm_managed_cluster_autoscaler_enabled. Defaults to no metric")
+	flag.StringVar(&o.FIPSClaim, "fips-claim", "", "Name of the cluster claim reporting with true or false if the FIPS mode is enabled, exposed by acm_managed_cluster_fips. Defaults to no metric")
+	flag.StringVar(&o.EtcdEncryptionClaim, "etcd-encryption-claim", "", "Name of the cluster claim reporting with true or false if the etcd encryption is enabled, exposed by acm_managed_cluster_etcd_encryption_enabled. Defaults to no metric")
+	flag.StringVar(&o.HubClusterID, "hub-cluster-id", "", "Value of the hub_cluster_id label of all the series, overriding the spec.clusterID of the ClusterVersion of the hub, or the uid of its kube-system namespace without ClusterVersion. Defaults to the id read from the hub")
+	flag.StringVar(&o.ClusterSet, "clusterset", "", "Name of the ManagedClusterSet to restrict the collection to its member clusters. Defaults to all the clusters")
+	flag.StringVar(&o.ClusterSelector, "cluster-selector", "", "Label selector of the ManagedClusters to collect, for example shard=a. Only the matching ManagedClusters are listed and watched, so the fleet can be shared by several instances. Defaults to all the clusters")
+	flag.DurationVar(&o.ClusterCreatedWithin, "cluster-created-within", 0, "Duration restricting the collection to the ManagedClusters created within it before the scrape, for example 168h for the last week. Defaults to 0, all the clusters")
+	flag.StringVar(&o.ExcludeVendors, "exclude-vendors", "", "Comma-separated list of the kube vendors of the clusters excluded from the collection, for example EKS,AKS. Defaults to none")
+	flag.StringVar(&o.ExcludeClouds, "exclude-clouds", "", "Comma-separated list of the cloud vendors of the clusters excluded from the collection, for example Azure,GCP. Defaults to none")
+	flag.StringVar(&o.ClusterNameLabel, "cluster-name-label", "", "Label of the ManagedClusterInfos overriding the name of their ManagedCluster, for the ManagedClusterInfos not named after their cluster in the cluster namespace. Defaults to the OCM convention only")
+	flag.Int64Var(&o.ListPageSize, "list-page-size", 500, "Number of objects requested per page when listing the resources on startup, 0 lets the apiserver serve the whole list at once from its watch cache")
+	flag.DurationVar(&o.RequestTimeout, "request-timeout", 10*time.Second, "Timeout of the lists and the gets sent to the apiserver, the watches are not bounded")
+	flag.BoolVar(&o.InstanceTypeMetrics, "instance-type-metrics", false, "Expose acm_managed_cluster_cpu_by_instance_type, a series per instance type of each cluster. Defaults to false")
+	flag.BoolVar(&o.SplitInfoMetrics, "split-info-metrics", false, "Expose acm_managed_cluster_version_info, acm_managed_cluster_capacity_info and acm_managed_cluster_provenance_info, the labels of acm_managed_cluster_info split by topic. Defaults to false")
+	flag.StringVar(&o.RequiredAddOns, "required-addons", "", "Comma-separated list of the addons expected on all the clusters, the fleet collector exposes acm_managed_cluster_missing_required_addon for the clusters missing one of them")
+	flag.BoolVar(&o.APIURLLabel, "api-url-label", false, "Expose the URL of the kube-apiserver of the managed clusters in the api_url label of acm_managed_cluster_info. Defaults to false")
+	flag.BoolVar(&o.CollapsePrerelease, "collapse-prerelease-versions", false, "Collapse the OpenShift nightly and CI builds to their version and stream in the version label of acm_managed_cluster_info, for example 4.13.0-nightly. Defaults to false")
+	flag.BoolVar(&o.ClusterUIDLabel, "cluster-uid-label", false, "Expose the uid of the ManagedClusters in the managed_cluster_uid label of acm_managed_cluster_info, to tell apart the clusters recreated with the same name. Defaults to false")
+	flag.StringVar(&o.InfoLabels, "info-labels", "", "Comma-separated list of the labels of acm_managed_cluster_info to expose, for example vendor,cloud,version. hub_cluster_id and managed_cluster_id are always exposed. Defaults to all the labels")
+	flag.StringVar(&o.CapacityResources, "capacity-resources", "", "Comma-separated list of the ManagedCluster capacity resources exposed by acm_managed_cluster_capacity, for example example.com/fpga. Defaults to none")
+	flag.DurationVar(&o.CapacityViewMaxAge, "capacity-view-max-age", 0, "Age of the ManagedClusterInfos after which the capacity of their nodes is read from ManagedClusterViews created by the exporter, for example 30m. Defaults to 0, no views")
+	flag.BoolVar(&o.MachineSetMetrics, "machineset-metrics", false, "Expose acm_managed_cluster_machineset_count, read from a ManagedClusterView created by the exporter per node of the OpenShift clusters. Defaults to false")
+	flag.BoolVar(&o.ClusterVersionViews, "clusterversion-views", false, "Expose acm_managed_cluster_last_upgrade_timestamp_seconds, read from a ManagedClusterView created by the exporter of the ClusterVersion of the OpenShift clusters. Defaults to false")
+	flag.StringVar(&o.CoreWorkerResource, "core-worker-resource", "core_worker", "Name of the ManagedCluster capacity resource holding the worker cores, as written by the registration agent")
+	flag.StringVar(&o.SocketWorkerResource, "socket-worker-resource", "socket_worker", "Name of the ManagedCluster capacity resource holding the worker sockets, as written by the registration agent")
+	flag.StringVar(&o.MemoryWorkerResource, "memory-worker-resource", "memory_worker", "Name of the ManagedCluster capacity resource holding the worker memory, as written by the registration agent")
+	flag.StringVar(&o.CAPIClusterResource, "capi-cluster-resource", "", "Resource of the Cluster API Clusters as resource.version.group, for example clusters.v1beta1.cluster.x-k8s.io. The clusters having one in their namespace are exposed with created_via CAPI. Defaults to no detection")
+	flag.StringVar(&o.PushgatewayURL, "pushgateway-url", "", "URL of a Prometheus Pushgateway the metrics are pushed to, in addition to be served. Defaults to no push")
+	flag.StringVar(&o.PushgatewayJob, "pushgateway-job", "clusterlifecycle-state-metrics", "Job name of the metrics pushed to the Pushgateway")
+	flag.DurationVar(&o.PushgatewayInterval, "pushgateway-interval", time.Minute, "Interval between two pushes to the Pushgateway")
+	flag.Var(&o.ConstLabels, "const-labels", "Comma-separated list of name=value labels added to all the series, for example environment=production,datacenter=east")
 
 	flag.BoolVar(&o.EnableGZIPEncoding, "enable-gzip-encoding", false, "Gzip responses when requested by clients via 'Accept-Encoding: gzip' header.")
 	klog.Info("End add args")